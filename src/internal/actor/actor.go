@@ -0,0 +1,20 @@
+package actor
+
+import "context"
+
+type contextKey string
+
+const userIdKey contextKey = "user_id"
+
+// ContextWithUserId adds id of the user performing the request to context
+func ContextWithUserId(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userIdKey, userId)
+}
+
+// UserIdFromContext returns id of the user performing the request, empty for system actions
+func UserIdFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(userIdKey).(string); ok {
+		return id
+	}
+	return ""
+}
@@ -2,10 +2,12 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,12 +17,27 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	"github.com/slava-911/test-task-0723/internal/broker"
+	"github.com/slava-911/test-task-0723/internal/broker/kafka"
+	"github.com/slava-911/test-task-0723/internal/broker/memory"
+	"github.com/slava-911/test-task-0723/internal/broker/nats"
 	"github.com/slava-911/test-task-0723/internal/config"
 	"github.com/slava-911/test-task-0723/internal/controller/http/handler"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
 	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/idempotency"
 	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/internal/notification"
+	"github.com/slava-911/test-task-0723/internal/notification/mailbox"
+	"github.com/slava-911/test-task-0723/internal/notification/smtp"
+	"github.com/slava-911/test-task-0723/internal/payment"
+	"github.com/slava-911/test-task-0723/internal/payment/fake"
+	"github.com/slava-911/test-task-0723/internal/realtime"
 	"github.com/slava-911/test-task-0723/internal/storage"
 	"github.com/slava-911/test-task-0723/pkg/cache/freecache"
 	"github.com/slava-911/test-task-0723/pkg/logging"
@@ -28,6 +45,9 @@ import (
 	"github.com/slava-911/test-task-0723/pkg/postgresql"
 )
 
+// allowedOrigins are the origins of the frontends allowed to call the API from browsers
+var allowedOrigins = []string{"http://localhost:3000", "http://localhost:8080"}
+
 func LaunchApp(ctx context.Context, cfg *config.Config) {
 	logger := logging.LoggerFromContext(ctx)
 
@@ -57,34 +77,173 @@ func LaunchApp(ctx context.Context, cfg *config.Config) {
 	logger.Info("setup handlers and routes")
 	metric.Register(e, cfg.App.Name)
 
+	notificationRenderer, err := notification.NewRenderer()
+	if err != nil {
+		logger.WithError(err).Fatal("failed to load notification templates")
+	}
+	notificationSender, err := newNotificationSender(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to create notification sender")
+	}
+
 	userStorage := storage.NewUserStorage(dbClient, logger)
-	userService := service.NewUserService(userStorage, logger)
-	userHandler := handler.NewUserHandler(userService, jwtHelper, validateInst, logger)
-	userHandler.Register(e)
+	currencyStorage := storage.NewCurrencyStorage(dbClient, logger)
+	notificationStorage := storage.NewNotificationStorage(dbClient, logger)
+	notificationService := service.NewNotificationService(notificationStorage, userStorage, currencyStorage,
+		notificationRenderer, notificationSender, cfg.Notifications.MaxAttempts, cfg.Notifications.RetryDelay, logger)
+	userService := service.NewUserService(userStorage, notificationService, logger)
+
+	logger.Info("admin user initialization")
+	if err = createAdminUser(ctx, cfg, userStorage); err != nil {
+		logger.WithError(err).Fatal("failed to create admin user")
+	}
+
+	taxStorage := storage.NewTaxStorage(dbClient, logger)
+	taxService := service.NewTaxService(taxStorage, logger)
+	taxHandler := handler.NewTaxHandler(taxService, validateInst, logger)
+	taxHandler.Register(e)
+
+	shippingStorage := storage.NewShippingStorage(dbClient, logger)
 
+	invoiceSeller := dmodel.InvoiceParty{
+		Name:    cfg.Invoice.Seller.Name,
+		Address: cfg.Invoice.Seller.Address,
+		TaxId:   cfg.Invoice.Seller.TaxId,
+		Email:   cfg.Invoice.Seller.Email,
+	}
 	orderStorage := storage.NewOrderStorage(dbClient, logger)
-	orderService := service.NewOrderService(orderStorage, logger)
+	orderService := service.NewOrderService(orderStorage, taxStorage, shippingStorage, notificationService,
+		cfg.Orders.ReservationTTL, cfg.Tax.PricesIncludeTax, invoiceSeller, cfg.Invoice.NumberPrefix, logger)
 	orderHandler := handler.NewOrderHandler(orderService, logger)
 	orderHandler.Register(e)
 
+	addressRules, err := loadAddressRules(cfg.Addresses.RulesFile, validateInst)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to load address rules")
+	}
+	addressStorage := storage.NewAddressStorage(dbClient, logger)
+	addressService, err := service.NewAddressService(addressStorage, addressRules.ToAddressRules(),
+		addressRules.OnlyListedCountries, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to create address service")
+	}
+	addressHandler := handler.NewAddressHandler(addressService, validateInst, logger)
+	addressHandler.Register(e)
+
+	shippingService := service.NewShippingService(shippingStorage, orderService, addressService, logger)
+	shippingHandler := handler.NewShippingHandler(shippingService, validateInst, logger)
+	shippingHandler.Register(e)
+
+	cartStorage := storage.NewCartStorage(dbClient, logger)
+	cartService := service.NewCartService(cartStorage, orderService, logger)
+	cartHandler := handler.NewCartHandler(cartService, validateInst, logger)
+	cartHandler.Register(e)
+
+	userHandler := handler.NewUserHandler(userService, cartService, jwtHelper, validateInst, logger)
+	userHandler.Register(e)
+
 	productStorage := storage.NewProductStorage(dbClient, logger)
 	productService := service.NewProductService(productStorage, logger)
-	productHandler := handler.NewProductHandler(productService, logger)
+	productHandler := handler.NewProductHandler(productService, validateInst, logger)
 	productHandler.Register(e)
 
+	stockStorage := storage.NewStockStorage(dbClient, logger)
+	stockService := service.NewStockService(stockStorage, logger)
+	stockHandler := handler.NewStockHandler(stockService, validateInst, logger)
+	stockHandler.Register(e)
+
+	warehouseStorage := storage.NewWarehouseStorage(dbClient, logger)
+	warehouseService := service.NewWarehouseService(warehouseStorage, logger)
+	warehouseHandler := handler.NewWarehouseHandler(warehouseService, validateInst, logger)
+	warehouseHandler.Register(e)
+
+	currencyService := service.NewCurrencyService(currencyStorage, logger)
+	currencyHandler := handler.NewCurrencyHandler(currencyService, validateInst, logger)
+	currencyHandler.Register(e)
+
+	if cfg.Currency.RatesFile != "" {
+		logger.Info("exchange rates initialization")
+		if err = loadExchangeRates(ctx, cfg.Currency.RatesFile, validateInst, currencyService); err != nil {
+			logger.WithError(err).Fatal("failed to load exchange rates")
+		}
+	}
+
+	promotionStorage := storage.NewPromotionStorage(dbClient, logger)
+	promotionService := service.NewPromotionService(promotionStorage, logger)
+	promotionHandler := handler.NewPromotionHandler(promotionService, validateInst, logger)
+	promotionHandler.Register(e)
+
+	paymentProvider, err := newPaymentProvider(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to create payment provider")
+	}
+	paymentStorage := storage.NewPaymentStorage(dbClient, logger)
+	paymentService := service.NewPaymentService(paymentStorage, paymentProvider, logger)
+	paymentHandler := handler.NewPaymentHandler(paymentService, validateInst, logger)
+	paymentHandler.Register(e)
+
+	returnStorage := storage.NewReturnStorage(dbClient, logger)
+	returnService := service.NewReturnService(returnStorage, logger)
+	returnHandler := handler.NewReturnHandler(returnService, validateInst, logger)
+	returnHandler.Register(e)
+
+	shipmentStorage := storage.NewShipmentStorage(dbClient, logger)
+	shipmentService := service.NewShipmentService(shipmentStorage, orderService, notificationService, logger)
+	shipmentHandler := handler.NewShipmentHandler(shipmentService, validateInst, logger)
+	shipmentHandler.Register(e)
+
+	invoiceStorage := storage.NewInvoiceStorage(dbClient, logger)
+	invoiceService := service.NewInvoiceService(invoiceStorage, orderService, logger)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService, logger)
+	invoiceHandler.Register(e)
+
+	webhookStorage := storage.NewWebhookStorage(dbClient, logger)
+	webhookService := service.NewWebhookService(webhookStorage, cfg.Webhooks.Timeout, cfg.Webhooks.MaxAttempts,
+		cfg.Webhooks.RetryDelay, logger)
+	webhookHandler := handler.NewWebhookHandler(webhookService, validateInst, logger)
+	webhookHandler.Register(e)
+
+	eventBroker, err := newEventBroker(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to create event broker")
+	}
+	defer eventBroker.Close()
+	outboxStorage := storage.NewOutboxStorage(dbClient, logger)
+	outboxService := service.NewOutboxService(outboxStorage, eventBroker, logger)
+
+	orderChangesHub := realtime.NewHub()
+	orderChangesListener := realtime.NewListener(dbClient, orderChangesHub, cfg.Realtime.ReconnectDelay, logger)
+	orderStreamService := service.NewOrderStreamService(orderService, orderChangesHub, cfg.Realtime.PingInterval, logger)
+	orderStreamHandler := handler.NewOrderStreamHandler(orderStreamService, allowedOrigins, logger)
+	orderStreamHandler.Register(e)
+
+	idempotencyStorage := storage.NewIdempotencyStorage(dbClient, logger)
+	idempotencyService := service.NewIdempotencyService(idempotencyStorage, cfg.Idempotency.TTL,
+		cfg.Idempotency.LockTimeout, logger)
+
+	graphqlHandler, err := handler.NewGraphqlHandler(userService, orderService, productService, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to create graphql handler")
+	}
+	graphqlHandler.Register(e)
+
 	// Rate Limiter
 	e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(10)))
 
 	// CORS
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:3000", "http://localhost:8080"},
+		AllowOrigins: allowedOrigins,
 		AllowMethods: []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodPatch, http.MethodDelete},
 		AllowHeaders: []string{"Authorization", "Location", "Charset", "Access-Control-Allow-Origin", "Content-Type",
-			"content-type", "Origin", "Accept", "Content-Length", "Accept-Encoding", "X-CSRF-Token"},
-		ExposeHeaders:    []string{"Location", "Authorization", "Content-Disposition"},
+			"content-type", "Origin", "Accept", "Content-Length", "Accept-Encoding", "X-CSRF-Token",
+			"X-Cart-Token", idempotency.HeaderKey},
+		ExposeHeaders:    []string{"Location", "Authorization", "Content-Disposition", idempotency.HeaderReplayed},
 		AllowCredentials: true,
 	}))
 
+	// Idempotency keys of mutating requests
+	e.Use(idempotency.Middleware(idempotencyService, logger))
+
 	logger.WithFields(map[string]any{
 		"IP":   cfg.HTTP.IP,
 		"Port": cfg.HTTP.Port,
@@ -100,9 +259,18 @@ func LaunchApp(ctx context.Context, cfg *config.Config) {
 		WriteTimeout: cfg.HTTP.WriteTimeout,
 		ReadTimeout:  cfg.HTTP.ReadTimeout,
 	}
+	// order streams are long-lived requests, they end when the hub is closed so the shutdown does not wait for them
+	httpServer.RegisterOnShutdown(orderChangesHub.Close)
 
 	logger.Info("application completely initialized and started")
 
+	go runReservationSweeper(ctx, orderService, cfg.Orders.ReservationSweepInterval)
+	go runNotificationDispatcher(ctx, notificationService, cfg.Notifications.PollInterval)
+	go runOutboxRelay(ctx, outboxService, cfg.Outbox.PollInterval, cfg.Outbox.Retention)
+	go runWebhookDispatcher(ctx, webhookService, cfg.Webhooks.PollInterval)
+	go orderChangesListener.Run(ctx)
+	go runIdempotencyKeysCleanup(ctx, idempotencyService, cfg.Idempotency.CleanupInterval)
+
 	go handleGracefulShutdown(ctx, dbClient, httpServer)
 
 	if err = httpServer.Serve(listener); err != nil {
@@ -121,6 +289,123 @@ func getDatabaseConnectionString(cfg *config.Config) string {
 		cfg.DB.Type, cfg.DB.Username, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name)
 }
 
+// createAdminUser creates the admin user from config if it does not exist yet.
+// An existing user with the admin email is promoted to admin.
+func createAdminUser(ctx context.Context, cfg *config.Config, userStorage storage.UserStorage) error {
+	logger := logging.LoggerFromContext(ctx)
+	u, err := userStorage.FindOneByEmail(ctx, cfg.App.AdminUser.Email)
+	if err == nil {
+		if !u.IsAdmin {
+			logger.Warnf("user %s with the admin email %s is promoted to admin", u.Id, u.Email)
+			return userStorage.SetAdmin(ctx, u.Id)
+		}
+		return nil
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return err
+	}
+	if cfg.App.AdminUser.Password == "" {
+		logger.Warn("admin password is not set, the admin user is not created")
+		return nil
+	}
+
+	admin := &dmodel.User{
+		Id:        uuid.New().String(),
+		FirstName: "admin",
+		LastName:  "admin",
+		Email:     cfg.App.AdminUser.Email,
+		Password:  cfg.App.AdminUser.Password,
+		Locale:    dmodel.DefaultLocale,
+		IsAdmin:   true,
+	}
+	if err = admin.GeneratePasswordHash(); err != nil {
+		return err
+	}
+	_, err = userStorage.Create(ctx, admin)
+	return err
+}
+
+// loadExchangeRates sets exchange rates from the JSON file in the format of the admin endpoint
+func loadExchangeRates(ctx context.Context, path string, v *validator.Validate, currencyService service.CurrencyService) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var req cmodel.SetExchangeRatesDTO
+	if err = json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("failed to decode exchange rates file %s: %w", path, err)
+	}
+	if err = v.Struct(req); err != nil {
+		return fmt.Errorf("invalid exchange rates file %s: %w", path, err)
+	}
+	return currencyService.SetRates(ctx, &req)
+}
+
+// loadAddressRules reads the per-country address rules, there are no rules if path is empty
+func loadAddressRules(path string, v *validator.Validate) (r cmodel.AddressRulesDTO, err error) {
+	if path == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err = json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("failed to decode address rules file %s: %w", path, err)
+	}
+	if err = v.Struct(r); err != nil {
+		return r, fmt.Errorf("invalid address rules file %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// newPaymentProvider returns the payment provider configured for the app
+func newPaymentProvider(cfg *config.Config) (payment.PaymentProvider, error) {
+	switch cfg.Payments.Provider {
+	case "fake":
+		// the webhook route has no other authorization, unsigned events could mark any order paid
+		if cfg.Payments.WebhookSecret == "" {
+			return nil, errors.New("payments webhook secret is not set")
+		}
+		return fake.NewProvider(cfg.Payments.WebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Payments.Provider)
+	}
+}
+
+func newEventBroker(cfg *config.Config) (broker.Broker, error) {
+	o := cfg.Outbox
+	switch o.Broker {
+	case "memory":
+		return memory.NewBroker(), nil
+	case "kafka":
+		if len(o.Kafka.Brokers) == 0 {
+			return nil, errors.New("kafka brokers are not set")
+		}
+		return kafka.NewBroker(o.Kafka.Brokers, o.Kafka.Topic), nil
+	case "nats":
+		return nats.NewBroker(o.NATS.URL, o.NATS.SubjectPrefix)
+	default:
+		return nil, fmt.Errorf("unknown event broker %q", o.Broker)
+	}
+}
+
+func newNotificationSender(cfg *config.Config) (notification.Sender, error) {
+	n := cfg.Notifications
+	switch n.Sender {
+	case "mailbox":
+		return mailbox.NewSender(n.MailboxDir, n.From)
+	case "smtp":
+		if n.SMTP.Host == "" {
+			return nil, errors.New("smtp host is not set")
+		}
+		return smtp.NewSender(n.SMTP.Host, n.SMTP.Port, n.SMTP.Username, n.SMTP.Password, n.From), nil
+	default:
+		return nil, fmt.Errorf("unknown notification sender %q", n.Sender)
+	}
+}
+
 func runMigrations(migrationsPath, dbDSN string) error {
 	m, err := migrate.New(migrationsPath, dbDSN+"?sslmode=disable")
 	if err != nil {
@@ -135,19 +420,19 @@ func runMigrations(migrationsPath, dbDSN string) error {
 
 func loggerConfigurationForEcho(e *echo.Echo, logger *logging.Logger) {
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-		LogURI:      true,
 		LogStatus:   true,
 		LogError:    true,
 		HandleError: true, // forwards error to the global error handler, so it can decide appropriate status code
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			uri := loggedURI(c.Request().URL)
 			if v.Error == nil {
 				logger.WithFields(map[string]any{
-					"URI":    v.URI,
+					"URI":    uri,
 					"status": v.Status,
 				}).Info("request")
 			} else {
 				logger.WithFields(map[string]any{
-					"URI":    v.URI,
+					"URI":    uri,
 					"status": v.Status,
 					"error":  v.Error,
 				}).Error("request error")
@@ -157,6 +442,18 @@ func loggerConfigurationForEcho(e *echo.Echo, logger *logging.Logger) {
 	}))
 }
 
+// loggedURI returns the request URI with the token of stream requests redacted, so it does not get into logs
+func loggedURI(u *url.URL) string {
+	q := u.Query()
+	if !q.Has(jwt.AccessTokenParam) {
+		return u.RequestURI()
+	}
+	q.Set(jwt.AccessTokenParam, "REDACTED")
+	redacted := *u
+	redacted.RawQuery = q.Encode()
+	return redacted.RequestURI()
+}
+
 func handleGracefulShutdown(ctx context.Context, db postgresql.Client, httpServer *http.Server) {
 	// Wait for interrupt signal to gracefully shutdown the server with a timeout of 10 seconds.
 	// Use a buffered channel to avoid missing signals as recommended for signal.Notify
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+// runReservationSweeper periodically releases stock reserved by abandoned orders.
+// It is safe to run in every app replica, the storage skips orders released by other replicas.
+func runReservationSweeper(ctx context.Context, orderService service.OrderService, interval time.Duration) {
+	logger := logging.LoggerFromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// the error lists the orders failed to be released, the other orders are released anyway
+			released, err := orderService.ReleaseExpiredReservations(ctx)
+			if err != nil {
+				logger.WithError(err).Error("failed to release expired reservations")
+			}
+			if released > 0 {
+				logger.Infof("released reservations of %d orders", released)
+			}
+		}
+	}
+}
+
+// runNotificationDispatcher periodically sends queued emails until there are no due ones left.
+// It is safe to run in every app replica, a notification is claimed by one replica at a time.
+func runNotificationDispatcher(ctx context.Context, notificationService service.NotificationService, interval time.Duration) {
+	logger := logging.LoggerFromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				sent, err := notificationService.DeliverDue(ctx)
+				if err != nil {
+					logger.WithError(err).Error("failed to deliver notifications")
+					break
+				}
+				if sent == 0 {
+					break
+				}
+				logger.Infof("sent %d notifications", sent)
+			}
+		}
+	}
+}
+
+// runOutboxRelay publishes outbox events to the broker until there are none left every interval and
+// deletes events published more than retention ago once an hour. It is safe to run in every app replica,
+// one relay publishes at a time.
+func runOutboxRelay(ctx context.Context, outboxService service.OutboxService, interval, retention time.Duration) {
+	logger := logging.LoggerFromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				published, err := outboxService.Relay(ctx)
+				if err != nil {
+					logger.WithError(err).Error("failed to relay outbox events")
+					break
+				}
+				if published == 0 {
+					break
+				}
+				logger.Debugf("published %d outbox events", published)
+			}
+		case <-cleanup.C:
+			deleted, err := outboxService.Cleanup(ctx, retention)
+			if err != nil {
+				logger.WithError(err).Error("failed to clean up outbox")
+				continue
+			}
+			if deleted > 0 {
+				logger.Infof("deleted %d published outbox events", deleted)
+			}
+		}
+	}
+}
+
+// runWebhookDispatcher periodically sends due webhook deliveries until there are none left.
+// It is safe to run in every app replica, a delivery is claimed by one replica at a time.
+func runWebhookDispatcher(ctx context.Context, webhookService service.WebhookService, interval time.Duration) {
+	logger := logging.LoggerFromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				sent, err := webhookService.DeliverDue(ctx)
+				if err != nil {
+					logger.WithError(err).Error("failed to deliver webhooks")
+					break
+				}
+				if sent == 0 {
+					break
+				}
+				logger.Infof("delivered %d webhooks", sent)
+			}
+		}
+	}
+}
+
+// runIdempotencyKeysCleanup periodically deletes expired idempotency keys.
+// It is safe to run in every app replica.
+func runIdempotencyKeysCleanup(ctx context.Context, idempotencyService service.IdempotencyService, interval time.Duration) {
+	logger := logging.LoggerFromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := idempotencyService.Cleanup(ctx)
+			if err != nil {
+				logger.WithError(err).Error("failed to clean up idempotency keys")
+				continue
+			}
+			if deleted > 0 {
+				logger.Infof("deleted %d expired idempotency keys", deleted)
+			}
+		}
+	}
+}
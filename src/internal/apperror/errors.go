@@ -3,5 +3,30 @@ package apperror
 import "errors"
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound            = errors.New("not found")
+	ErrInsufficientStock   = errors.New("insufficient quantity in stock")
+	ErrUnsupportedCurrency = errors.New("currency has no exchange rate")
+	ErrBaseCurrencyRate    = errors.New("rate of the base currency must be 1")
+	ErrCouponNotApplicable = errors.New("coupon can't be applied to the order")
+	ErrAlreadyExists       = errors.New("already exists")
+	ErrOrderCompleted      = errors.New("order is completed")
+	ErrEmptyOrder          = errors.New("order is empty")
+	ErrPricesChanged       = errors.New("prices of the order products changed")
+	ErrOrderNotCheckedOut  = errors.New("order is not checked out")
+	ErrOrderPaid           = errors.New("order is already paid")
+	ErrNothingToPay        = errors.New("order total is zero")
+	ErrPaymentState        = errors.New("operation is not allowed in the payment status")
+	ErrReturnState         = errors.New("operation is not allowed in the return status")
+	ErrReturnQuantity      = errors.New("return quantity exceeds the quantity not returned yet")
+	ErrRefundAmount        = errors.New("refund amount exceeds the refundable amount")
+	ErrCaptureAmount       = errors.New("captured amount must exceed the amount captured before and not exceed the payment amount")
+	ErrEmptyCart           = errors.New("cart is empty")
+	ErrInvalidAddress      = errors.New("address is invalid")
+	ErrShippingUnavailable = errors.New("order can't be shipped with the shipping method")
+	ErrShippingAddressSet  = errors.New("shipping region is taken from the shipping address of the order")
+	ErrOrderNotPaid        = errors.New("order is not paid")
+	ErrShipmentQuantity    = errors.New("shipment quantity exceeds the quantity not shipped yet")
+	ErrShipmentState       = errors.New("operation is not allowed in the shipment status")
+	ErrIdempotencyMismatch = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyConflict = errors.New("request with the idempotency key is in progress")
 )
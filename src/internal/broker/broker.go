@@ -0,0 +1,31 @@
+package broker
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a domain event as published to the broker. Events are delivered at least once,
+// consumers drop duplicates by Id.
+type Message struct {
+	Id   string
+	Type string
+	// Key is the id of the aggregate, events of one aggregate are kept in order by brokers that partition by key
+	Key        string
+	Payload    []byte
+	OccurredAt time.Time
+}
+
+// Broker publishes domain events to the rest of the platform
+type Broker interface {
+	// Publish publishes the messages in order, an error means some of them may have to be published again
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// Headers are the names of the headers carrying the message metadata in brokers with headers
+const (
+	HeaderEventId    = "Event-Id"
+	HeaderEventType  = "Event-Type"
+	HeaderOccurredAt = "Occurred-At"
+)
@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/slava-911/test-task-0723/internal/broker"
+)
+
+// Broker publishes messages to a Kafka topic partitioned by the message key
+type Broker struct {
+	writer *kafka.Writer
+}
+
+func NewBroker(brokers []string, topic string) *Broker {
+	return &Broker{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// the relay passes a batch at once and waits for it, so the writer must not wait for more
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (b *Broker) Publish(ctx context.Context, msgs []broker.Message) error {
+	kmsgs := make([]kafka.Message, 0, len(msgs))
+	for _, m := range msgs {
+		kmsgs = append(kmsgs, kafka.Message{
+			Key:   []byte(m.Key),
+			Value: m.Payload,
+			Headers: []kafka.Header{
+				{Key: broker.HeaderEventId, Value: []byte(m.Id)},
+				{Key: broker.HeaderEventType, Value: []byte(m.Type)},
+				{Key: broker.HeaderOccurredAt, Value: []byte(m.OccurredAt.UTC().Format(time.RFC3339Nano))},
+			},
+			Time: m.OccurredAt,
+		})
+	}
+	return b.writer.WriteMessages(ctx, kmsgs...)
+}
+
+func (b *Broker) Close() error {
+	return b.writer.Close()
+}
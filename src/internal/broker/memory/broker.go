@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/slava-911/test-task-0723/internal/broker"
+)
+
+// Broker keeps published messages in memory, it is meant for tests and local runs without a broker
+type Broker struct {
+	mu       sync.Mutex
+	messages []broker.Message
+	err      error
+}
+
+func NewBroker() *Broker {
+	return &Broker{}
+}
+
+func (b *Broker) Publish(_ context.Context, msgs []broker.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+	b.messages = append(b.messages, msgs...)
+	return nil
+}
+
+// Messages returns the published messages in the order they were published
+func (b *Broker) Messages() []broker.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := make([]broker.Message, len(b.messages))
+	copy(r, b.messages)
+	return r
+}
+
+// SetError makes the next publishes fail with err until it is reset with nil
+func (b *Broker) SetError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.err = err
+}
+
+func (b *Broker) Close() error {
+	return nil
+}
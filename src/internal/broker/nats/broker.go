@@ -0,0 +1,53 @@
+package nats
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/slava-911/test-task-0723/internal/broker"
+)
+
+// Broker publishes messages to JetStream on the subject <prefix>.<event type>. The stream capturing
+// the subjects is created by the NATS setup, the message id lets JetStream drop republished events.
+type Broker struct {
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	prefix string
+}
+
+func NewBroker(url, subjectPrefix string) (*Broker, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Broker{
+		conn:   conn,
+		js:     js,
+		prefix: subjectPrefix,
+	}, nil
+}
+
+func (b *Broker) Publish(ctx context.Context, msgs []broker.Message) error {
+	for _, m := range msgs {
+		nm := nats.NewMsg(b.prefix + "." + m.Type)
+		nm.Data = m.Payload
+		nm.Header.Set(nats.MsgIdHdr, m.Id)
+		nm.Header.Set(broker.HeaderEventId, m.Id)
+		nm.Header.Set(broker.HeaderEventType, m.Type)
+		nm.Header.Set(broker.HeaderOccurredAt, m.OccurredAt.UTC().Format(time.RFC3339Nano))
+		if _, err := b.js.PublishMsg(nm, nats.Context(ctx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Broker) Close() error {
+	return b.conn.Drain()
+}
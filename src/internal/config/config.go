@@ -18,9 +18,11 @@ type Config struct {
 		Name           string `yaml:"name" env:"NAME" env-default:"app"`
 		LogLevel       string `yaml:"log-level" env:"LOG_LEVEL" env-default:"trace"`
 		MigrationsPath string `yaml:"migrations-path" env:"MIGRATIONS_PATH" env-default:"file:///migrations"`
-		AdminUser      struct {
+		// AdminUser is created on start, its password is not kept in config files and without it
+		// the admin user is not created
+		AdminUser struct {
 			Email    string `yaml:"email" env:"ADMIN_EMAIL" env-default:"admin"`
-			Password string `yaml:"password" env:"ADMIN_PWD" env-default:"admin"`
+			Password string `yaml:"password" env:"ADMIN_PWD"`
 		} `yaml:"admin"`
 	} `yaml:"app"`
 	HTTP struct {
@@ -32,6 +34,94 @@ type Config struct {
 	JWT struct {
 		Secret string `yaml:"secret" env:"JWT_SECRET"` //env-required:"true"`
 	} `yaml:"jwt"`
+	Orders struct {
+		ReservationTTL           time.Duration `yaml:"reservation-ttl" env:"ORDERS_RESERVATION_TTL" env-default:"30m"`
+		ReservationSweepInterval time.Duration `yaml:"reservation-sweep-interval" env:"ORDERS_RESERVATION_SWEEP_INTERVAL" env-default:"1m"`
+	} `yaml:"orders"`
+	Currency struct {
+		// RatesFile is a JSON file with exchange rates loaded on start, rates are not loaded if it is empty
+		RatesFile string `yaml:"rates-file" env:"CURRENCY_RATES_FILE"`
+	} `yaml:"currency"`
+	Addresses struct {
+		// RulesFile is a JSON file with per-country address validation rules, addresses are only checked
+		// for the required fields if it is empty
+		RulesFile string `yaml:"rules-file" env:"ADDRESSES_RULES_FILE"`
+	} `yaml:"addresses"`
+	Payments struct {
+		// Provider is the payment provider orders are paid through, only the local fake one is available now
+		Provider string `yaml:"provider" env:"PAYMENTS_PROVIDER" env-default:"fake"`
+		// WebhookSecret signs webhooks of the provider, the app does not start without it
+		WebhookSecret string `yaml:"webhook-secret" env:"PAYMENTS_WEBHOOK_SECRET"`
+	} `yaml:"payments"`
+	Invoice struct {
+		// NumberPrefix starts the invoice numbers, every prefix has its own gap-free sequence of numbers
+		NumberPrefix string `yaml:"number-prefix" env:"INVOICE_NUMBER_PREFIX" env-default:"INV-"`
+		// Seller is the company issuing invoices, its details are copied to an invoice when it is issued
+		Seller struct {
+			Name    string   `yaml:"name" env:"INVOICE_SELLER_NAME" env-default:"Shop"`
+			Address []string `yaml:"address" env:"INVOICE_SELLER_ADDRESS" env-separator:";"`
+			TaxId   string   `yaml:"tax-id" env:"INVOICE_SELLER_TAX_ID"`
+			Email   string   `yaml:"email" env:"INVOICE_SELLER_EMAIL"`
+		} `yaml:"seller"`
+	} `yaml:"invoice"`
+	Notifications struct {
+		// Sender is smtp or mailbox, the mailbox sender writes emails to files in MailboxDir instead of sending them
+		Sender     string `yaml:"sender" env:"NOTIFICATIONS_SENDER" env-default:"mailbox"`
+		From       string `yaml:"from" env:"NOTIFICATIONS_FROM" env-default:"Shop <no-reply@example.com>"`
+		MailboxDir string `yaml:"mailbox-dir" env:"NOTIFICATIONS_MAILBOX_DIR" env-default:"./mailbox"`
+		SMTP       struct {
+			Host     string `yaml:"host" env:"SMTP_HOST"`
+			Port     string `yaml:"port" env:"SMTP_PORT" env-default:"587"`
+			Username string `yaml:"username" env:"SMTP_USERNAME"`
+			Password string `yaml:"password" env:"SMTP_PASSWORD"`
+		} `yaml:"smtp"`
+		// MaxAttempts is the number of attempts to send an email before it fails, RetryDelay is the delay
+		// before the first retry, it doubles with every next one
+		MaxAttempts  int           `yaml:"max-attempts" env:"NOTIFICATIONS_MAX_ATTEMPTS" env-default:"8"`
+		RetryDelay   time.Duration `yaml:"retry-delay" env:"NOTIFICATIONS_RETRY_DELAY" env-default:"1m"`
+		PollInterval time.Duration `yaml:"poll-interval" env:"NOTIFICATIONS_POLL_INTERVAL" env-default:"10s"`
+	} `yaml:"notifications"`
+	Outbox struct {
+		// Broker is kafka, nats or memory, the memory broker keeps events in the app and is meant for tests
+		Broker string `yaml:"broker" env:"OUTBOX_BROKER" env-default:"memory"`
+		Kafka  struct {
+			Brokers []string `yaml:"brokers" env:"KAFKA_BROKERS" env-separator:","`
+			Topic   string   `yaml:"topic" env:"KAFKA_TOPIC" env-default:"shop.events"`
+		} `yaml:"kafka"`
+		NATS struct {
+			URL string `yaml:"url" env:"NATS_URL" env-default:"nats://localhost:4222"`
+			// SubjectPrefix starts the subjects of events, e.g. shop.events.order.created
+			SubjectPrefix string `yaml:"subject-prefix" env:"NATS_SUBJECT_PREFIX" env-default:"shop.events"`
+		} `yaml:"nats"`
+		PollInterval time.Duration `yaml:"poll-interval" env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+		// Retention is how long published events are kept in the outbox
+		Retention time.Duration `yaml:"retention" env:"OUTBOX_RETENTION" env-default:"168h"`
+	} `yaml:"outbox"`
+	Webhooks struct {
+		// Timeout is the time an endpoint has to respond, MaxAttempts is the number of attempts
+		// before a delivery is dead, RetryDelay is the delay before the first retry, it doubles with every next one
+		Timeout      time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" env-default:"10s"`
+		MaxAttempts  int           `yaml:"max-attempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"10"`
+		RetryDelay   time.Duration `yaml:"retry-delay" env:"WEBHOOKS_RETRY_DELAY" env-default:"30s"`
+		PollInterval time.Duration `yaml:"poll-interval" env:"WEBHOOKS_POLL_INTERVAL" env-default:"5s"`
+	} `yaml:"webhooks"`
+	Realtime struct {
+		// PingInterval is how often idle order streams are pinged, ReconnectDelay is the delay before
+		// listening to the order changes again after the database connection broke
+		PingInterval   time.Duration `yaml:"ping-interval" env:"REALTIME_PING_INTERVAL" env-default:"25s"`
+		ReconnectDelay time.Duration `yaml:"reconnect-delay" env:"REALTIME_RECONNECT_DELAY" env-default:"5s"`
+	} `yaml:"realtime"`
+	Idempotency struct {
+		// TTL is how long responses of requests with an Idempotency-Key are replayed, LockTimeout is how long
+		// a request holds its key, a retry after it runs again if the first request stored no response
+		TTL             time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+		LockTimeout     time.Duration `yaml:"lock-timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" env-default:"1m"`
+		CleanupInterval time.Duration `yaml:"cleanup-interval" env:"IDEMPOTENCY_CLEANUP_INTERVAL" env-default:"1h"`
+	} `yaml:"idempotency"`
+	Tax struct {
+		// PricesIncludeTax means product prices are gross and the tax is a part of them, otherwise it is added on top
+		PricesIncludeTax bool `yaml:"prices-include-tax" env:"TAX_PRICES_INCLUDE_TAX" env-default:"false"`
+	} `yaml:"tax"`
 	DB struct {
 		Type              string        `yaml:"type" env:"DB_TYPE" env-default:"postgresql"`
 		DSN               string        `yaml:"dsn" env:"POSTGRES_DSN"`
@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/pkg/dataloader"
+)
+
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+type loadersKey struct{}
+
+// loaders holds per-request batching loaders, so resolvers of sibling fields
+// share one query instead of querying the storage per entity
+type loaders struct {
+	users      *dataloader.Loader[string, dmodel.User]
+	products   *dataloader.Loader[string, dmodel.Product]
+	orderItems *dataloader.Loader[string, []dmodel.OrderItem]
+}
+
+// newLoaders creates loaders, products are loaded with prices in the currency
+func newLoaders(us service.UserService, os service.OrderService, ps service.ProductService, currency string) *loaders {
+	return &loaders{
+		users: dataloader.New(us.GetManyByIds, loaderWait, loaderMaxBatch),
+		products: dataloader.New(func(ctx context.Context, ids []string) (map[string]dmodel.Product, error) {
+			return ps.GetManyByIds(ctx, ids, currency)
+		}, loaderWait, loaderMaxBatch),
+		orderItems: dataloader.New(os.GetItemsByOrderIds, loaderWait, loaderMaxBatch),
+	}
+}
+
+// WithLoaders returns a context with a fresh set of loaders for one GraphQL request
+func WithLoaders(ctx context.Context, us service.UserService, os service.OrderService, ps service.ProductService, currency string) context.Context {
+	return context.WithValue(ctx, loadersKey{}, newLoaders(us, os, ps, currency))
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
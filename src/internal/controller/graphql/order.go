@@ -0,0 +1,244 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type orderResolver struct {
+	root  *Resolver
+	order dmodel.Order
+}
+
+func (r *orderResolver) Id() graphql.ID {
+	return graphql.ID(r.order.Id)
+}
+
+func (r *orderResolver) Number() *string {
+	if r.order.Number == "" {
+		return nil
+	}
+	return &r.order.Number
+}
+
+func (r *orderResolver) UserId() graphql.ID {
+	return graphql.ID(r.order.UserId)
+}
+
+func (r *orderResolver) User(ctx context.Context) (*userResolver, error) {
+	u, err := loadersFromContext(ctx).users.Load(ctx, r.order.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{root: r.root, user: u}, nil
+}
+
+func (r *orderResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.order.CreatedAt}
+}
+
+func (r *orderResolver) Completed() bool {
+	return r.order.Completed
+}
+
+func (r *orderResolver) CheckedOutAt() *graphql.Time {
+	if r.order.CheckedOutAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.order.CheckedOutAt}
+}
+
+func (r *orderResolver) PaidAt() *graphql.Time {
+	if r.order.PaidAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.order.PaidAt}
+}
+
+func (r *orderResolver) FulfillmentStatus() string {
+	return string(r.order.FulfillmentStatus)
+}
+
+func (r *orderResolver) Subtotal() *moneyResolver {
+	return &moneyResolver{money: r.order.Subtotal}
+}
+
+func (r *orderResolver) Discounts() []*discountResolver {
+	res := make([]*discountResolver, 0, len(r.order.Discounts))
+	for _, d := range r.order.Discounts {
+		res = append(res, &discountResolver{discount: d})
+	}
+	return res
+}
+
+func (r *orderResolver) ShippingRegion() string {
+	return r.order.ShippingRegion
+}
+
+func (r *orderResolver) ShippingAddress() *addressResolver {
+	if r.order.ShippingAddress == nil {
+		return nil
+	}
+	return &addressResolver{address: *r.order.ShippingAddress}
+}
+
+func (r *orderResolver) BillingAddress() *addressResolver {
+	if r.order.BillingAddress == nil {
+		return nil
+	}
+	return &addressResolver{address: *r.order.BillingAddress}
+}
+
+func (r *orderResolver) ShippingMethod() *string {
+	if r.order.ShippingMethod == "" {
+		return nil
+	}
+	return &r.order.ShippingMethod
+}
+
+func (r *orderResolver) Shipping() *moneyResolver {
+	return &moneyResolver{money: r.order.Shipping}
+}
+
+func (r *orderResolver) Tax() *moneyResolver {
+	return &moneyResolver{money: r.order.Tax}
+}
+
+func (r *orderResolver) PricesIncludeTax() bool {
+	return r.order.PricesIncludeTax
+}
+
+func (r *orderResolver) Total() *moneyResolver {
+	return &moneyResolver{money: r.order.Total}
+}
+
+func (r *orderResolver) Coupon() *string {
+	if r.order.Coupon == "" {
+		return nil
+	}
+	return &r.order.Coupon
+}
+
+func (r *orderResolver) Items(ctx context.Context) ([]*orderItemResolver, error) {
+	l := loadersFromContext(ctx)
+	if r.order.Items != nil {
+		l.orderItems.Prime(r.order.Id, r.order.Items)
+	}
+	items, err := l.orderItems.Load(ctx, r.order.Id)
+	if err != nil {
+		if isNotFound(err) {
+			return []*orderItemResolver{}, nil
+		}
+		return nil, err
+	}
+	res := make([]*orderItemResolver, 0, len(items))
+	for _, i := range items {
+		res = append(res, &orderItemResolver{item: i})
+	}
+	return res, nil
+}
+
+type orderItemResolver struct {
+	item dmodel.OrderItem
+}
+
+func (r *orderItemResolver) ProductId() graphql.ID {
+	return graphql.ID(r.item.ProductId)
+}
+
+func (r *orderItemResolver) Description() string {
+	return r.item.Description
+}
+
+func (r *orderItemResolver) Price() *moneyResolver {
+	return &moneyResolver{money: r.item.Price}
+}
+
+func (r *orderItemResolver) Quantity() int32 {
+	return int32(r.item.Quantity)
+}
+
+func (r *orderItemResolver) Total() *moneyResolver {
+	return &moneyResolver{money: r.item.Total}
+}
+
+func (r *orderItemResolver) Discount() *moneyResolver {
+	return &moneyResolver{money: r.item.Discount}
+}
+
+func (r *orderItemResolver) TaxRate() float64 {
+	return r.item.TaxRate
+}
+
+func (r *orderItemResolver) Tax() *moneyResolver {
+	return &moneyResolver{money: r.item.Tax}
+}
+
+func (r *orderItemResolver) Product(ctx context.Context) (*productResolver, error) {
+	p, err := loadersFromContext(ctx).products.Load(ctx, r.item.ProductId)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &productResolver{product: p}, nil
+}
+
+type discountResolver struct {
+	discount dmodel.OrderDiscount
+}
+
+func (r *discountResolver) PromotionId() graphql.ID {
+	return graphql.ID(r.discount.PromotionId)
+}
+
+func (r *discountResolver) Code() string {
+	return r.discount.Code
+}
+
+func (r *discountResolver) Name() string {
+	return r.discount.Name
+}
+
+func (r *discountResolver) Amount() *moneyResolver {
+	return &moneyResolver{money: r.discount.Amount}
+}
+
+type addressResolver struct {
+	address dmodel.OrderAddress
+}
+
+func (r *addressResolver) Name() string {
+	return r.address.Name
+}
+
+func (r *addressResolver) Line1() string {
+	return r.address.Line1
+}
+
+func (r *addressResolver) Line2() string {
+	return r.address.Line2
+}
+
+func (r *addressResolver) City() string {
+	return r.address.City
+}
+
+func (r *addressResolver) Region() string {
+	return r.address.Region
+}
+
+func (r *addressResolver) PostalCode() string {
+	return r.address.PostalCode
+}
+
+func (r *addressResolver) Country() string {
+	return r.address.Country
+}
+
+func (r *addressResolver) Phone() string {
+	return r.address.Phone
+}
@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"github.com/graph-gophers/graphql-go"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type productResolver struct {
+	product dmodel.Product
+}
+
+func (r *productResolver) Id() graphql.ID {
+	return graphql.ID(r.product.Id)
+}
+
+func (r *productResolver) Price() *moneyResolver {
+	return &moneyResolver{money: r.product.Price}
+}
+
+func (r *productResolver) Quantity() int32 {
+	return int32(r.product.Quantity)
+}
+
+func (r *productResolver) Description() string {
+	return r.product.Description
+}
+
+func (r *productResolver) Tags() []string {
+	if r.product.Tags == nil {
+		return []string{}
+	}
+	return r.product.Tags
+}
+
+type moneyResolver struct {
+	money dmodel.Money
+}
+
+func (r *moneyResolver) Amount() Int64 {
+	return Int64(r.money.Amount)
+}
+
+func (r *moneyResolver) Currency() string {
+	return r.money.Currency
+}
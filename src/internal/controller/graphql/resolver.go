@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/pkg/dataloader"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+type userIdKey struct{}
+
+// WithUserId returns a context with id of the authorized user
+func WithUserId(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userIdKey{}, userId)
+}
+
+func userIdFromContext(ctx context.Context) (string, error) {
+	userId, ok := ctx.Value(userIdKey{}).(string)
+	if !ok || userId == "" {
+		return "", errors.New("unauthorized")
+	}
+	return userId, nil
+}
+
+// Resolver is the root resolver of the GraphQL schema
+type Resolver struct {
+	userService    service.UserService
+	orderService   service.OrderService
+	productService service.ProductService
+	logger         *logging.Logger
+}
+
+func NewSchema(us service.UserService, os service.OrderService, ps service.ProductService, l *logging.Logger) (*graphql.Schema, error) {
+	r := &Resolver{
+		userService:    us,
+		orderService:   os,
+		productService: ps,
+		logger:         l,
+	}
+	return graphql.ParseSchema(schemaString, r)
+}
+
+// pageArgs are limit and offset arguments, defaults are set in the schema
+type pageArgs struct {
+	Limit  int32
+	Offset int32
+}
+
+func (r *Resolver) Me(ctx context.Context) (*userResolver, error) {
+	userId, err := userIdFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := loadersFromContext(ctx).users.Load(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{root: r, user: u}, nil
+}
+
+func (r *Resolver) Product(ctx context.Context, args struct{ Id graphql.ID }) (*productResolver, error) {
+	p, err := loadersFromContext(ctx).products.Load(ctx, string(args.Id))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &productResolver{product: p}, nil
+}
+
+func (r *Resolver) Order(ctx context.Context, args struct{ Id graphql.ID }) (*orderResolver, error) {
+	userId, err := userIdFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.orderService.GetOneById(ctx, string(args.Id))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if o.UserId != userId {
+		return nil, nil
+	}
+	return &orderResolver{root: r, order: o}, nil
+}
+
+func (r *Resolver) Orders(ctx context.Context, args pageArgs) ([]*orderResolver, error) {
+	userId, err := userIdFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.ordersByUserId(ctx, userId, args)
+}
+
+func (r *Resolver) ordersByUserId(ctx context.Context, userId string, args pageArgs) ([]*orderResolver, error) {
+	// items are loaded to price the orders anyway, they carry the discounts and taxes of the lines
+	resp, err := r.orderService.GetAllByUserId(ctx, userId, int(args.Limit), int(args.Offset), true)
+	if err != nil {
+		if isNotFound(err) {
+			return []*orderResolver{}, nil
+		}
+		return nil, err
+	}
+	orders := make([]*orderResolver, 0, len(resp.Orders))
+	for _, o := range resp.Orders {
+		orders = append(orders, &orderResolver{root: r, order: o})
+	}
+	return orders, nil
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, apperror.ErrNotFound) || errors.Is(err, dataloader.ErrNoResult)
+}
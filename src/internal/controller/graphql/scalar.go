@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Int64 is a 64-bit integer, the built-in Int has 32 bits and can't hold amounts in minor units
+// above about 21 million. It is written as a JSON number.
+type Int64 int64
+
+func (Int64) ImplementsGraphQLType(name string) bool {
+	return name == "Int64"
+}
+
+func (i *Int64) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case int32:
+		*i = Int64(v)
+	case int64:
+		*i = Int64(v)
+	case float64:
+		if v != math.Trunc(v) || v > math.MaxInt64 || v < math.MinInt64 {
+			return fmt.Errorf("%v is not a 64-bit integer", v)
+		}
+		*i = Int64(v)
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a 64-bit integer", v)
+		}
+		*i = Int64(n)
+	default:
+		return fmt.Errorf("wrong type for Int64: %T", input)
+	}
+	return nil
+}
+
+func (i Int64) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(i), 10), nil
+}
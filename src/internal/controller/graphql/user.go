@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type userResolver struct {
+	root *Resolver
+	user dmodel.User
+}
+
+func (r *userResolver) Id() graphql.ID {
+	return graphql.ID(r.user.Id)
+}
+
+func (r *userResolver) Firstname() string {
+	return r.user.FirstName
+}
+
+func (r *userResolver) Lastname() string {
+	return r.user.LastName
+}
+
+func (r *userResolver) Email() string {
+	return r.user.Email
+}
+
+func (r *userResolver) Age() int32 {
+	return int32(r.user.Age)
+}
+
+func (r *userResolver) IsMarried() bool {
+	return r.user.IsMarried
+}
+
+func (r *userResolver) Orders(ctx context.Context, args pageArgs) ([]*orderResolver, error) {
+	return r.root.ordersByUserId(ctx, r.user.Id, args)
+}
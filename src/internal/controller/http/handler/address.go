@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	addressesPath   = "/profile/addresses"
+	addressesIdPath = "/profile/addresses/:address_id"
+)
+
+type addressHandler struct {
+	addressService service.AddressService
+	validate       *validator.Validate
+	logger         *logging.Logger
+}
+
+func NewAddressHandler(s service.AddressService, v *validator.Validate, l *logging.Logger) *addressHandler {
+	return &addressHandler{
+		addressService: s,
+		validate:       v,
+		logger:         l,
+	}
+}
+
+func (h *addressHandler) Register(e *echo.Echo) {
+	e.POST(addressesPath, jwt.Middleware(h.CreateAddress, h.logger))
+	e.GET(addressesPath, jwt.Middleware(h.GetAddresses, h.logger))
+	e.GET(addressesIdPath, jwt.Middleware(h.GetAddress, h.logger))
+	e.PUT(addressesIdPath, jwt.Middleware(h.UpdateAddress, h.logger))
+	e.DELETE(addressesIdPath, jwt.Middleware(h.DeleteAddress, h.logger))
+}
+
+func (h *addressHandler) CreateAddress(c echo.Context) error {
+	h.logger.Info("request received to create address")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	var req cmodel.AddressDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode address data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.addressService.Create(c.Request().Context(), userId, &req)
+	if err != nil {
+		return addressError(fmt.Errorf("failed to create address: %w", err))
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *addressHandler) GetAddresses(c echo.Context) error {
+	h.logger.Info("request received to get addresses")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	resp, err := h.addressService.GetAllByUserId(c.Request().Context(), userId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get addresses: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *addressHandler) GetAddress(c echo.Context) error {
+	h.logger.Info("request received to get address")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	addressId := c.Param("address_id")
+	if addressId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter address_id")
+	}
+
+	resp, err := h.addressService.GetOneById(c.Request().Context(), userId, addressId)
+	if err != nil {
+		return addressError(fmt.Errorf("failed to get address with id %s: %w", addressId, err))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *addressHandler) UpdateAddress(c echo.Context) error {
+	h.logger.Info("request received to update address")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	addressId := c.Param("address_id")
+	if addressId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter address_id")
+	}
+
+	var req cmodel.AddressDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode address data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.addressService.Update(c.Request().Context(), userId, addressId, &req)
+	if err != nil {
+		return addressError(fmt.Errorf("failed to update address with id %s: %w", addressId, err))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *addressHandler) DeleteAddress(c echo.Context) error {
+	h.logger.Info("request received to delete address")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	addressId := c.Param("address_id")
+	if addressId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter address_id")
+	}
+
+	if err := h.addressService.Delete(c.Request().Context(), userId, addressId); err != nil {
+		return addressError(fmt.Errorf("failed to delete address with id %s: %w", addressId, err))
+	}
+
+	return c.JSON(http.StatusOK, "address deleted")
+}
+
+func addressError(err error) error {
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, apperror.ErrInvalidAddress):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
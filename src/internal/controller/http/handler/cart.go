@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	cartPath         = "/cart"
+	cartItemsPath    = "/cart/items"
+	cartItemsIdPath  = "/cart/items/:product_id"
+	cartCheckoutPath = "/cart/checkout"
+)
+
+const (
+	// cartTokenCookie keeps the token of the anonymous cart in the browser,
+	// API clients can send it in cartTokenHeader instead
+	cartTokenCookie = "cart_token"
+	cartTokenHeader = "X-Cart-Token"
+	cartTokenMaxAge = 30 * 24 * time.Hour
+)
+
+type cartHandler struct {
+	cartService service.CartService
+	validate    *validator.Validate
+	logger      *logging.Logger
+}
+
+func NewCartHandler(s service.CartService, v *validator.Validate, l *logging.Logger) *cartHandler {
+	return &cartHandler{
+		cartService: s,
+		validate:    v,
+		logger:      l,
+	}
+}
+
+// Register registers the cart routes, they are available to anonymous visitors except the checkout
+func (h *cartHandler) Register(e *echo.Echo) {
+	e.GET(cartPath, jwt.OptionalMiddleware(h.GetCart, h.logger))
+	e.POST(cartItemsPath, jwt.OptionalMiddleware(h.AddItem, h.logger))
+	e.PUT(cartItemsIdPath, jwt.OptionalMiddleware(h.SetItemQuantity, h.logger))
+	e.DELETE(cartItemsIdPath, jwt.OptionalMiddleware(h.DeleteItem, h.logger))
+	e.POST(cartCheckoutPath, jwt.Middleware(h.Checkout, h.logger))
+}
+
+func (h *cartHandler) GetCart(c echo.Context) error {
+	h.logger.Info("request received to get cart")
+
+	userId, token := cartOwner(c)
+	resp, err := h.cartService.Get(c.Request().Context(), userId, token)
+	if err != nil {
+		return cartError(fmt.Errorf("failed to get cart: %w", err))
+	}
+
+	return h.cartJSON(c, http.StatusOK, resp)
+}
+
+func (h *cartHandler) AddItem(c echo.Context) error {
+	h.logger.Info("request received to add product to cart")
+
+	var req cmodel.AddCartItemDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode cart item data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+	// a new cart is priced in the requested currency
+	currency, err := requestCurrency(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	userId, token := cartOwner(c)
+	resp, err := h.cartService.AddItem(c.Request().Context(), userId, token, currency, &req)
+	if err != nil {
+		return cartError(fmt.Errorf("failed to add product (id %s) to cart: %w", req.ProductId, err))
+	}
+
+	return h.cartJSON(c, http.StatusOK, resp)
+}
+
+func (h *cartHandler) SetItemQuantity(c echo.Context) error {
+	h.logger.Info("request received to set quantity of product in cart")
+
+	productId := c.Param("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+	var req cmodel.SetCartItemQuantityDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode cart item data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	userId, token := cartOwner(c)
+	resp, err := h.cartService.SetItemQuantity(c.Request().Context(), userId, token, productId, req.Quantity)
+	if err != nil {
+		return cartError(fmt.Errorf("failed to set quantity of product (id %s) in cart: %w", productId, err))
+	}
+
+	return h.cartJSON(c, http.StatusOK, resp)
+}
+
+func (h *cartHandler) DeleteItem(c echo.Context) error {
+	h.logger.Info("request received to delete product from cart")
+
+	productId := c.Param("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+
+	userId, token := cartOwner(c)
+	resp, err := h.cartService.DeleteItem(c.Request().Context(), userId, token, productId)
+	if err != nil {
+		return cartError(fmt.Errorf("failed to delete product (id %s) from cart: %w", productId, err))
+	}
+
+	return h.cartJSON(c, http.StatusOK, resp)
+}
+
+// Checkout converts the cart of the user to an order reserving stock for its products
+func (h *cartHandler) Checkout(c echo.Context) error {
+	h.logger.Info("request received to check out cart")
+
+	var req cmodel.CheckoutCartDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode cart checkout data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	userId, _ := cartOwner(c)
+	resp, err := h.cartService.Checkout(c.Request().Context(), userId, &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to check out cart: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrInsufficientStock):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrEmptyCart):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// cartJSON writes the cart, the token of an anonymous cart is also set as a cookie
+func (h *cartHandler) cartJSON(c echo.Context, code int, cart dmodel.Cart) error {
+	if cart.UserId == nil && cart.Token != "" {
+		c.SetCookie(&http.Cookie{
+			Name:     cartTokenCookie,
+			Value:    cart.Token,
+			Path:     "/",
+			MaxAge:   int(cartTokenMaxAge.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return c.JSON(code, cart)
+}
+
+// cartOwner returns id of the authorized user or the token of the anonymous cart from the request
+func cartOwner(c echo.Context) (userId, token string) {
+	if pId, ok := c.Get("user_id").(string); ok {
+		return pId, ""
+	}
+	return "", CartToken(c)
+}
+
+// CartToken returns the token of the anonymous cart from the header or the cookie of the request
+func CartToken(c echo.Context) string {
+	if token := c.Request().Header.Get(cartTokenHeader); token != "" {
+		return token
+	}
+	if cookie, err := c.Cookie(cartTokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func cartError(err error) error {
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, apperror.ErrUnsupportedCurrency):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	exchangeRatesPath      = "/exchange-rates"
+	adminExchangeRatesPath = "/admin/exchange-rates"
+
+	acceptCurrencyHeader = "Accept-Currency"
+)
+
+var currencyCodeRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type currencyHandler struct {
+	currencyService service.CurrencyService
+	validate        *validator.Validate
+	logger          *logging.Logger
+}
+
+func NewCurrencyHandler(s service.CurrencyService, v *validator.Validate, l *logging.Logger) *currencyHandler {
+	return &currencyHandler{
+		currencyService: s,
+		validate:        v,
+		logger:          l,
+	}
+}
+
+func (h *currencyHandler) Register(e *echo.Echo) {
+	e.GET(exchangeRatesPath, h.GetExchangeRates)
+	e.PUT(adminExchangeRatesPath, jwt.AdminMiddleware(h.SetExchangeRates, h.logger))
+}
+
+func (h *currencyHandler) GetExchangeRates(c echo.Context) error {
+	h.logger.Info("request received to get exchange rates")
+
+	resp, err := h.currencyService.GetRates(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get exchange rates: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *currencyHandler) SetExchangeRates(c echo.Context) error {
+	h.logger.Info("request received to set exchange rates")
+
+	var req cmodel.SetExchangeRatesDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode exchange rates: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	err := h.currencyService.SetRates(c.Request().Context(), &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to set exchange rates: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrBaseCurrencyRate):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "exchange rates updated")
+}
+
+// requestCurrency returns the currency requested with the currency query parameter
+// or the Accept-Currency header, empty if the base currency should be used
+func requestCurrency(c echo.Context) (string, error) {
+	currency := c.QueryParam("currency")
+	if currency == "" {
+		currency = c.Request().Header.Get(acceptCurrencyHeader)
+	}
+	if currency == "" {
+		return "", nil
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyCodeRegexp.MatchString(currency) {
+		return "", fmt.Errorf("failed to parse currency: %q is not an ISO 4217 code", currency)
+	}
+	return currency, nil
+}
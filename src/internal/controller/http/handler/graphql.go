@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/labstack/echo/v4"
+	gql "github.com/slava-911/test-task-0723/internal/controller/graphql"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+const (
+	graphqlPath = "/graphql"
+)
+
+type graphqlHandler struct {
+	schema         *graphql.Schema
+	userService    service.UserService
+	orderService   service.OrderService
+	productService service.ProductService
+	logger         *logging.Logger
+}
+
+func NewGraphqlHandler(us service.UserService, os service.OrderService, ps service.ProductService, l *logging.Logger) (*graphqlHandler, error) {
+	schema, err := gql.NewSchema(us, os, ps, l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse graphql schema: %w", err)
+	}
+	return &graphqlHandler{
+		schema:         schema,
+		userService:    us,
+		orderService:   os,
+		productService: ps,
+		logger:         l,
+	}, nil
+}
+
+func (h *graphqlHandler) Register(e *echo.Echo) {
+	e.POST(graphqlPath, jwt.Middleware(h.Query, h.logger))
+}
+
+func (h *graphqlHandler) Query(c echo.Context) error {
+	h.logger.Info("request received to execute graphql query")
+
+	pId := c.Get("user_id")
+	if pId == nil {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	userId := pId.(string)
+
+	var req cmodel.GraphqlRequestDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode graphql request: %w", err).Error())
+	}
+	if req.Query == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "query is required")
+	}
+
+	currency, err := requestCurrency(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	ctx := gql.WithUserId(c.Request().Context(), userId)
+	ctx = gql.WithLoaders(ctx, h.userService, h.orderService, h.productService, currency)
+
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	if len(resp.Errors) > 0 {
+		h.logger.Errorf("graphql query executed with errors: %v", resp.Errors)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
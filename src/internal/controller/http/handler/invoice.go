@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+const (
+	orderInvoicePath = "/orders/:order_id/invoice.pdf"
+)
+
+type invoiceHandler struct {
+	invoiceService service.InvoiceService
+	logger         *logging.Logger
+}
+
+func NewInvoiceHandler(s service.InvoiceService, l *logging.Logger) *invoiceHandler {
+	return &invoiceHandler{
+		invoiceService: s,
+		logger:         l,
+	}
+}
+
+func (h *invoiceHandler) Register(e *echo.Echo) {
+	e.GET(orderInvoicePath, jwt.Middleware(h.GetOrderInvoice, h.logger))
+}
+
+// GetOrderInvoice returns the invoice of the checked out order as PDF, the same document on every request
+func (h *invoiceHandler) GetOrderInvoice(c echo.Context) error {
+	h.logger.Info("request received to get invoice of order")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	b, inv, err := h.invoiceService.GetPDF(c.Request().Context(), userId, orderId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get invoice of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderNotCheckedOut):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", inv.Number+".pdf"))
+	return c.Blob(http.StatusOK, "application/pdf", b)
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/slava-911/test-task-0723/internal/apperror"
 	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
@@ -17,6 +19,12 @@ import (
 const (
 	ordersPath         = "/orders"
 	ordersIdPath       = "/orders/:order_id"
+	ordersHistoryPath  = "/orders/:order_id/history"
+	ordersCouponPath   = "/orders/:order_id/coupon"
+	ordersRegionPath   = "/orders/:order_id/shipping-region"
+	ordersAddressPath  = "/orders/:order_id/addresses"
+	ordersMethodPath   = "/orders/:order_id/shipping-method"
+	ordersCheckoutPath = "/orders/:order_id/checkout"
 	ordersCompletePath = "/orders/complete/:order_id"
 	ordersContentPath  = "/orders/content/:order_id"
 )
@@ -37,8 +45,16 @@ func (h *orderHandler) Register(e *echo.Echo) {
 	e.POST(ordersPath, jwt.Middleware(h.CreateOrder, h.logger))
 	e.GET(ordersPath, jwt.Middleware(h.GetOrders, h.logger))
 	e.GET(ordersIdPath, jwt.Middleware(h.GetOrder, h.logger))
+	e.GET(ordersHistoryPath, jwt.Middleware(h.GetOrderHistory, h.logger))
+	e.POST(ordersCouponPath, jwt.Middleware(h.ApplyCoupon, h.logger))
+	e.DELETE(ordersCouponPath, jwt.Middleware(h.RemoveCoupon, h.logger))
+	e.PUT(ordersRegionPath, jwt.Middleware(h.SetShippingRegion, h.logger))
+	e.PUT(ordersAddressPath, jwt.Middleware(h.SetAddresses, h.logger))
+	e.PUT(ordersMethodPath, jwt.Middleware(h.SetShippingMethod, h.logger))
+	e.POST(ordersCheckoutPath, jwt.Middleware(h.CheckoutOrder, h.logger))
 	e.POST(ordersCompletePath, jwt.Middleware(h.CompleteOrder, h.logger))
 	e.POST(ordersContentPath, jwt.Middleware(h.AddProductToOrder, h.logger))
+	e.PATCH(ordersContentPath, jwt.Middleware(h.SetProductQuantityInOrder, h.logger))
 	e.DELETE(ordersContentPath, jwt.Middleware(h.DeleteProductFromOrder, h.logger))
 }
 
@@ -50,10 +66,23 @@ func (h *orderHandler) CreateOrder(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode order data: %w", err).Error())
 	}
+	// the order is priced in the currency from the body or the requested one
+	if req.Currency == "" {
+		if req.Currency, err = requestCurrency(c); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	req.Currency = strings.ToUpper(req.Currency)
 
 	resp, err := h.orderService.Create(c.Request().Context(), &req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to create order: %w", err).Error())
+		wrappedErr := fmt.Errorf("failed to create order: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrUnsupportedCurrency):
+			return echo.NewHTTPError(http.StatusBadRequest, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
 	}
 
 	return c.JSON(http.StatusCreated, resp)
@@ -89,7 +118,19 @@ func (h *orderHandler) GetOrders(c echo.Context) error {
 		}
 	}
 
-	resp, err := h.orderService.GetAllByUserId(c.Request().Context(), userId, limit, offset)
+	withItems := false
+	if pExpand := c.QueryParam("expand"); pExpand != "" {
+		for _, v := range strings.Split(pExpand, ",") {
+			switch strings.TrimSpace(v) {
+			case "items":
+				withItems = true
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse parameter expand: unknown value %q", v))
+			}
+		}
+	}
+
+	resp, err := h.orderService.GetAllByUserId(c.Request().Context(), userId, limit, offset, withItems)
 	if err != nil {
 		wrappedErr := fmt.Errorf("failed to get orders: %w", err)
 		switch {
@@ -124,16 +165,27 @@ func (h *orderHandler) GetOrder(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
-func (h *orderHandler) CompleteOrder(c echo.Context) error {
-	h.logger.Info("request received to complete order")
+func (h *orderHandler) GetOrderHistory(c echo.Context) error {
+	h.logger.Info("request received to get order history")
 
 	orderId := c.Param("order_id")
 	if orderId == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
 	}
-	err := h.orderService.Complete(c.Request().Context(), orderId)
+	limit, offset, err := parseLimitOffset(c, 100)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	productId := c.QueryParam("product_id")
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	resp, err := h.orderService.GetHistory(c.Request().Context(), userId, orderId, productId, limit, offset)
 	if err != nil {
-		wrappedErr := fmt.Errorf("failed to complete order with id %s: %w", orderId, err)
+		wrappedErr := fmt.Errorf("failed to get history of order with id %s: %w", orderId, err)
 		switch {
 		case errors.Is(err, apperror.ErrNotFound):
 			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
@@ -142,9 +194,74 @@ func (h *orderHandler) CompleteOrder(c echo.Context) error {
 		}
 	}
 
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *orderHandler) CheckoutOrder(c echo.Context) error {
+	h.logger.Info("request received to check out order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	var req cmodel.CheckoutOrderDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode checkout data: %w", err).Error())
+	}
+
+	resp, err := h.orderService.Checkout(c.Request().Context(), userId, orderId, req.ConfirmPrices)
+	if err != nil {
+		return checkoutError(orderId, err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CompleteOrder checks out the order without accepting changed prices, it is kept for existing clients
+func (h *orderHandler) CompleteOrder(c echo.Context) error {
+	h.logger.Info("request received to complete order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	_, err := h.orderService.Checkout(c.Request().Context(), userId, orderId, false)
+	if err != nil {
+		return checkoutError(orderId, err)
+	}
+
 	return c.JSON(http.StatusOK, "order completed")
 }
 
+func checkoutError(orderId string, err error) error {
+	wrappedErr := fmt.Errorf("failed to check out order with id %s: %w", orderId, err)
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+	case errors.Is(err, apperror.ErrOrderCompleted), errors.Is(err, apperror.ErrPricesChanged),
+		errors.Is(err, apperror.ErrInsufficientStock):
+		return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+	case errors.Is(err, apperror.ErrEmptyOrder), errors.Is(err, apperror.ErrShippingUnavailable),
+		errors.Is(err, apperror.ErrCouponNotApplicable):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+	}
+}
+
 func (h *orderHandler) AddProductToOrder(c echo.Context) error {
 	h.logger.Info("request received to assign orders")
 
@@ -164,13 +281,26 @@ func (h *orderHandler) AddProductToOrder(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse parameter quantity: %w", err).Error())
 	}
+	if quantity < 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "parameter quantity must be positive")
+	}
 
-	err = h.orderService.AddProduct(c.Request().Context(), productId, orderId, quantity)
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	err = h.orderService.AddProduct(c.Request().Context(), userId, productId, orderId, quantity)
 	if err != nil {
 		wrappedErr := fmt.Errorf("failed to add product (id %s) to order (id %s): %w", productId, orderId, err)
 		switch {
 		case errors.Is(err, apperror.ErrNotFound):
 			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderCompleted):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrInsufficientStock):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
 		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
 		}
@@ -179,6 +309,53 @@ func (h *orderHandler) AddProductToOrder(c echo.Context) error {
 	return c.JSON(http.StatusOK, "product added")
 }
 
+func (h *orderHandler) SetProductQuantityInOrder(c echo.Context) error {
+	h.logger.Info("request received to set quantity of product in order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+	productId := c.QueryParam("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+	pQuantity := c.QueryParam("quantity")
+	if pQuantity == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter quantity")
+	}
+	quantity, err := strconv.Atoi(pQuantity)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse parameter quantity: %w", err).Error())
+	}
+	if quantity < 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "parameter quantity must be positive")
+	}
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	err = h.orderService.SetProductQuantity(c.Request().Context(), userId, productId, orderId, quantity)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to set quantity of product (id %s) in order (id %s): %w", productId, orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderCompleted):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrInsufficientStock):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "product quantity updated")
+}
+
 func (h *orderHandler) DeleteProductFromOrder(c echo.Context) error {
 	h.logger.Info("request received to assign orders")
 
@@ -191,12 +368,20 @@ func (h *orderHandler) DeleteProductFromOrder(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
 	}
 
-	err := h.orderService.DeleteProduct(c.Request().Context(), productId, orderId)
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	err := h.orderService.DeleteProduct(c.Request().Context(), userId, productId, orderId)
 	if err != nil {
 		wrappedErr := fmt.Errorf("failed to delete product (id %s) from order (id %s): %w", productId, orderId, err)
 		switch {
 		case errors.Is(err, apperror.ErrNotFound):
 			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderCompleted):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
 		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
 		}
@@ -204,3 +389,194 @@ func (h *orderHandler) DeleteProductFromOrder(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, "product deleted")
 }
+
+func (h *orderHandler) ApplyCoupon(c echo.Context) error {
+	h.logger.Info("request received to apply coupon to order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	var req cmodel.ApplyCouponDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode coupon data: %w", err).Error())
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "coupon code is required")
+	}
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	resp, err := h.orderService.ApplyCoupon(c.Request().Context(), userId, orderId, strings.TrimSpace(req.Code))
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to apply coupon to order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrCouponNotApplicable):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *orderHandler) RemoveCoupon(c echo.Context) error {
+	h.logger.Info("request received to remove coupon from order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	err := h.orderService.RemoveCoupon(c.Request().Context(), userId, orderId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to remove coupon from order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderCompleted):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "coupon removed")
+}
+
+func (h *orderHandler) SetShippingRegion(c echo.Context) error {
+	h.logger.Info("request received to set shipping region of order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	var req cmodel.SetShippingRegionDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode shipping region data: %w", err).Error())
+	}
+	region := strings.ToUpper(strings.TrimSpace(req.Region))
+	if region == "" || len(region) > 16 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "region must be from 1 to 16 characters long")
+	}
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	resp, err := h.orderService.SetShippingRegion(c.Request().Context(), userId, orderId, region)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to set shipping region of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderCompleted), errors.Is(err, apperror.ErrShippingAddressSet):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetAddresses chooses addresses of the user for the draft order, they are copied to the order at checkout
+func (h *orderHandler) SetAddresses(c echo.Context) error {
+	h.logger.Info("request received to set addresses of order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	var req cmodel.SetOrderAddressesDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode addresses data: %w", err).Error())
+	}
+	for _, id := range []*string{req.ShippingAddressId, req.BillingAddressId} {
+		if id == nil {
+			continue
+		}
+		if _, err := uuid.Parse(*id); err != nil {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("address id %q is not a UUID", *id))
+		}
+	}
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	resp, err := h.orderService.SetAddresses(c.Request().Context(), userId, orderId, &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to set addresses of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderCompleted):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetShippingMethod chooses the method the draft order is shipped with, its cost is added to the order total
+func (h *orderHandler) SetShippingMethod(c echo.Context) error {
+	h.logger.Info("request received to set shipping method of order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	var req cmodel.SetShippingMethodDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode shipping method data: %w", err).Error())
+	}
+	if req.ShippingMethodId != nil {
+		if _, err := uuid.Parse(*req.ShippingMethodId); err != nil {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity,
+				fmt.Sprintf("shipping method id %q is not a UUID", *req.ShippingMethodId))
+		}
+	}
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+
+	resp, err := h.orderService.SetShippingMethod(c.Request().Context(), userId, orderId, req.ShippingMethodId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to set shipping method of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderCompleted):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+const (
+	ordersEventsPath = "/orders/:order_id/events"
+	ordersWsPath     = "/orders/:order_id/ws"
+)
+
+// wsWriteTimeout is the time a WebSocket client has to accept a message before the connection is closed
+const wsWriteTimeout = 10 * time.Second
+
+type orderStreamHandler struct {
+	orderStreamService service.OrderStreamService
+	allowedOrigins     []string
+	upgrader           websocket.Upgrader
+	logger             *logging.Logger
+}
+
+// NewOrderStreamHandler creates the handler of the order event streams. WebSocket connections are accepted
+// from the pages of the app host and of allowedOrigins.
+func NewOrderStreamHandler(s service.OrderStreamService, allowedOrigins []string, l *logging.Logger) *orderStreamHandler {
+	h := &orderStreamHandler{
+		orderStreamService: s,
+		allowedOrigins:     allowedOrigins,
+		logger:             l,
+	}
+	h.upgrader = websocket.Upgrader{CheckOrigin: h.checkOrigin}
+	return h
+}
+
+func (h *orderStreamHandler) Register(e *echo.Echo) {
+	e.GET(ordersEventsPath, jwt.StreamMiddleware(h.StreamOrderEvents, h.logger))
+	e.GET(ordersWsPath, jwt.StreamMiddleware(h.StreamOrderWebSocket, h.logger))
+}
+
+// StreamOrderEvents streams the order and its changes as Server-Sent Events
+func (h *orderStreamHandler) StreamOrderEvents(c echo.Context) error {
+	h.logger.Info("request received to stream events of order")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	res := c.Response()
+	rc := http.NewResponseController(res)
+	started := false
+	id := 0
+	err := h.orderStreamService.Watch(c.Request().Context(), userId, orderId, func(ev cmodel.OrderStreamEvent) error {
+		if !started {
+			// the write timeout of the server would cut the stream
+			if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+			res.Header().Set(echo.HeaderContentType, "text/event-stream")
+			res.Header().Set(echo.HeaderCacheControl, "no-cache")
+			res.Header().Set(echo.HeaderConnection, "keep-alive")
+			res.Header().Set("X-Accel-Buffering", "no")
+			res.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		if ev.Type == cmodel.OrderStreamPing {
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		id++
+		if _, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", id, ev.Type, b); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+	if err != nil {
+		if !started {
+			return orderStreamError(orderId, err)
+		}
+		h.logger.WithError(err).Warnf("stream of order %s ended", orderId)
+	}
+	return nil
+}
+
+// StreamOrderWebSocket streams the order and its changes as JSON messages over a WebSocket connection
+func (h *orderStreamHandler) StreamOrderWebSocket(c echo.Context) error {
+	h.logger.Info("request received to stream order over WebSocket")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+	if !websocket.IsWebSocketUpgrade(c.Request()) {
+		return echo.NewHTTPError(http.StatusBadRequest, "WebSocket upgrade is required")
+	}
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	var conn *websocket.Conn
+	err := h.orderStreamService.Watch(ctx, userId, orderId, func(ev cmodel.OrderStreamEvent) error {
+		if conn == nil {
+			var err error
+			// the connection is upgraded once the order is found, so a missing order is a plain HTTP error
+			if conn, err = h.upgrader.Upgrade(c.Response(), c.Request(), nil); err != nil {
+				return err
+			}
+			go discardMessages(conn, cancel)
+		}
+
+		deadline := time.Now().Add(wsWriteTimeout)
+		if ev.Type == cmodel.OrderStreamPing {
+			return conn.WriteControl(websocket.PingMessage, nil, deadline)
+		}
+		if err := conn.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+		return conn.WriteJSON(ev)
+	})
+	if conn == nil {
+		if err != nil && !c.Response().Committed {
+			return orderStreamError(orderId, err)
+		}
+		return nil
+	}
+	defer conn.Close()
+
+	if err != nil {
+		h.logger.WithError(err).Warnf("WebSocket stream of order %s ended", orderId)
+		return nil
+	}
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+	return nil
+}
+
+// checkOrigin allows WebSocket requests without Origin, from the app host and from the allowed origins
+func (h *orderStreamHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range h.allowedOrigins {
+		if strings.EqualFold(origin, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// discardMessages reads the connection so control messages are handled, messages of the client are ignored.
+// cancel is called when the client closes the connection or it breaks.
+func discardMessages(conn *websocket.Conn, cancel context.CancelFunc) {
+	defer cancel()
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+func orderStreamError(orderId string, err error) error {
+	wrappedErr := fmt.Errorf("failed to stream order with id %s: %w", orderId, err)
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// parseLimitOffset parses optional limit and offset query parameters
+func parseLimitOffset(c echo.Context, defaultLimit int) (limit, offset int, err error) {
+	limit, offset = defaultLimit, 0
+	if pLimit := c.QueryParam("limit"); pLimit != "" {
+		if limit, err = strconv.Atoi(pLimit); err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("failed to parse parameter limit: %q", pLimit)
+		}
+	}
+	if pOffset := c.QueryParam("offset"); pOffset != "" {
+		if offset, err = strconv.Atoi(pOffset); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("failed to parse parameter offset: %q", pOffset)
+		}
+	}
+	return limit, offset, nil
+}
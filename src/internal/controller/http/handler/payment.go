@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/internal/payment"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	orderPaymentsPath   = "/orders/:order_id/payments"
+	paymentCapturePath  = "/admin/payments/:payment_id/capture"
+	paymentWebhooksPath = "/payments/webhooks/:provider"
+	orderRefundsPath    = "/orders/:order_id/refunds"
+	adminRefundsPath    = "/admin/orders/:order_id/refunds"
+)
+
+// maxWebhookSize is the max size of the webhook request body
+const maxWebhookSize = 1 << 20
+
+type paymentHandler struct {
+	paymentService service.PaymentService
+	validate       *validator.Validate
+	logger         *logging.Logger
+}
+
+func NewPaymentHandler(s service.PaymentService, v *validator.Validate, l *logging.Logger) *paymentHandler {
+	return &paymentHandler{
+		paymentService: s,
+		validate:       v,
+		logger:         l,
+	}
+}
+
+func (h *paymentHandler) Register(e *echo.Echo) {
+	e.POST(orderPaymentsPath, jwt.Middleware(h.CreatePayment, h.logger))
+	e.GET(orderPaymentsPath, jwt.Middleware(h.GetPayments, h.logger))
+	e.POST(paymentCapturePath, jwt.AdminMiddleware(h.CapturePayment, h.logger))
+	e.POST(paymentWebhooksPath, h.HandleWebhook)
+	e.POST(adminRefundsPath, jwt.AdminMiddleware(h.CreateRefund, h.logger))
+	e.GET(orderRefundsPath, jwt.Middleware(h.GetRefunds, h.logger))
+}
+
+func (h *paymentHandler) CreatePayment(c echo.Context) error {
+	h.logger.Info("request received to create payment")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	resp, err := h.paymentService.Create(c.Request().Context(), userId, orderId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to create payment for order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderPaid), errors.Is(err, apperror.ErrAlreadyExists):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderNotCheckedOut), errors.Is(err, apperror.ErrNothingToPay):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *paymentHandler) GetPayments(c echo.Context) error {
+	h.logger.Info("request received to get payments of order")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	resp, err := h.paymentService.GetAllByOrderId(c.Request().Context(), userId, orderId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get payments of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *paymentHandler) CapturePayment(c echo.Context) error {
+	h.logger.Info("request received to capture payment")
+
+	paymentId := c.Param("payment_id")
+	if paymentId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter payment_id")
+	}
+
+	resp, err := h.paymentService.Capture(c.Request().Context(), paymentId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to capture payment with id %s: %w", paymentId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrPaymentState):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// HandleWebhook accepts payment events of the provider, the request is authenticated by its signature
+func (h *paymentHandler) HandleWebhook(c echo.Context) error {
+	h.logger.Info("request received to handle payment webhook")
+
+	provider := c.Param("provider")
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxWebhookSize))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to read webhook body: %w", err).Error())
+	}
+
+	err = h.paymentService.HandleWebhook(c.Request().Context(), provider, c.Request().Header, body)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to handle webhook of payment provider %s: %w", provider, err)
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature):
+			return echo.NewHTTPError(http.StatusUnauthorized, wrappedErr.Error())
+		case errors.Is(err, payment.ErrInvalidEvent):
+			return echo.NewHTTPError(http.StatusBadRequest, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrCaptureAmount):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "event accepted")
+}
+
+// CreateRefund refunds a part of the captured order payment, optionally for a received return
+func (h *paymentHandler) CreateRefund(c echo.Context) error {
+	h.logger.Info("request received to create refund")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	var req cmodel.CreateRefundDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode refund data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.paymentService.Refund(c.Request().Context(), orderId, &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to refund order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrPaymentState), errors.Is(err, apperror.ErrReturnState):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrRefundAmount):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *paymentHandler) GetRefunds(c echo.Context) error {
+	h.logger.Info("request received to get refunds of order")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	resp, err := h.paymentService.GetRefundsByOrderId(c.Request().Context(), userId, orderId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get refunds of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -4,35 +4,50 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/slava-911/test-task-0723/internal/apperror"
 	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
 	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
 	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
 )
 
 const (
-	productsPath   = "/products"
-	productsIdPath = "/products/:product_id"
+	productsPath      = "/products"
+	productsIdPath    = "/products/:product_id"
+	productPricesPath = "/products/:product_id/prices"
+	adminPricesPath   = "/admin/products/:product_id/prices"
+	adminPricesIdPath = "/admin/products/:product_id/prices/:price_id"
 )
 
 type productHandler struct {
 	productService service.ProductService
+	validate       *validator.Validate
 	logger         *logging.Logger
 }
 
-func NewProductHandler(s service.ProductService, l *logging.Logger) *productHandler {
+func NewProductHandler(s service.ProductService, v *validator.Validate, l *logging.Logger) *productHandler {
 	return &productHandler{
 		productService: s,
+		validate:       v,
 		logger:         l,
 	}
 }
 
 func (h *productHandler) Register(e *echo.Echo) {
-	e.POST(productsPath, h.CreateProduct)
-	e.PUT(productsPath, h.UpdateProduct)
+	// products are created and updated with their stock and price, so only admins can do it
+	e.POST(productsPath, jwt.AdminMiddleware(h.CreateProduct, h.logger))
+	e.PUT(productsPath, jwt.AdminMiddleware(h.UpdateProduct, h.logger))
 	e.GET(productsIdPath, h.GetProduct)
+	e.GET(productPricesPath, h.GetProductPrices)
+	e.POST(adminPricesPath, jwt.AdminMiddleware(h.AddProductPrice, h.logger))
+	e.DELETE(adminPricesIdPath, jwt.AdminMiddleware(h.DeleteProductPrice, h.logger))
 }
 
 func (h *productHandler) CreateProduct(c echo.Context) error {
@@ -43,6 +58,9 @@ func (h *productHandler) CreateProduct(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode product data: %w", err).Error())
 	}
+	if err = h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
 
 	resp, err := h.productService.Create(c.Request().Context(), &req)
 	if err != nil {
@@ -59,12 +77,19 @@ func (h *productHandler) GetProduct(c echo.Context) error {
 	if productId == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
 	}
-	resp, err := h.productService.GetOneById(c.Request().Context(), productId)
+	currency, err := requestCurrency(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.productService.GetOneById(c.Request().Context(), productId, currency)
 	if err != nil {
 		wrappedErr := fmt.Errorf("failed to get product with id %s: %w", productId, err)
 		switch {
 		case errors.Is(err, apperror.ErrNotFound):
 			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrUnsupportedCurrency):
+			return echo.NewHTTPError(http.StatusBadRequest, wrappedErr.Error())
 		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
 		}
@@ -81,13 +106,18 @@ func (h *productHandler) UpdateProduct(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode product data: %w", err).Error())
 	}
+	if err = h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
 
 	err = h.productService.Update(c.Request().Context(), &req)
 	if err != nil {
-		wrappedErr := fmt.Errorf("failed to get product with id %s: %w", req.Id, err)
+		wrappedErr := fmt.Errorf("failed to update product with id %s: %w", req.Id, err)
 		switch {
 		case errors.Is(err, apperror.ErrNotFound):
 			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrInsufficientStock):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
 		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
 		}
@@ -95,3 +125,109 @@ func (h *productHandler) UpdateProduct(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, "product updated")
 }
+
+func (h *productHandler) GetProductPrices(c echo.Context) error {
+	h.logger.Info("request received to get product prices")
+
+	productId := c.Param("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+	limit, offset, err := parseLimitOffset(c, 100)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	currency, err := requestCurrency(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.productService.GetPrices(c.Request().Context(), productId, currency, limit, offset)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get prices of product with id %s: %w", productId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrUnsupportedCurrency):
+			return echo.NewHTTPError(http.StatusBadRequest, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *productHandler) AddProductPrice(c echo.Context) error {
+	h.logger.Info("request received to add product price")
+
+	productId := c.Param("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+
+	var req cmodel.CreateProductPriceDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode price data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+	now := time.Now()
+	if req.EffectiveFrom != nil && req.EffectiveFrom.Before(now) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "effective_from must not be in the past")
+	}
+	if req.EffectiveTo != nil {
+		from := now
+		if req.EffectiveFrom != nil {
+			from = *req.EffectiveFrom
+		}
+		if !req.EffectiveTo.After(from) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "effective_to must be after effective_from")
+		}
+	}
+	req.ProductId = productId
+	req.Currency = strings.ToUpper(req.Currency)
+	req.UserId = c.Get("user_id").(string)
+
+	resp, err := h.productService.AddPrice(c.Request().Context(), &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to add price of product with id %s: %w", productId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrUnsupportedCurrency):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *productHandler) DeleteProductPrice(c echo.Context) error {
+	h.logger.Info("request received to delete scheduled product price")
+
+	productId := c.Param("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+	priceId, err := strconv.ParseInt(c.Param("price_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter price_id")
+	}
+
+	err = h.productService.DeletePrice(c.Request().Context(), productId, priceId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to delete scheduled price with id %d: %w", priceId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "scheduled price deleted")
+}
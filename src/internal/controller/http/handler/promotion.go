@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	promotionsPath = "/admin/promotions"
+)
+
+type promotionHandler struct {
+	promotionService service.PromotionService
+	validate         *validator.Validate
+	logger           *logging.Logger
+}
+
+func NewPromotionHandler(s service.PromotionService, v *validator.Validate, l *logging.Logger) *promotionHandler {
+	return &promotionHandler{
+		promotionService: s,
+		validate:         v,
+		logger:           l,
+	}
+}
+
+func (h *promotionHandler) Register(e *echo.Echo) {
+	e.POST(promotionsPath, jwt.AdminMiddleware(h.CreatePromotion, h.logger))
+	e.GET(promotionsPath, jwt.AdminMiddleware(h.GetPromotions, h.logger))
+}
+
+func (h *promotionHandler) CreatePromotion(c echo.Context) error {
+	h.logger.Info("request received to create promotion")
+
+	var req cmodel.CreatePromotionDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode promotion data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+	if err := validatePromotion(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	resp, err := h.promotionService.Create(c.Request().Context(), &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to create promotion: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrAlreadyExists):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrUnsupportedCurrency):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *promotionHandler) GetPromotions(c echo.Context) error {
+	h.logger.Info("request received to get promotions")
+
+	limit, offset, err := parseLimitOffset(c, 100)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.promotionService.GetAll(c.Request().Context(), limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get promotions: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// validatePromotion checks the fields required by the promotion kind
+func validatePromotion(req *cmodel.CreatePromotionDTO) error {
+	switch dmodel.PromotionKind(req.Kind) {
+	case dmodel.PromotionPercentage:
+		if req.Value < 1 || req.Value > 100 {
+			return errors.New("value of percentage promotion must be between 1 and 100")
+		}
+	case dmodel.PromotionFixed:
+		if req.Value < 1 || req.Currency == "" {
+			return errors.New("fixed promotion requires positive value and currency")
+		}
+	case dmodel.PromotionBuyXGetY:
+		if req.BuyQuantity < 1 || req.GetQuantity < 1 {
+			return errors.New("buy_x_get_y promotion requires positive buy_quantity and get_quantity")
+		}
+	}
+	if req.MinOrderValue > 0 && req.Currency == "" {
+		return errors.New("min_order_value requires currency")
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	orderReturnsPath   = "/orders/:order_id/returns"
+	returnsPath        = "/admin/returns"
+	returnsApprovePath = "/admin/returns/:return_id/approve"
+	returnsRejectPath  = "/admin/returns/:return_id/reject"
+	returnsReceivePath = "/admin/returns/:return_id/receive"
+)
+
+type returnHandler struct {
+	returnService service.ReturnService
+	validate      *validator.Validate
+	logger        *logging.Logger
+}
+
+func NewReturnHandler(s service.ReturnService, v *validator.Validate, l *logging.Logger) *returnHandler {
+	return &returnHandler{
+		returnService: s,
+		validate:      v,
+		logger:        l,
+	}
+}
+
+func (h *returnHandler) Register(e *echo.Echo) {
+	e.POST(orderReturnsPath, jwt.Middleware(h.CreateReturn, h.logger))
+	e.GET(orderReturnsPath, jwt.Middleware(h.GetOrderReturns, h.logger))
+	e.GET(returnsPath, jwt.AdminMiddleware(h.GetReturns, h.logger))
+	e.POST(returnsApprovePath, jwt.AdminMiddleware(h.ApproveReturn, h.logger))
+	e.POST(returnsRejectPath, jwt.AdminMiddleware(h.RejectReturn, h.logger))
+	e.POST(returnsReceivePath, jwt.AdminMiddleware(h.ReceiveReturn, h.logger))
+}
+
+func (h *returnHandler) CreateReturn(c echo.Context) error {
+	h.logger.Info("request received to create return")
+
+	pId := c.Get("user_id")
+	if pId == nil {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	var req cmodel.CreateReturnDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode return data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.returnService.Create(c.Request().Context(), pId.(string), orderId, &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to create return for order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderNotCheckedOut), errors.Is(err, apperror.ErrReturnQuantity):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *returnHandler) GetOrderReturns(c echo.Context) error {
+	h.logger.Info("request received to get returns of order")
+
+	userId, ok := c.Get("user_id").(string)
+	if !ok {
+		h.logger.Error("there is no user_id in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "failed to parse parameter user_id")
+	}
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	resp, err := h.returnService.GetAllByOrderId(c.Request().Context(), userId, orderId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get returns of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetReturns returns returns of all orders for staff, optionally filtered by status
+func (h *returnHandler) GetReturns(c echo.Context) error {
+	h.logger.Info("request received to get returns")
+
+	limit, offset, err := parseLimitOffset(c, 100)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	status := c.QueryParam("status")
+	switch dmodel.ReturnStatus(status) {
+	case "", dmodel.ReturnRequested, dmodel.ReturnApproved, dmodel.ReturnRejected, dmodel.ReturnReceived,
+		dmodel.ReturnRefunded:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse parameter status: unknown value %q", status))
+	}
+
+	resp, err := h.returnService.GetAll(c.Request().Context(), status, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get returns: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *returnHandler) ApproveReturn(c echo.Context) error {
+	h.logger.Info("request received to approve return")
+	return h.review(c, h.returnService.Approve)
+}
+
+func (h *returnHandler) RejectReturn(c echo.Context) error {
+	h.logger.Info("request received to reject return")
+	return h.review(c, h.returnService.Reject)
+}
+
+func (h *returnHandler) review(c echo.Context, review func(ctx context.Context, id, note string) (dmodel.Return, error)) error {
+	returnId := c.Param("return_id")
+	if returnId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter return_id")
+	}
+
+	var req cmodel.ReviewReturnDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode review data: %w", err).Error())
+	}
+
+	resp, err := review(c.Request().Context(), returnId, req.Note)
+	if err != nil {
+		return returnError(fmt.Errorf("failed to review return with id %s: %w", returnId, err))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ReceiveReturn restocks the products of the approved return when they arrive
+func (h *returnHandler) ReceiveReturn(c echo.Context) error {
+	h.logger.Info("request received to receive return")
+
+	returnId := c.Param("return_id")
+	if returnId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter return_id")
+	}
+
+	resp, err := h.returnService.Receive(c.Request().Context(), returnId)
+	if err != nil {
+		return returnError(fmt.Errorf("failed to receive return with id %s: %w", returnId, err))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func returnError(err error) error {
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, apperror.ErrReturnState):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
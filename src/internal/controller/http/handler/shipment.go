@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	orderShipmentsPath      = "/orders/:order_id/shipments"
+	adminOrderShipmentsPath = "/admin/orders/:order_id/shipments"
+	shipmentsDeliverPath    = "/admin/shipments/:shipment_id/deliver"
+	fulfillmentOrdersPath   = "/admin/fulfillment/orders"
+)
+
+type shipmentHandler struct {
+	shipmentService service.ShipmentService
+	validate        *validator.Validate
+	logger          *logging.Logger
+}
+
+func NewShipmentHandler(s service.ShipmentService, v *validator.Validate, l *logging.Logger) *shipmentHandler {
+	return &shipmentHandler{
+		shipmentService: s,
+		validate:        v,
+		logger:          l,
+	}
+}
+
+func (h *shipmentHandler) Register(e *echo.Echo) {
+	e.GET(orderShipmentsPath, jwt.Middleware(h.GetOrderShipments, h.logger))
+	e.POST(adminOrderShipmentsPath, jwt.AdminMiddleware(h.CreateShipment, h.logger))
+	e.POST(shipmentsDeliverPath, jwt.AdminMiddleware(h.DeliverShipment, h.logger))
+	e.GET(fulfillmentOrdersPath, jwt.AdminMiddleware(h.GetUnfulfilledOrders, h.logger))
+}
+
+// CreateShipment ships lines of the paid order, the order becomes shipped when all its lines are shipped
+func (h *shipmentHandler) CreateShipment(c echo.Context) error {
+	h.logger.Info("request received to create shipment")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	var req cmodel.CreateShipmentDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode shipment data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.shipmentService.Create(c.Request().Context(), c.Get("user_id").(string), orderId, &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to create shipment for order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrOrderNotCheckedOut), errors.Is(err, apperror.ErrOrderNotPaid):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrShipmentQuantity):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *shipmentHandler) GetOrderShipments(c echo.Context) error {
+	h.logger.Info("request received to get shipments of order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+
+	resp, err := h.shipmentService.GetAllByOrderId(c.Request().Context(), orderId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get shipments: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeliverShipment marks the shipment delivered, the order becomes delivered when all its shipments are delivered
+func (h *shipmentHandler) DeliverShipment(c echo.Context) error {
+	h.logger.Info("request received to deliver shipment")
+
+	shipmentId := c.Param("shipment_id")
+	if shipmentId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter shipment_id")
+	}
+
+	var req cmodel.DeliverShipmentDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode delivery data: %w", err).Error())
+	}
+
+	resp, err := h.shipmentService.Deliver(c.Request().Context(), shipmentId, req.DeliveredAt)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to deliver shipment with id %s: %w", shipmentId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrShipmentState):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetUnfulfilledOrders returns paid orders waiting to be shipped for the warehouse, the oldest first
+func (h *shipmentHandler) GetUnfulfilledOrders(c echo.Context) error {
+	h.logger.Info("request received to get unfulfilled orders")
+
+	limit, offset, err := parseLimitOffset(c, 100)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.shipmentService.GetUnfulfilled(c.Request().Context(), limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get unfulfilled orders: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	shippingMethodsPath   = "/admin/shipping-methods"
+	shippingMethodsIdPath = "/admin/shipping-methods/:shipping_method_id"
+	ordersQuotesPath      = "/orders/:order_id/shipping-quotes"
+)
+
+type shippingHandler struct {
+	shippingService service.ShippingService
+	validate        *validator.Validate
+	logger          *logging.Logger
+}
+
+func NewShippingHandler(s service.ShippingService, v *validator.Validate, l *logging.Logger) *shippingHandler {
+	return &shippingHandler{
+		shippingService: s,
+		validate:        v,
+		logger:          l,
+	}
+}
+
+func (h *shippingHandler) Register(e *echo.Echo) {
+	e.POST(shippingMethodsPath, jwt.AdminMiddleware(h.CreateShippingMethod, h.logger))
+	e.GET(shippingMethodsPath, jwt.AdminMiddleware(h.GetShippingMethods, h.logger))
+	e.DELETE(shippingMethodsIdPath, jwt.AdminMiddleware(h.DeleteShippingMethod, h.logger))
+	e.GET(ordersQuotesPath, jwt.Middleware(h.GetShippingQuotes, h.logger))
+}
+
+func (h *shippingHandler) CreateShippingMethod(c echo.Context) error {
+	h.logger.Info("request received to create shipping method")
+
+	var req cmodel.CreateShippingMethodDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode shipping method data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+	if req.Kind != string(dmodel.ShippingFlat) && len(req.Tiers) == 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "tiers are required for weight and quantity methods")
+	}
+
+	resp, err := h.shippingService.Create(c.Request().Context(), &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to create shipping method: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrAlreadyExists):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *shippingHandler) GetShippingMethods(c echo.Context) error {
+	h.logger.Info("request received to get shipping methods")
+
+	currency, err := requestCurrency(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.shippingService.GetAll(c.Request().Context(), currency)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get shipping methods: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrUnsupportedCurrency):
+			return echo.NewHTTPError(http.StatusBadRequest, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *shippingHandler) DeleteShippingMethod(c echo.Context) error {
+	h.logger.Info("request received to delete shipping method")
+
+	methodId := c.Param("shipping_method_id")
+	if methodId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter shipping_method_id")
+	}
+
+	err := h.shippingService.Delete(c.Request().Context(), methodId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to delete shipping method with id %s: %w", methodId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "shipping method deleted")
+}
+
+// GetShippingQuotes returns the costs of shipping the order with the available methods,
+// to the address of the user if address_id is set and to the shipping region of the order otherwise
+func (h *shippingHandler) GetShippingQuotes(c echo.Context) error {
+	h.logger.Info("request received to get shipping quotes of order")
+
+	orderId := c.Param("order_id")
+	if orderId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter order_id")
+	}
+	addressId := c.QueryParam("address_id")
+	if addressId != "" {
+		if _, err := uuid.Parse(addressId); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("address id %q is not a UUID", addressId))
+		}
+	}
+
+	resp, err := h.shippingService.GetQuotes(c.Request().Context(), orderId, addressId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get shipping quotes of order with id %s: %w", orderId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
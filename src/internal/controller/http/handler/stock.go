@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	stockPath               = "/admin/products/:product_id/stock"
+	stockMovementsPath      = "/admin/products/:product_id/stock/movements"
+	stockReconciliationPath = "/admin/stock/reconciliation"
+	stockTransfersPath      = "/admin/stock/transfers"
+)
+
+type stockHandler struct {
+	stockService service.StockService
+	validate     *validator.Validate
+	logger       *logging.Logger
+}
+
+func NewStockHandler(s service.StockService, v *validator.Validate, l *logging.Logger) *stockHandler {
+	return &stockHandler{
+		stockService: s,
+		validate:     v,
+		logger:       l,
+	}
+}
+
+func (h *stockHandler) Register(e *echo.Echo) {
+	e.POST(stockPath, jwt.AdminMiddleware(h.AddStockMovement, h.logger))
+	e.GET(stockMovementsPath, jwt.AdminMiddleware(h.GetStockMovements, h.logger))
+	e.GET(stockReconciliationPath, jwt.AdminMiddleware(h.GetStockReconciliation, h.logger))
+	e.POST(stockTransfersPath, jwt.AdminMiddleware(h.TransferStock, h.logger))
+}
+
+func (h *stockHandler) AddStockMovement(c echo.Context) error {
+	h.logger.Info("request received to add stock movement")
+
+	productId := c.Param("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+
+	var req cmodel.CreateStockMovementDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode stock movement data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+	if req.Kind == string(dmodel.StockReceipt) && req.Quantity < 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "quantity of receipt must be positive")
+	}
+	req.ProductId = productId
+	req.UserId = c.Get("user_id").(string)
+
+	resp, err := h.stockService.AddMovement(c.Request().Context(), &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to add stock movement for product with id %s: %w", productId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrInsufficientStock):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *stockHandler) TransferStock(c echo.Context) error {
+	h.logger.Info("request received to transfer stock between warehouses")
+
+	var req cmodel.CreateStockTransferDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode stock transfer data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+	req.UserId = c.Get("user_id").(string)
+
+	err := h.stockService.Transfer(c.Request().Context(), &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to transfer product with id %s: %w", req.ProductId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrInsufficientStock):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "stock transferred")
+}
+
+func (h *stockHandler) GetStockMovements(c echo.Context) error {
+	h.logger.Info("request received to get stock movements")
+
+	productId := c.Param("product_id")
+	if productId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter product_id")
+	}
+	limit, offset, err := parseLimitOffset(c, 100)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.stockService.GetMovementsByProductId(c.Request().Context(), productId, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get stock movements: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *stockHandler) GetStockReconciliation(c echo.Context) error {
+	h.logger.Info("request received to reconcile stock")
+
+	all := c.QueryParam("all") == "true"
+	resp, err := h.stockService.GetReconciliation(c.Request().Context(), all)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to reconcile stock: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	taxRulesPath   = "/admin/tax-rules"
+	taxRulesIdPath = "/admin/tax-rules/:tax_rule_id"
+)
+
+type taxHandler struct {
+	taxService service.TaxService
+	validate   *validator.Validate
+	logger     *logging.Logger
+}
+
+func NewTaxHandler(s service.TaxService, v *validator.Validate, l *logging.Logger) *taxHandler {
+	return &taxHandler{
+		taxService: s,
+		validate:   v,
+		logger:     l,
+	}
+}
+
+func (h *taxHandler) Register(e *echo.Echo) {
+	e.POST(taxRulesPath, jwt.AdminMiddleware(h.CreateTaxRule, h.logger))
+	e.GET(taxRulesPath, jwt.AdminMiddleware(h.GetTaxRules, h.logger))
+	e.DELETE(taxRulesIdPath, jwt.AdminMiddleware(h.DeleteTaxRule, h.logger))
+}
+
+func (h *taxHandler) CreateTaxRule(c echo.Context) error {
+	h.logger.Info("request received to create tax rule")
+
+	var req cmodel.CreateTaxRuleDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode tax rule data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.taxService.Create(c.Request().Context(), &req)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to create tax rule: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrAlreadyExists):
+			return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *taxHandler) GetTaxRules(c echo.Context) error {
+	h.logger.Info("request received to get tax rules")
+
+	resp, err := h.taxService.GetAll(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get tax rules: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *taxHandler) DeleteTaxRule(c echo.Context) error {
+	h.logger.Info("request received to delete tax rule")
+
+	ruleId := c.Param("tax_rule_id")
+	if ruleId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter tax_rule_id")
+	}
+
+	err := h.taxService.Delete(c.Request().Context(), ruleId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to delete tax rule with id %s: %w", ruleId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "tax rule deleted")
+}
@@ -24,14 +24,17 @@ const (
 
 type userHandler struct {
 	userService service.UserService
+	cartService service.CartService
 	jwtHelper   jwt.Helper
 	validate    *validator.Validate
 	logger      *logging.Logger
 }
 
-func NewUserHandler(s service.UserService, h jwt.Helper, v *validator.Validate, l *logging.Logger) *userHandler {
+func NewUserHandler(s service.UserService, cs service.CartService, h jwt.Helper, v *validator.Validate,
+	l *logging.Logger) *userHandler {
 	return &userHandler{
 		userService: s,
+		cartService: cs,
 		jwtHelper:   h,
 		validate:    v,
 		logger:      l,
@@ -69,7 +72,13 @@ func (h *userHandler) Signup(c echo.Context) error {
 	}
 
 	if user, err = h.userService.Create(c.Request().Context(), &req); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to create user: %w", err).Error())
+		wrappedErr := fmt.Errorf("failed to create user: %w", err)
+		switch {
+		case errors.Is(err, apperror.ErrAlreadyExists):
+			return echo.NewHTTPError(http.StatusConflict, "user with this email already exists")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
 	}
 
 	if token, err = h.jwtHelper.GenerateAccessToken(user); err != nil {
@@ -109,6 +118,15 @@ func (h *userHandler) Auth(c echo.Context) error {
 		if token, err = h.jwtHelper.GenerateAccessToken(user); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
+
+		// the anonymous cart is merged into the cart of the user, a failed merge doesn't fail the login
+		if cartToken := CartToken(c); cartToken != "" {
+			if err = h.cartService.Merge(c.Request().Context(), cartToken, user.Id); err != nil {
+				h.logger.Error(err)
+			} else {
+				c.SetCookie(&http.Cookie{Name: cartTokenCookie, Path: "/", MaxAge: -1})
+			}
+		}
 	case http.MethodPut:
 		if err = c.Bind(&rt); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode token data: %w", err).Error())
@@ -138,7 +156,7 @@ func (h *userHandler) GetUser(c echo.Context) error {
 	userId := pId.(string)
 
 	if user, err = h.userService.GetOneById(c.Request().Context(), userId); err != nil {
-		wrappedErr := fmt.Errorf("failed to get user with id %d: %w", userId, err)
+		wrappedErr := fmt.Errorf("failed to get user with id %s: %w", userId, err)
 		switch {
 		case errors.Is(err, apperror.ErrNotFound):
 			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
@@ -186,6 +204,12 @@ func (h *userHandler) PartiallyUpdateUser(c echo.Context) error {
 		}
 		changedFields["email"] = *req.Email
 	}
+	if req.Locale != nil {
+		if err = h.validate.Var(*req.Locale, "required,oneof=en de"); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, utils.TranslateValidationError(err, "Locale"))
+		}
+		changedFields["locale"] = *req.Locale
+	}
 	if req.OldPassword != nil && req.NewPassword != nil {
 		if *req.OldPassword != *req.NewPassword && *req.OldPassword != "" && *req.NewPassword != "" {
 			if err = h.validate.Var(*req.NewPassword, "required,min=8"); err != nil {
@@ -205,6 +229,8 @@ func (h *userHandler) PartiallyUpdateUser(c echo.Context) error {
 		switch {
 		case errors.Is(err, apperror.ErrNotFound):
 			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		case errors.Is(err, apperror.ErrAlreadyExists):
+			return echo.NewHTTPError(http.StatusConflict, "user with this email already exists")
 		default:
 			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
 		}
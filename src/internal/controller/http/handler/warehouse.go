@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	warehousesPath            = "/warehouses"
+	adminWarehousesPath       = "/admin/warehouses"
+	adminWarehouseDefaultPath = "/admin/warehouses/:warehouse_id/default"
+)
+
+type warehouseHandler struct {
+	warehouseService service.WarehouseService
+	validate         *validator.Validate
+	logger           *logging.Logger
+}
+
+func NewWarehouseHandler(s service.WarehouseService, v *validator.Validate, l *logging.Logger) *warehouseHandler {
+	return &warehouseHandler{
+		warehouseService: s,
+		validate:         v,
+		logger:           l,
+	}
+}
+
+func (h *warehouseHandler) Register(e *echo.Echo) {
+	e.GET(warehousesPath, h.GetWarehouses)
+	e.POST(adminWarehousesPath, jwt.AdminMiddleware(h.CreateWarehouse, h.logger))
+	e.PUT(adminWarehouseDefaultPath, jwt.AdminMiddleware(h.SetDefaultWarehouse, h.logger))
+}
+
+func (h *warehouseHandler) CreateWarehouse(c echo.Context) error {
+	h.logger.Info("request received to create warehouse")
+
+	var req cmodel.CreateWarehouseDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode warehouse data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.warehouseService.Create(c.Request().Context(), &req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to create warehouse: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *warehouseHandler) GetWarehouses(c echo.Context) error {
+	h.logger.Info("request received to get warehouses")
+
+	resp, err := h.warehouseService.GetAll(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get warehouses: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *warehouseHandler) SetDefaultWarehouse(c echo.Context) error {
+	h.logger.Info("request received to set default warehouse")
+
+	warehouseId := c.Param("warehouse_id")
+	if warehouseId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter warehouse_id")
+	}
+
+	err := h.warehouseService.SetDefault(c.Request().Context(), warehouseId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to set default warehouse with id %s: %w", warehouseId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "default warehouse updated")
+}
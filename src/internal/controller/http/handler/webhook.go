@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/utils"
+)
+
+const (
+	webhooksPath                   = "/admin/webhooks"
+	webhooksIdPath                 = "/admin/webhooks/:webhook_id"
+	webhookDeliveriesPath          = "/admin/webhooks/:webhook_id/deliveries"
+	webhookDeliveriesIdPath        = "/admin/webhook-deliveries/:delivery_id"
+	webhookDeliveriesRedeliverPath = "/admin/webhook-deliveries/:delivery_id/redeliver"
+)
+
+type webhookHandler struct {
+	webhookService service.WebhookService
+	validate       *validator.Validate
+	logger         *logging.Logger
+}
+
+func NewWebhookHandler(s service.WebhookService, v *validator.Validate, l *logging.Logger) *webhookHandler {
+	return &webhookHandler{
+		webhookService: s,
+		validate:       v,
+		logger:         l,
+	}
+}
+
+func (h *webhookHandler) Register(e *echo.Echo) {
+	e.POST(webhooksPath, jwt.AdminMiddleware(h.CreateWebhook, h.logger))
+	e.GET(webhooksPath, jwt.AdminMiddleware(h.GetWebhooks, h.logger))
+	e.DELETE(webhooksIdPath, jwt.AdminMiddleware(h.DeleteWebhook, h.logger))
+	e.GET(webhookDeliveriesPath, jwt.AdminMiddleware(h.GetWebhookDeliveries, h.logger))
+	e.GET(webhookDeliveriesIdPath, jwt.AdminMiddleware(h.GetWebhookDelivery, h.logger))
+	e.POST(webhookDeliveriesRedeliverPath, jwt.AdminMiddleware(h.RedeliverWebhook, h.logger))
+}
+
+// CreateWebhook registers the endpoint for the event types, the response has the secret the requests
+// to the endpoint are signed with
+func (h *webhookHandler) CreateWebhook(c echo.Context) error {
+	h.logger.Info("request received to create webhook")
+
+	var req cmodel.CreateWebhookDTO
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to decode webhook data: %w", err).Error())
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, utils.TranslateValidationError(err, ""))
+	}
+
+	resp, err := h.webhookService.Create(c.Request().Context(), &req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to create webhook: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *webhookHandler) GetWebhooks(c echo.Context) error {
+	h.logger.Info("request received to get webhooks")
+
+	resp, err := h.webhookService.GetAll(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get webhooks: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *webhookHandler) DeleteWebhook(c echo.Context) error {
+	h.logger.Info("request received to delete webhook")
+
+	webhookId := c.Param("webhook_id")
+	if webhookId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter webhook_id")
+	}
+
+	err := h.webhookService.Delete(c.Request().Context(), webhookId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to delete webhook with id %s: %w", webhookId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, "webhook deleted")
+}
+
+// GetWebhookDeliveries returns the delivery log of the webhook, the latest deliveries first
+func (h *webhookHandler) GetWebhookDeliveries(c echo.Context) error {
+	h.logger.Info("request received to get webhook deliveries")
+
+	webhookId := c.Param("webhook_id")
+	if webhookId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter webhook_id")
+	}
+	limit, offset, err := parseLimitOffset(c, 100)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	status := c.QueryParam("status")
+	switch dmodel.WebhookDeliveryStatus(status) {
+	case "", dmodel.WebhookPending, dmodel.WebhookDelivered, dmodel.WebhookDead:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse parameter status: unknown value %q", status))
+	}
+
+	resp, err := h.webhookService.GetDeliveries(c.Request().Context(), webhookId, status, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to get webhook deliveries: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetWebhookDelivery returns the delivery with the log of its attempts
+func (h *webhookHandler) GetWebhookDelivery(c echo.Context) error {
+	h.logger.Info("request received to get webhook delivery")
+
+	deliveryId, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter delivery_id")
+	}
+
+	resp, err := h.webhookService.GetDelivery(c.Request().Context(), deliveryId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to get webhook delivery with id %d: %w", deliveryId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RedeliverWebhook schedules the delivery to be sent again right away with all attempts available
+func (h *webhookHandler) RedeliverWebhook(c echo.Context) error {
+	h.logger.Info("request received to redeliver webhook")
+
+	deliveryId, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse parameter delivery_id")
+	}
+
+	resp, err := h.webhookService.Redeliver(c.Request().Context(), deliveryId)
+	if err != nil {
+		wrappedErr := fmt.Errorf("failed to redeliver webhook delivery with id %d: %w", deliveryId, err)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, wrappedErr.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+		}
+	}
+
+	return c.JSON(http.StatusAccepted, resp)
+}
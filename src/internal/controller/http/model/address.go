@@ -0,0 +1,82 @@
+package cmodel
+
+import (
+	"strings"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type AddressDTO struct {
+	Name            string `json:"name" validate:"required,max=200"`
+	Line1           string `json:"line1" validate:"required,max=200"`
+	Line2           string `json:"line2" validate:"max=200"`
+	City            string `json:"city" validate:"required,max=100"`
+	Region          string `json:"region" validate:"max=100"`
+	PostalCode      string `json:"postal_code" validate:"max=20"`
+	Country         string `json:"country" validate:"required,len=2,alpha"`
+	Phone           string `json:"phone" validate:"omitempty,e164"`
+	DefaultBilling  bool   `json:"default_billing"`
+	DefaultShipping bool   `json:"default_shipping"`
+}
+
+// ToAddress returns the address with trimmed fields and upper-cased country and postal code
+func (d *AddressDTO) ToAddress() *dmodel.Address {
+	return &dmodel.Address{
+		Name:            strings.TrimSpace(d.Name),
+		Line1:           strings.TrimSpace(d.Line1),
+		Line2:           strings.TrimSpace(d.Line2),
+		City:            strings.TrimSpace(d.City),
+		Region:          strings.TrimSpace(d.Region),
+		PostalCode:      strings.ToUpper(strings.TrimSpace(d.PostalCode)),
+		Country:         strings.ToUpper(d.Country),
+		Phone:           d.Phone,
+		DefaultBilling:  d.DefaultBilling,
+		DefaultShipping: d.DefaultShipping,
+	}
+}
+
+type AddressesResponse struct {
+	Addresses []dmodel.Address `json:"addresses"`
+}
+
+// SetOrderAddressesDTO chooses addresses from the address book for the order, omitted ones are not changed
+type SetOrderAddressesDTO struct {
+	ShippingAddressId *string `json:"shipping_address_id"`
+	BillingAddressId  *string `json:"billing_address_id"`
+}
+
+type AddressRuleDTO struct {
+	Country            string   `json:"country" validate:"required,len=2,alpha"`
+	PostalCodeRequired bool     `json:"postal_code_required"`
+	PostalCodePattern  string   `json:"postal_code_pattern"`
+	RegionRequired     bool     `json:"region_required"`
+	Regions            []string `json:"regions"`
+	PhoneRequired      bool     `json:"phone_required"`
+}
+
+// AddressRulesDTO are the per-country address rules loaded on start,
+// addresses in countries without rules are rejected if OnlyListedCountries is set
+type AddressRulesDTO struct {
+	OnlyListedCountries bool             `json:"only_listed_countries"`
+	Countries           []AddressRuleDTO `json:"countries" validate:"dive"`
+}
+
+// ToAddressRules returns rules with upper-cased country and region codes
+func (d *AddressRulesDTO) ToAddressRules() []dmodel.AddressRule {
+	rules := make([]dmodel.AddressRule, 0, len(d.Countries))
+	for _, c := range d.Countries {
+		r := dmodel.AddressRule{
+			Country:            strings.ToUpper(c.Country),
+			PostalCodeRequired: c.PostalCodeRequired,
+			PostalCodePattern:  c.PostalCodePattern,
+			RegionRequired:     c.RegionRequired,
+			Regions:            make([]string, 0, len(c.Regions)),
+			PhoneRequired:      c.PhoneRequired,
+		}
+		for _, region := range c.Regions {
+			r.Regions = append(r.Regions, strings.ToUpper(region))
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
@@ -0,0 +1,14 @@
+package cmodel
+
+type AddCartItemDTO struct {
+	ProductId string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"min=1"`
+}
+
+type SetCartItemQuantityDTO struct {
+	Quantity int `json:"quantity" validate:"min=1"`
+}
+
+type CheckoutCartDTO struct {
+	ShippingRegion string `json:"shipping_region" validate:"omitempty,max=16"`
+}
@@ -0,0 +1,39 @@
+package cmodel
+
+import (
+	"strings"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type ExchangeRateDTO struct {
+	Currency   string  `json:"currency" validate:"required,len=3,alpha"`
+	Rate       float64 `json:"rate" validate:"required,gt=0"`
+	MinorUnits *int    `json:"minor_units" validate:"omitempty,min=0,max=4"`
+}
+
+type SetExchangeRatesDTO struct {
+	Rates []ExchangeRateDTO `json:"rates" validate:"required,min=1,dive"`
+}
+
+// ToExchangeRates returns rates with upper-cased currency codes, a currency has 2 minor units by default
+func (d *SetExchangeRatesDTO) ToExchangeRates() []dmodel.ExchangeRate {
+	rates := make([]dmodel.ExchangeRate, 0, len(d.Rates))
+	for _, r := range d.Rates {
+		er := dmodel.ExchangeRate{
+			Currency:   strings.ToUpper(r.Currency),
+			Rate:       r.Rate,
+			MinorUnits: 2,
+		}
+		if r.MinorUnits != nil {
+			er.MinorUnits = *r.MinorUnits
+		}
+		rates = append(rates, er)
+	}
+	return rates
+}
+
+type ExchangeRatesResponse struct {
+	Base  string                `json:"base"`
+	Rates []dmodel.ExchangeRate `json:"rates"`
+}
@@ -0,0 +1,7 @@
+package cmodel
+
+type GraphqlRequestDTO struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
@@ -1,21 +1,58 @@
 package cmodel
 
 import (
+	"strings"
+
 	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
 )
 
 type CreateOrderDTO struct {
-	UserId string `json:"user_id"`
+	UserId         string `json:"user_id"`
+	Currency       string `json:"currency"`
+	ShippingRegion string `json:"shipping_region"`
 }
 
 func (d *CreateOrderDTO) ToOrder() *dmodel.Order {
 	return &dmodel.Order{
-		UserId: d.UserId,
+		UserId:            d.UserId,
+		Currency:          d.Currency,
+		ShippingRegion:    strings.ToUpper(d.ShippingRegion),
+		FulfillmentStatus: dmodel.FulfillmentUnfulfilled,
 	}
 }
 
+type CheckoutOrderDTO struct {
+	// ConfirmPrices accepts the current product prices if they changed since the products were added to the order
+	ConfirmPrices bool `json:"confirm_prices"`
+}
+
+type SetShippingRegionDTO struct {
+	Region string `json:"region"`
+}
+
 type OrdersResponse struct {
 	Limit  int            `json:"limit"`
 	Offset int            `json:"offset"`
 	Orders []dmodel.Order `json:"orders"`
 }
+
+type OrderHistoryResponse struct {
+	Limit  int                        `json:"limit"`
+	Offset int                        `json:"offset"`
+	Events []dmodel.OrderContentEvent `json:"events"`
+}
+
+const (
+	// OrderStreamOrder carries the whole order, it is sent when the stream starts and after every change
+	OrderStreamOrder = "order"
+	// OrderStreamDeleted is sent when the order is deleted, the stream ends after it
+	OrderStreamDeleted = "order.deleted"
+	// OrderStreamPing keeps idle streams open through proxies, it has no order
+	OrderStreamPing = "ping"
+)
+
+// OrderStreamEvent is a message of the order event streams
+type OrderStreamEvent struct {
+	Type  string        `json:"type"`
+	Order *dmodel.Order `json:"order,omitempty"`
+}
@@ -0,0 +1,23 @@
+package cmodel
+
+import dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+
+type PaymentsResponse struct {
+	Payments []dmodel.Payment `json:"payments"`
+}
+
+// CreateRefundDTO refunds the amount or, if it is zero, the amount of the return or the whole refundable amount
+type CreateRefundDTO struct {
+	Amount   int64  `json:"amount" validate:"min=0"`
+	ReturnId string `json:"return_id" validate:"omitempty,uuid"`
+	Reason   string `json:"reason"`
+}
+
+type RefundResponse struct {
+	Refund  dmodel.Refund  `json:"refund"`
+	Payment dmodel.Payment `json:"payment"`
+}
+
+type RefundsResponse struct {
+	Refunds []dmodel.Refund `json:"refunds"`
+}
@@ -0,0 +1,39 @@
+package cmodel
+
+import (
+	"time"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type CreateProductPriceDTO struct {
+	ProductId     string     `json:"-"`
+	UserId        string     `json:"-"`
+	Price         *int64     `json:"price" validate:"required,min=0"`
+	Currency      string     `json:"currency" validate:"omitempty,len=3"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+}
+
+// ToProductPrice returns the price with times in UTC, the time zone is not stored in the database
+func (d *CreateProductPriceDTO) ToProductPrice() *dmodel.ProductPrice {
+	p := &dmodel.ProductPrice{
+		ProductId: d.ProductId,
+		Price:     dmodel.Money{Amount: *d.Price, Currency: d.Currency},
+		UserId:    &d.UserId,
+	}
+	if d.EffectiveFrom != nil {
+		p.EffectiveFrom = d.EffectiveFrom.UTC()
+	}
+	if d.EffectiveTo != nil {
+		to := d.EffectiveTo.UTC()
+		p.EffectiveTo = &to
+	}
+	return p
+}
+
+type ProductPricesResponse struct {
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+	Prices []dmodel.ProductPrice `json:"prices"`
+}
@@ -2,37 +2,49 @@ package cmodel
 
 import dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
 
+// CreateProductDTO holds the price in minor units of the base currency
 type CreateProductDTO struct {
-	Price       int      `json:"price"`
+	Price       int64    `json:"price"`
 	Quantity    int      `json:"quantity"`
 	Description string   `json:"description"`
 	Tags        []string `json:"tags"`
+	// Weight is in grams, dimensions are in millimeters
+	Weight     *int               `json:"weight" validate:"omitempty,gte=0"`
+	Dimensions *dmodel.Dimensions `json:"dimensions"`
 }
 
 func (d *CreateProductDTO) ToProduct() *dmodel.Product {
 	return &dmodel.Product{
-		Price:       d.Price,
+		Price:       dmodel.Money{Amount: d.Price},
 		Quantity:    d.Quantity,
 		Description: d.Description,
 		Tags:        d.Tags,
+		Weight:      d.Weight,
+		Dimensions:  d.Dimensions,
 	}
 }
 
+// UpdateProductDTO holds the price in minor units of the base currency
 type UpdateProductDTO struct {
 	Id          string   `json:"id"`
-	Price       int      `json:"price"`
+	Price       int64    `json:"price"`
 	Quantity    int      `json:"quantity"`
 	Description string   `json:"description"`
 	Tags        []string `json:"tags"`
+	// Weight is in grams, dimensions are in millimeters
+	Weight     *int               `json:"weight" validate:"omitempty,gte=0"`
+	Dimensions *dmodel.Dimensions `json:"dimensions"`
 }
 
 func (d *UpdateProductDTO) ToProduct() *dmodel.Product {
 	return &dmodel.Product{
 		Id:          d.Id,
-		Price:       d.Price,
+		Price:       dmodel.Money{Amount: d.Price},
 		Quantity:    d.Quantity,
 		Description: d.Description,
 		Tags:        d.Tags,
+		Weight:      d.Weight,
+		Dimensions:  d.Dimensions,
 	}
 }
 
@@ -0,0 +1,60 @@
+package cmodel
+
+import (
+	"strings"
+	"time"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type CreatePromotionDTO struct {
+	Code              string     `json:"code" validate:"required,min=3,max=64"`
+	Name              string     `json:"name" validate:"required"`
+	Kind              string     `json:"kind" validate:"required,oneof=percentage fixed buy_x_get_y"`
+	Value             int64      `json:"value" validate:"min=0"`
+	Currency          string     `json:"currency" validate:"omitempty,len=3,alpha"`
+	Tag               string     `json:"tag"`
+	BuyQuantity       int        `json:"buy_quantity" validate:"min=0"`
+	GetQuantity       int        `json:"get_quantity" validate:"min=0"`
+	MinOrderValue     int64      `json:"min_order_value" validate:"min=0"`
+	UsageLimit        *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	UsageLimitPerUser *int       `json:"usage_limit_per_user" validate:"omitempty,min=1"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+}
+
+// ToPromotion returns the promotion with an upper-cased code and times in UTC
+func (d *CreatePromotionDTO) ToPromotion() *dmodel.Promotion {
+	p := &dmodel.Promotion{
+		Code:              strings.ToUpper(d.Code),
+		Name:              d.Name,
+		Kind:              dmodel.PromotionKind(d.Kind),
+		Value:             d.Value,
+		Currency:          strings.ToUpper(d.Currency),
+		Tag:               d.Tag,
+		BuyQuantity:       d.BuyQuantity,
+		GetQuantity:       d.GetQuantity,
+		MinOrderValue:     d.MinOrderValue,
+		UsageLimit:        d.UsageLimit,
+		UsageLimitPerUser: d.UsageLimitPerUser,
+	}
+	if d.StartsAt != nil {
+		startsAt := d.StartsAt.UTC()
+		p.StartsAt = &startsAt
+	}
+	if d.EndsAt != nil {
+		endsAt := d.EndsAt.UTC()
+		p.EndsAt = &endsAt
+	}
+	return p
+}
+
+type PromotionsResponse struct {
+	Limit      int                `json:"limit"`
+	Offset     int                `json:"offset"`
+	Promotions []dmodel.Promotion `json:"promotions"`
+}
+
+type ApplyCouponDTO struct {
+	Code string `json:"code" validate:"required"`
+}
@@ -0,0 +1,41 @@
+package cmodel
+
+import dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+
+type CreateReturnDTO struct {
+	Reason string                `json:"reason" validate:"required"`
+	Items  []CreateReturnItemDTO `json:"items" validate:"required,min=1,dive"`
+}
+
+type CreateReturnItemDTO struct {
+	ProductId string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"min=1"`
+}
+
+// ToReturn returns the return with the quantities of the same product summed up
+func (d *CreateReturnDTO) ToReturn() *dmodel.Return {
+	r := &dmodel.Return{
+		Reason: d.Reason,
+		Items:  make([]dmodel.ReturnItem, 0, len(d.Items)),
+	}
+	positions := make(map[string]int, len(d.Items))
+	for _, i := range d.Items {
+		if n, ok := positions[i.ProductId]; ok {
+			r.Items[n].Quantity += i.Quantity
+			continue
+		}
+		positions[i.ProductId] = len(r.Items)
+		r.Items = append(r.Items, dmodel.ReturnItem{ProductId: i.ProductId, Quantity: i.Quantity})
+	}
+	return r
+}
+
+type ReviewReturnDTO struct {
+	Note string `json:"note"`
+}
+
+type ReturnsResponse struct {
+	Limit   int             `json:"limit,omitempty"`
+	Offset  int             `json:"offset,omitempty"`
+	Returns []dmodel.Return `json:"returns"`
+}
@@ -0,0 +1,52 @@
+package cmodel
+
+import (
+	"time"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type CreateShipmentDTO struct {
+	Carrier        string                  `json:"carrier" validate:"required,max=64"`
+	TrackingNumber string                  `json:"tracking_number" validate:"max=128"`
+	Items          []CreateShipmentItemDTO `json:"items" validate:"required,min=1,dive"`
+}
+
+type CreateShipmentItemDTO struct {
+	ProductId string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"min=1"`
+}
+
+// ToShipment returns the shipment with the quantities of the same product summed up
+func (d *CreateShipmentDTO) ToShipment() *dmodel.Shipment {
+	sh := &dmodel.Shipment{
+		Carrier:        d.Carrier,
+		TrackingNumber: d.TrackingNumber,
+		Items:          make([]dmodel.ShipmentItem, 0, len(d.Items)),
+	}
+	positions := make(map[string]int, len(d.Items))
+	for _, i := range d.Items {
+		if n, ok := positions[i.ProductId]; ok {
+			sh.Items[n].Quantity += i.Quantity
+			continue
+		}
+		positions[i.ProductId] = len(sh.Items)
+		sh.Items = append(sh.Items, dmodel.ShipmentItem{ProductId: i.ProductId, Quantity: i.Quantity})
+	}
+	return sh
+}
+
+// DeliverShipmentDTO holds the time the shipment was delivered, now if it is not set
+type DeliverShipmentDTO struct {
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+type ShipmentsResponse struct {
+	Shipments []dmodel.Shipment `json:"shipments"`
+}
+
+type FulfillmentOrdersResponse struct {
+	Limit  int                       `json:"limit"`
+	Offset int                       `json:"offset"`
+	Orders []dmodel.FulfillmentOrder `json:"orders"`
+}
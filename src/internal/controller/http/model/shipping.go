@@ -0,0 +1,61 @@
+package cmodel
+
+import (
+	"strings"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+// CreateShippingMethodDTO holds the amounts in minor units of the base currency,
+// up_to of tiers is the weight in grams or the number of items
+type CreateShippingMethodDTO struct {
+	Code     string            `json:"code" validate:"required,max=32"`
+	Name     string            `json:"name" validate:"required"`
+	Kind     string            `json:"kind" validate:"required,oneof=flat weight quantity"`
+	Price    int64             `json:"price" validate:"gte=0"`
+	FreeOver *int64            `json:"free_over" validate:"omitempty,gte=0"`
+	Tiers    []ShippingTierDTO `json:"tiers" validate:"unique=UpTo,dive"`
+	Regions  []string          `json:"regions" validate:"dive,required,max=16"`
+}
+
+type ShippingTierDTO struct {
+	UpTo  int64 `json:"up_to" validate:"gt=0"`
+	Price int64 `json:"price" validate:"gte=0"`
+}
+
+// ToShippingMethod returns the shipping method with upper-cased regions
+func (d *CreateShippingMethodDTO) ToShippingMethod() *dmodel.ShippingMethod {
+	m := &dmodel.ShippingMethod{
+		Code:    strings.TrimSpace(d.Code),
+		Name:    d.Name,
+		Kind:    dmodel.ShippingMethodKind(d.Kind),
+		Price:   dmodel.Money{Amount: d.Price},
+		Tiers:   make([]dmodel.ShippingTier, 0, len(d.Tiers)),
+		Regions: make([]string, 0, len(d.Regions)),
+	}
+	if d.FreeOver != nil {
+		m.FreeOver = &dmodel.Money{Amount: *d.FreeOver}
+	}
+	for _, t := range d.Tiers {
+		m.Tiers = append(m.Tiers, dmodel.ShippingTier{UpTo: t.UpTo, Price: dmodel.Money{Amount: t.Price}})
+	}
+	for _, r := range d.Regions {
+		m.Regions = append(m.Regions, strings.ToUpper(r))
+	}
+	return m
+}
+
+type ShippingMethodsResponse struct {
+	ShippingMethods []dmodel.ShippingMethod `json:"shipping_methods"`
+}
+
+// SetShippingMethodDTO chooses the shipping method of the order, null removes the chosen method
+type SetShippingMethodDTO struct {
+	ShippingMethodId *string `json:"shipping_method_id"`
+}
+
+// ShippingQuotesResponse holds the costs of shipping the order to the region with the available methods
+type ShippingQuotesResponse struct {
+	Region string                 `json:"region"`
+	Quotes []dmodel.ShippingQuote `json:"quotes"`
+}
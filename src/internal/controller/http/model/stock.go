@@ -0,0 +1,54 @@
+package cmodel
+
+import dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+
+type CreateStockMovementDTO struct {
+	ProductId   string `json:"-"`
+	UserId      string `json:"-"`
+	WarehouseId string `json:"warehouse_id" validate:"omitempty,uuid"`
+	Kind        string `json:"kind" validate:"required,oneof=receipt adjustment"`
+	Quantity    int    `json:"quantity" validate:"required"`
+	Reason      string `json:"reason" validate:"required,min=3"`
+}
+
+func (d *CreateStockMovementDTO) ToStockMovement() *dmodel.StockMovement {
+	return &dmodel.StockMovement{
+		ProductId:   d.ProductId,
+		WarehouseId: d.WarehouseId,
+		Kind:        dmodel.StockMovementKind(d.Kind),
+		Quantity:    d.Quantity,
+		Reason:      d.Reason,
+		UserId:      &d.UserId,
+	}
+}
+
+type CreateStockTransferDTO struct {
+	UserId          string `json:"-"`
+	ProductId       string `json:"product_id" validate:"required,uuid"`
+	FromWarehouseId string `json:"from_warehouse_id" validate:"required,uuid"`
+	ToWarehouseId   string `json:"to_warehouse_id" validate:"required,uuid,nefield=FromWarehouseId"`
+	Quantity        int    `json:"quantity" validate:"required,min=1"`
+	Reason          string `json:"reason"`
+}
+
+func (d *CreateStockTransferDTO) ToWarehouseTransfer() *dmodel.WarehouseTransfer {
+	return &dmodel.WarehouseTransfer{
+		ProductId:       d.ProductId,
+		FromWarehouseId: d.FromWarehouseId,
+		ToWarehouseId:   d.ToWarehouseId,
+		Quantity:        d.Quantity,
+		Reason:          d.Reason,
+		UserId:          d.UserId,
+	}
+}
+
+type StockMovementsResponse struct {
+	Limit     int                    `json:"limit"`
+	Offset    int                    `json:"offset"`
+	Movements []dmodel.StockMovement `json:"movements"`
+}
+
+type StockReconciliationResponse struct {
+	DriftCount int                 `json:"drift_count"`
+	Products   []dmodel.StockDrift `json:"products"`
+}
@@ -0,0 +1,28 @@
+package cmodel
+
+import (
+	"strings"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+type CreateTaxRuleDTO struct {
+	Name   string  `json:"name" validate:"required"`
+	Rate   float64 `json:"rate" validate:"min=0,max=100"`
+	Tag    string  `json:"tag"`
+	Region string  `json:"region" validate:"omitempty,max=16"`
+}
+
+// ToTaxRule returns the tax rule with an upper-cased region
+func (d *CreateTaxRuleDTO) ToTaxRule() *dmodel.TaxRule {
+	return &dmodel.TaxRule{
+		Name:   d.Name,
+		Rate:   d.Rate,
+		Tag:    d.Tag,
+		Region: strings.ToUpper(d.Region),
+	}
+}
+
+type TaxRulesResponse struct {
+	TaxRules []dmodel.TaxRule `json:"tax_rules"`
+}
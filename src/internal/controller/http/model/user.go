@@ -19,17 +19,23 @@ type CreateUserDTO struct {
 	RepeatPassword string `json:"repeat_password" validate:"required,min=8"`
 	Age            int    `json:"age" validate:"required,min=18"`
 	IsMarried      bool   `json:"is_married"`
+	Locale         string `json:"locale" validate:"omitempty,oneof=en de"`
 }
 
 func (d *CreateUserDTO) ToUser() *dmodel.User {
-	return &dmodel.User{
+	u := &dmodel.User{
 		FirstName: d.FirstName,
 		LastName:  d.LastName,
 		Email:     d.Email,
 		Password:  d.Password,
 		Age:       d.Age,
 		IsMarried: d.IsMarried,
+		Locale:    d.Locale,
 	}
+	if u.Locale == "" {
+		u.Locale = dmodel.DefaultLocale
+	}
+	return u
 }
 
 type UpdateUserDTO struct {
@@ -39,6 +45,7 @@ type UpdateUserDTO struct {
 	OldPassword *string `json:"old_password,omitempty"`
 	NewPassword *string `json:"new_password,omitempty"`
 	IsMarried   *bool   `json:"is_married,omitempty"`
+	Locale      *string `json:"locale,omitempty"`
 }
 
 func (d *CreateUserDTO) ValidatePassword() error {
@@ -0,0 +1,19 @@
+package cmodel
+
+import dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+
+type CreateWarehouseDTO struct {
+	Code string `json:"code" validate:"required,min=2,max=32"`
+	Name string `json:"name" validate:"required,min=2"`
+}
+
+func (d *CreateWarehouseDTO) ToWarehouse() *dmodel.Warehouse {
+	return &dmodel.Warehouse{
+		Code: d.Code,
+		Name: d.Name,
+	}
+}
+
+type WarehousesResponse struct {
+	Warehouses []dmodel.Warehouse `json:"warehouses"`
+}
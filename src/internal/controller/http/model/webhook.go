@@ -0,0 +1,36 @@
+package cmodel
+
+import dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+
+// CreateWebhookDTO registers the endpoint for the event types, the secret is generated if it is not set
+type CreateWebhookDTO struct {
+	URL        string   `json:"url" validate:"required,http_url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=order.created order.completed product.stock_changed user.created"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+func (d *CreateWebhookDTO) ToWebhookEndpoint() *dmodel.WebhookEndpoint {
+	types := make([]string, 0, len(d.EventTypes))
+	seen := make(map[string]bool, len(d.EventTypes))
+	for _, t := range d.EventTypes {
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	return &dmodel.WebhookEndpoint{
+		URL:        d.URL,
+		Secret:     d.Secret,
+		EventTypes: types,
+	}
+}
+
+type WebhooksResponse struct {
+	Webhooks []dmodel.WebhookEndpoint `json:"webhooks"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Limit      int                      `json:"limit"`
+	Offset     int                      `json:"offset"`
+	Deliveries []dmodel.WebhookDelivery `json:"deliveries"`
+}
@@ -0,0 +1,81 @@
+package dmodel
+
+import (
+	"strings"
+	"time"
+)
+
+type Address struct {
+	Id         string `json:"id"`
+	UserId     string `json:"-"`
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	// Country is the ISO 3166-1 alpha-2 code
+	Country         string    `json:"country"`
+	Phone           string    `json:"phone,omitempty"`
+	DefaultBilling  bool      `json:"default_billing"`
+	DefaultShipping bool      `json:"default_shipping"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TaxRegion returns the region code taxes are calculated for when the order is shipped to the address,
+// e.g. DE or US-CA
+func (a *Address) TaxRegion() string {
+	return taxRegion(a.Country, a.Region)
+}
+
+// OrderAddress is the copy of the address kept in the order at checkout
+type OrderAddress struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone,omitempty"`
+}
+
+// TaxRegion returns the region code taxes of the order shipped to the address are calculated for
+func (a *OrderAddress) TaxRegion() string {
+	return taxRegion(a.Country, a.Region)
+}
+
+// Lines returns the lines of the address as printed on documents
+func (a *OrderAddress) Lines() []string {
+	lines := make([]string, 0, 4)
+	for _, l := range []string{a.Line1, a.Line2} {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	city := strings.TrimSpace(strings.Join([]string{a.PostalCode, a.City, a.Region}, " "))
+	if city != "" {
+		lines = append(lines, strings.Join(strings.Fields(city), " "))
+	}
+	return append(lines, a.Country)
+}
+
+func taxRegion(country, region string) string {
+	if region == "" {
+		return country
+	}
+	return country + "-" + strings.ToUpper(region)
+}
+
+// AddressRule is the validation rule of addresses in the country
+type AddressRule struct {
+	Country            string
+	PostalCodeRequired bool
+	// PostalCodePattern is the regular expression the whole postal code must match
+	PostalCodePattern string
+	RegionRequired    bool
+	// Regions are the allowed region codes, any region is allowed if it is empty
+	Regions       []string
+	PhoneRequired bool
+}
@@ -0,0 +1,37 @@
+package dmodel
+
+import "time"
+
+// Cart collects products before they are ordered, it doesn't reserve stock.
+// Prices and availability of the lines are current, they are recalculated every time the cart is read.
+type Cart struct {
+	Id     string  `json:"id"`
+	UserId *string `json:"user_id"`
+	// Token identifies the anonymous cart, it is returned to the client only for anonymous carts
+	Token     string     `json:"token,omitempty"`
+	Currency  string     `json:"currency"`
+	Items     []CartItem `json:"items"`
+	Subtotal  Money      `json:"subtotal"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type CartItem struct {
+	ProductId   string `json:"product_id"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	// Price is the current price of the product, AddedPrice is its price when it was added to the cart
+	Price        Money `json:"price"`
+	AddedPrice   Money `json:"added_price"`
+	PriceChanged bool  `json:"price_changed"`
+	Total        Money `json:"total"`
+	// Available is the quantity in stock, the line can be ordered if it is not less than the line quantity
+	Available int  `json:"available"`
+	InStock   bool `json:"in_stock"`
+}
+
+// OrderLine is a product and its quantity moved from the cart to a new order
+type OrderLine struct {
+	ProductId string
+	Quantity  int
+}
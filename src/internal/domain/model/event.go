@@ -0,0 +1,135 @@
+package dmodel
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// DomainEvent is a state change published to the rest of the platform. Events are written to the outbox
+// in the transaction of the change and published by the relay after it commits.
+type DomainEvent interface {
+	// EventType is <aggregate type>.<what happened>, e.g. order.created
+	EventType() string
+	AggregateId() string
+}
+
+// AggregateType returns the type of the aggregate the event of the type is about, e.g. order of order.created
+func AggregateType(eventType string) string {
+	t, _, _ := strings.Cut(eventType, ".")
+	return t
+}
+
+type OrderCreated struct {
+	OrderId  string `json:"order_id"`
+	UserId   string `json:"user_id"`
+	Currency string `json:"currency"`
+}
+
+func (e OrderCreated) EventType() string   { return "order.created" }
+func (e OrderCreated) AggregateId() string { return e.OrderId }
+
+type ProductAddedToOrder struct {
+	OrderId   string `json:"order_id"`
+	ProductId string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+func (e ProductAddedToOrder) EventType() string   { return "order.product_added" }
+func (e ProductAddedToOrder) AggregateId() string { return e.OrderId }
+
+type OrderProductQuantityChanged struct {
+	OrderId   string `json:"order_id"`
+	ProductId string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+func (e OrderProductQuantityChanged) EventType() string   { return "order.product_quantity_changed" }
+func (e OrderProductQuantityChanged) AggregateId() string { return e.OrderId }
+
+type ProductRemovedFromOrder struct {
+	OrderId   string `json:"order_id"`
+	ProductId string `json:"product_id"`
+}
+
+func (e ProductRemovedFromOrder) EventType() string   { return "order.product_removed" }
+func (e ProductRemovedFromOrder) AggregateId() string { return e.OrderId }
+
+// OrderCompleted reports the checked out order with its frozen total
+type OrderCompleted struct {
+	OrderId string `json:"order_id"`
+	UserId  string `json:"user_id"`
+	Number  string `json:"number"`
+	Total   Money  `json:"total"`
+}
+
+func (e OrderCompleted) EventType() string   { return "order.completed" }
+func (e OrderCompleted) AggregateId() string { return e.OrderId }
+
+type ProductCreated struct {
+	ProductId   string   `json:"product_id"`
+	Description string   `json:"description"`
+	Price       Money    `json:"price"`
+	Tags        []string `json:"tags"`
+}
+
+func (e ProductCreated) EventType() string   { return "product.created" }
+func (e ProductCreated) AggregateId() string { return e.ProductId }
+
+type ProductUpdated struct {
+	ProductId   string   `json:"product_id"`
+	Description string   `json:"description"`
+	Price       Money    `json:"price"`
+	Tags        []string `json:"tags"`
+}
+
+func (e ProductUpdated) EventType() string   { return "product.updated" }
+func (e ProductUpdated) AggregateId() string { return e.ProductId }
+
+// ProductStockChanged reports a movement of the stock ledger with the product quantity after it
+type ProductStockChanged struct {
+	ProductId   string            `json:"product_id"`
+	WarehouseId string            `json:"warehouse_id"`
+	Kind        StockMovementKind `json:"kind"`
+	Change      int               `json:"change"`
+	Quantity    int               `json:"quantity"`
+	OrderId     *string           `json:"order_id,omitempty"`
+}
+
+func (e ProductStockChanged) EventType() string   { return "product.stock_changed" }
+func (e ProductStockChanged) AggregateId() string { return e.ProductId }
+
+type UserCreated struct {
+	UserId string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func (e UserCreated) EventType() string   { return "user.created" }
+func (e UserCreated) AggregateId() string { return e.UserId }
+
+// UserUpdated lists the changed fields of the user without their values, so passwords do not leak
+type UserUpdated struct {
+	UserId string   `json:"user_id"`
+	Fields []string `json:"fields"`
+}
+
+func (e UserUpdated) EventType() string   { return "user.updated" }
+func (e UserUpdated) AggregateId() string { return e.UserId }
+
+type UserDeleted struct {
+	UserId string `json:"user_id"`
+}
+
+func (e UserDeleted) EventType() string   { return "user.deleted" }
+func (e UserDeleted) AggregateId() string { return e.UserId }
+
+// OutboxEvent is a domain event stored in the outbox
+type OutboxEvent struct {
+	Id            int64           `json:"-"`
+	EventId       string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
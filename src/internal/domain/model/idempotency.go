@@ -0,0 +1,21 @@
+package dmodel
+
+import "net/http"
+
+// IdempotencyKey is a mutating request sent with the Idempotency-Key header. Fingerprint identifies
+// the request payload, StatusCode is 0 while the request is in progress.
+type IdempotencyKey struct {
+	Scope           string
+	Key             string
+	Method          string
+	Path            string
+	Fingerprint     string
+	StatusCode      int
+	ResponseHeaders http.Header
+	ResponseBody    []byte
+}
+
+// Completed reports whether the response of the request is stored
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,41 @@
+package dmodel
+
+import (
+	"strings"
+	"time"
+)
+
+// Invoice is issued once for a checked out order with a sequential number. Seller and buyer details
+// are copied when it is issued, the lines and amounts are the frozen ones of the order.
+type Invoice struct {
+	Id       string       `json:"id"`
+	OrderId  string       `json:"order_id"`
+	Number   string       `json:"number"`
+	IssuedAt time.Time    `json:"issued_at"`
+	Seller   InvoiceParty `json:"seller"`
+	Buyer    InvoiceParty `json:"buyer"`
+	// MinorUnits is the number of digits after the decimal point of the order currency
+	MinorUnits int `json:"-"`
+}
+
+type InvoiceParty struct {
+	Name    string   `json:"name"`
+	Address []string `json:"address,omitempty"`
+	TaxId   string   `json:"tax_id,omitempty"`
+	Email   string   `json:"email,omitempty"`
+}
+
+// InvoiceBuyer returns the buyer of the order placed by the user, billed to the billing address if it is set
+func InvoiceBuyer(firstName, lastName, email string, billing *OrderAddress) InvoiceParty {
+	buyer := InvoiceParty{
+		Name:  strings.TrimSpace(firstName + " " + lastName),
+		Email: email,
+	}
+	if billing != nil {
+		if billing.Name != "" {
+			buyer.Name = billing.Name
+		}
+		buyer.Address = billing.Lines()
+	}
+	return buyer
+}
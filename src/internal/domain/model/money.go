@@ -0,0 +1,36 @@
+package dmodel
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Money is an amount in minor units of the currency, e.g. cents for USD
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// ExchangeRate is the number of major units of the currency for one major unit of the base currency.
+// MinorUnits is the number of digits after the decimal point of the currency.
+type ExchangeRate struct {
+	Currency   string    `json:"currency"`
+	Rate       float64   `json:"rate"`
+	MinorUnits int       `json:"minor_units"`
+	IsBase     bool      `json:"is_base"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// FormatAmount formats the amount in minor units with minorUnits digits after the decimal point
+func FormatAmount(amount int64, minorUnits int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if minorUnits <= 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	s := fmt.Sprintf("%0*d", minorUnits+1, amount)
+	return sign + s[:len(s)-minorUnits] + "." + s[len(s)-minorUnits:]
+}
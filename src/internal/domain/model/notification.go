@@ -0,0 +1,41 @@
+package dmodel
+
+import "time"
+
+// DefaultLocale is the language of emails of users without a locale and of events without a translation
+const DefaultLocale = "en"
+
+// NotificationEvent is the domain event a notification is sent for
+type NotificationEvent string
+
+const (
+	EventUserSignedUp    NotificationEvent = "user.signed_up"
+	EventPasswordChanged NotificationEvent = "user.password_changed"
+	EventOrderPlaced     NotificationEvent = "order.placed"
+	EventOrderShipped    NotificationEvent = "order.shipped"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationFailed  NotificationStatus = "failed"
+)
+
+// Notification is a rendered email in the delivery queue. It stays pending while attempts are left,
+// the failed notification is not retried anymore.
+type Notification struct {
+	Id            string             `json:"id"`
+	Event         NotificationEvent  `json:"event"`
+	UserId        *string            `json:"user_id,omitempty"`
+	Recipient     string             `json:"recipient"`
+	Subject       string             `json:"subject"`
+	Body          string             `json:"body"`
+	Status        NotificationStatus `json:"status"`
+	Attempts      int                `json:"attempts"`
+	LastError     string             `json:"last_error,omitempty"`
+	NextAttemptAt time.Time          `json:"next_attempt_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+	SentAt        *time.Time         `json:"sent_at,omitempty"`
+}
@@ -3,9 +3,71 @@ package dmodel
 import "time"
 
 type Order struct {
-	Id        string    `json:"id"`
+	Id string `json:"id"`
+	// Number is the human-friendly number given to the order at checkout
+	Number    string    `json:"number,omitempty"`
 	UserId    string    `json:"user_id"`
 	CreatedAt time.Time `json:"created_at"`
 	Completed bool      `json:"completed"`
-	Cost      int       `json:"cost"`
+	// CheckedOutAt is the time the order was checked out and became immutable
+	CheckedOutAt *time.Time `json:"checked_out_at,omitempty"`
+	// PaidAt is the time the payment of the order total was captured
+	PaidAt *time.Time `json:"paid_at,omitempty"`
+	// FulfillmentStatus is updated when shipments of the order are created and delivered
+	FulfillmentStatus FulfillmentStatus `json:"fulfillment_status"`
+	Currency          string            `json:"currency"`
+	// ShippingRegion is the region code taxes are calculated for, e.g. DE or US-CA
+	ShippingRegion string `json:"shipping_region"`
+	// ShippingAddressId and BillingAddressId are the addresses chosen from the address book of the user,
+	// their copies are kept in ShippingAddress and BillingAddress at checkout
+	ShippingAddressId *string       `json:"shipping_address_id,omitempty"`
+	BillingAddressId  *string       `json:"billing_address_id,omitempty"`
+	ShippingAddress   *OrderAddress `json:"shipping_address,omitempty"`
+	BillingAddress    *OrderAddress `json:"billing_address,omitempty"`
+	// ShippingMethodId is the chosen shipping method, ShippingMethod is its name frozen at checkout
+	ShippingMethodId *string `json:"shipping_method_id,omitempty"`
+	ShippingMethod   string  `json:"shipping_method,omitempty"`
+	// ReservedUntil is the time the stock for the order items is reserved until,
+	// nil if the reservation was released or the order is completed
+	ReservedUntil *time.Time `json:"reserved_until"`
+	// Subtotal is the sum of the order lines, Total is the subtotal minus discounts plus shipping
+	// plus tax if prices do not include tax. The amounts are frozen when the order is completed.
+	Subtotal         Money           `json:"subtotal"`
+	Discounts        []OrderDiscount `json:"discounts"`
+	Shipping         Money           `json:"shipping"`
+	Tax              Money           `json:"tax"`
+	PricesIncludeTax bool            `json:"prices_include_tax"`
+	Total            Money           `json:"total"`
+	Coupon           string          `json:"coupon,omitempty"`
+	Items            []OrderItem     `json:"items,omitempty"`
+}
+
+type OrderItem struct {
+	OrderId     string `json:"-"`
+	ProductId   string `json:"product_id"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	Quantity    int    `json:"quantity"`
+	Total       Money  `json:"total"`
+	// Discount is the part of the order discounts given to the line,
+	// tax is calculated from the line total minus the discount
+	Discount Money   `json:"discount"`
+	TaxRate  float64 `json:"tax_rate"`
+	Tax      Money   `json:"tax"`
+	// Tags are the current tags of the product, promotions and taxes can be limited to them
+	Tags []string `json:"-"`
+	// Weight is the current weight of one product item in grams, zero if it is unknown
+	Weight int `json:"-"`
+}
+
+// OrderContentEvent is a change of an order line recorded in the order content history
+type OrderContentEvent struct {
+	Id          int64     `json:"id"`
+	Operation   string    `json:"operation"`
+	Stamp       time.Time `json:"stamp"`
+	ProductId   string    `json:"product_id"`
+	Description string    `json:"description"`
+	Price       Money     `json:"price"`
+	Quantity    int       `json:"quantity"`
+	UserId      *string   `json:"user_id"`
 }
@@ -0,0 +1,75 @@
+package dmodel
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentFailed     PaymentStatus = "failed"
+	PaymentCanceled   PaymentStatus = "canceled"
+)
+
+// CanBecome reports whether the payment status can be changed to the next one,
+// captured, failed and canceled payments are final
+func (s PaymentStatus) CanBecome(next PaymentStatus) bool {
+	switch s {
+	case PaymentPending:
+		return next != PaymentPending
+	case PaymentAuthorized:
+		return next == PaymentCaptured || next == PaymentFailed || next == PaymentCanceled
+	}
+	return false
+}
+
+// Payment is an attempt to pay the order total through a payment provider,
+// IntentId is the id of the payment at the provider
+type Payment struct {
+	Id             string        `json:"id"`
+	OrderId        string        `json:"order_id"`
+	Provider       string        `json:"provider"`
+	IntentId       string        `json:"intent_id,omitempty"`
+	Amount         Money         `json:"amount"`
+	Status         PaymentStatus `json:"status"`
+	CapturedAmount Money         `json:"captured_amount"`
+	RefundedAmount Money         `json:"refunded_amount"`
+	// ClientSecret is used by the client to complete the payment with the provider, it is only returned on creation
+	ClientSecret string    `json:"client_secret,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// Refund returns a part of the captured payment to the customer, optionally for a received return
+type Refund struct {
+	Id               string       `json:"id"`
+	OrderId          string       `json:"order_id"`
+	PaymentId        string       `json:"payment_id"`
+	ReturnId         *string      `json:"return_id"`
+	Amount           Money        `json:"amount"`
+	Status           RefundStatus `json:"status"`
+	ProviderRefundId string       `json:"provider_refund_id,omitempty"`
+	Reason           string       `json:"reason"`
+	UserId           *string      `json:"user_id"`
+	CreatedAt        time.Time    `json:"created_at"`
+}
+
+// PaymentEvent is a change of the payment status reported by the provider
+type PaymentEvent struct {
+	Provider string
+	EventId  string
+	Type     string
+	IntentId string
+	Status   PaymentStatus
+	// Amount is the captured amount for captured payments
+	Amount int64
+}
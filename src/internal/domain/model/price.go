@@ -0,0 +1,16 @@
+package dmodel
+
+import "time"
+
+// ProductPrice is an entry of the product price history.
+// A price without EffectiveTo is valid until the next such price takes effect,
+// when several prices are valid at the same time the one that took effect last wins.
+type ProductPrice struct {
+	Id            int64      `json:"id"`
+	ProductId     string     `json:"product_id"`
+	Price         Money      `json:"price"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	UserId        *string    `json:"user_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -2,8 +2,20 @@ package dmodel
 
 type Product struct {
 	Id          string   `json:"id"`
-	Price       int      `json:"price"`
+	Price       Money    `json:"price"`
 	Quantity    int      `json:"quantity"`
 	Description string   `json:"description"`
 	Tags        []string `json:"tags"`
+	// Weight is the weight of one item in grams, shipping by weight needs it
+	Weight     *int        `json:"weight,omitempty"`
+	Dimensions *Dimensions `json:"dimensions,omitempty"`
+
+	Availability []WarehouseStock `json:"availability,omitempty"`
+}
+
+// Dimensions are the length, width and height of one packed item in millimeters
+type Dimensions struct {
+	Length int `json:"length" validate:"gt=0"`
+	Width  int `json:"width" validate:"gt=0"`
+	Height int `json:"height" validate:"gt=0"`
 }
@@ -0,0 +1,55 @@
+package dmodel
+
+import "time"
+
+type PromotionKind string
+
+const (
+	PromotionPercentage PromotionKind = "percentage"
+	PromotionFixed      PromotionKind = "fixed"
+	PromotionBuyXGetY   PromotionKind = "buy_x_get_y"
+)
+
+// Promotion is a discount applied to an order with a coupon code.
+// Value is the percentage for percentage promotions and the amount in minor units of Currency for fixed ones.
+// Buy X get Y promotions give GetQuantity units for free for every BuyQuantity + GetQuantity units of a line.
+// The discount is limited to lines of products tagged with Tag if it is set.
+type Promotion struct {
+	Id                string        `json:"id"`
+	Code              string        `json:"code"`
+	Name              string        `json:"name"`
+	Kind              PromotionKind `json:"kind"`
+	Value             int64         `json:"value"`
+	Currency          string        `json:"currency,omitempty"`
+	Tag               string        `json:"tag,omitempty"`
+	BuyQuantity       int           `json:"buy_quantity,omitempty"`
+	GetQuantity       int           `json:"get_quantity,omitempty"`
+	MinOrderValue     int64         `json:"min_order_value,omitempty"`
+	UsageLimit        *int          `json:"usage_limit"`
+	UsageLimitPerUser *int          `json:"usage_limit_per_user"`
+	StartsAt          *time.Time    `json:"starts_at"`
+	EndsAt            *time.Time    `json:"ends_at"`
+	Uses              int           `json:"uses"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+// IsActive reports whether the promotion validity window contains the moment
+func (p *Promotion) IsActive(at time.Time) bool {
+	return (p.StartsAt == nil || !at.Before(*p.StartsAt)) && (p.EndsAt == nil || at.Before(*p.EndsAt))
+}
+
+// OrderCoupon is a promotion applied to an order
+type OrderCoupon struct {
+	OrderId   string
+	Promotion Promotion
+	// Discount is the discount frozen when the order was completed, nil for draft orders
+	Discount *int64
+}
+
+// OrderDiscount is a discount of the order cost given by a promotion
+type OrderDiscount struct {
+	PromotionId string `json:"promotion_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Amount      Money  `json:"amount"`
+}
@@ -0,0 +1,47 @@
+package dmodel
+
+import "time"
+
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "requested"
+	ReturnApproved  ReturnStatus = "approved"
+	ReturnRejected  ReturnStatus = "rejected"
+	ReturnReceived  ReturnStatus = "received"
+	ReturnRefunded  ReturnStatus = "refunded"
+)
+
+// CanBecome reports whether the return status can be changed to the next one:
+// requested returns are approved or rejected by staff, approved ones are received into stock and then refunded
+func (s ReturnStatus) CanBecome(next ReturnStatus) bool {
+	switch s {
+	case ReturnRequested:
+		return next == ReturnApproved || next == ReturnRejected
+	case ReturnApproved:
+		return next == ReturnReceived
+	case ReturnReceived:
+		return next == ReturnRefunded
+	}
+	return false
+}
+
+// Return is a request of the customer to return products of a checked out order (RMA).
+// Amount is the refund suggested for the returned products.
+type Return struct {
+	Id        string       `json:"id"`
+	OrderId   string       `json:"order_id"`
+	UserId    string       `json:"user_id"`
+	Status    ReturnStatus `json:"status"`
+	Reason    string       `json:"reason"`
+	Note      string       `json:"note,omitempty"`
+	Amount    Money        `json:"amount"`
+	Items     []ReturnItem `json:"items"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type ReturnItem struct {
+	ProductId string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
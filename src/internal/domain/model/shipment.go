@@ -0,0 +1,57 @@
+package dmodel
+
+import "time"
+
+type ShipmentStatus string
+
+const (
+	ShipmentShipped   ShipmentStatus = "shipped"
+	ShipmentDelivered ShipmentStatus = "delivered"
+)
+
+// FulfillmentStatus is the status of shipping the checked out order: unfulfilled until the first shipment,
+// partially shipped until all lines are shipped and delivered when all shipments are delivered
+type FulfillmentStatus string
+
+const (
+	FulfillmentUnfulfilled      FulfillmentStatus = "unfulfilled"
+	FulfillmentPartiallyShipped FulfillmentStatus = "partially_shipped"
+	FulfillmentShipped          FulfillmentStatus = "shipped"
+	FulfillmentDelivered        FulfillmentStatus = "delivered"
+)
+
+// Shipment is a parcel with products of lines of a paid order handed over to a carrier by staff
+type Shipment struct {
+	Id             string         `json:"id"`
+	OrderId        string         `json:"order_id"`
+	Status         ShipmentStatus `json:"status"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number,omitempty"`
+	// UserId is the staff member who created the shipment
+	UserId      *string        `json:"user_id,omitempty"`
+	Items       []ShipmentItem `json:"items"`
+	ShippedAt   time.Time      `json:"shipped_at"`
+	DeliveredAt *time.Time     `json:"delivered_at"`
+}
+
+type ShipmentItem struct {
+	ProductId string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// FulfillmentOrder is a paid order waiting to be shipped with the quantities of its lines not shipped yet
+type FulfillmentOrder struct {
+	OrderId        string            `json:"order_id"`
+	Number         string            `json:"number"`
+	Status         FulfillmentStatus `json:"fulfillment_status"`
+	ShippingMethod string            `json:"shipping_method,omitempty"`
+	CheckedOutAt   *time.Time        `json:"checked_out_at"`
+	PaidAt         *time.Time        `json:"paid_at"`
+	Items          []FulfillmentItem `json:"items"`
+}
+
+type FulfillmentItem struct {
+	ProductId   string `json:"product_id"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+}
@@ -0,0 +1,58 @@
+package dmodel
+
+import (
+	"strings"
+	"time"
+)
+
+type ShippingMethodKind string
+
+const (
+	ShippingFlat     ShippingMethodKind = "flat"
+	ShippingWeight   ShippingMethodKind = "weight"
+	ShippingQuantity ShippingMethodKind = "quantity"
+)
+
+// ShippingMethod is a way to deliver orders. Flat methods cost Price, weight and quantity methods cost
+// the price of the first tier the order weight in grams or the number of items fits in. Orders with
+// the subtotal minus discounts of at least FreeOver are shipped for free. The method is available
+// in Regions and their subregions, everywhere if Regions is empty. Amounts are stored in the base
+// currency and converted to the currency of the order.
+type ShippingMethod struct {
+	Id        string             `json:"id"`
+	Code      string             `json:"code"`
+	Name      string             `json:"name"`
+	Kind      ShippingMethodKind `json:"kind"`
+	Price     Money              `json:"price"`
+	FreeOver  *Money             `json:"free_over"`
+	Tiers     []ShippingTier     `json:"tiers"`
+	Regions   []string           `json:"regions"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// ShippingTier is the price of shipping orders with the weight in grams or the number of items up to UpTo
+type ShippingTier struct {
+	UpTo  int64 `json:"up_to"`
+	Price Money `json:"price"`
+}
+
+// AvailableIn reports whether orders shipped to the region can be shipped with the method
+func (m *ShippingMethod) AvailableIn(region string) bool {
+	if len(m.Regions) == 0 {
+		return true
+	}
+	for _, r := range m.Regions {
+		if region == r || strings.HasPrefix(region, r+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// ShippingQuote is the cost of shipping an order with the method
+type ShippingQuote struct {
+	MethodId string `json:"method_id"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Price    Money  `json:"price"`
+}
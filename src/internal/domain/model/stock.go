@@ -0,0 +1,39 @@
+package dmodel
+
+import "time"
+
+type StockMovementKind string
+
+const (
+	StockReceipt      StockMovementKind = "receipt"
+	StockSale         StockMovementKind = "sale"
+	StockReturn       StockMovementKind = "return"
+	StockAdjustment   StockMovementKind = "adjustment"
+	StockCancellation StockMovementKind = "cancellation"
+	StockTransfer     StockMovementKind = "transfer"
+)
+
+// StockMovement is an entry of the append-only stock ledger.
+// Quantity is signed: positive movements add products to stock, negative take them from stock.
+type StockMovement struct {
+	Id          int64             `json:"id"`
+	ProductId   string            `json:"product_id"`
+	WarehouseId string            `json:"warehouse_id"`
+	Kind        StockMovementKind `json:"kind"`
+	Quantity    int               `json:"quantity"`
+	Reason      string            `json:"reason"`
+	OrderId     *string           `json:"order_id,omitempty"`
+	UserId      *string           `json:"user_id,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// StockDrift compares the product quantity with the quantity derived from the stock ledger
+// and with the sum of the product quantities in all warehouses
+type StockDrift struct {
+	ProductId         string `json:"product_id"`
+	Description       string `json:"description"`
+	Quantity          int    `json:"quantity"`
+	LedgerQuantity    int    `json:"ledger_quantity"`
+	WarehouseQuantity int    `json:"warehouse_quantity"`
+	Drift             int    `json:"drift"`
+}
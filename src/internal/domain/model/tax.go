@@ -0,0 +1,43 @@
+package dmodel
+
+import (
+	"strings"
+	"time"
+)
+
+// TaxRule is a tax rate in percent applied to lines of products tagged with Tag in orders shipped to Region
+// or its subregions. Empty Tag or Region means the rule applies to all products or regions.
+type TaxRule struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Rate      float64   `json:"rate"`
+	Tag       string    `json:"tag,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches reports whether the rule applies to a product with the tags in an order shipped to the region
+func (r *TaxRule) Matches(tags []string, region string) bool {
+	if r.Region != "" && region != r.Region && !strings.HasPrefix(region, r.Region+"-") {
+		return false
+	}
+	if r.Tag == "" {
+		return true
+	}
+	for _, t := range tags {
+		if t == r.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Specificity orders matching rules, rules for a tag win over rules for a region
+// and rules for a subregion win over rules for its region
+func (r *TaxRule) Specificity() int {
+	s := len(r.Region)
+	if r.Tag != "" {
+		s += 1000
+	}
+	return s
+}
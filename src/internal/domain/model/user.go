@@ -14,6 +14,10 @@ type User struct {
 	Password  string `json:"-"`
 	Age       int    `json:"age"`
 	IsMarried bool   `json:"is_married"`
+	// Locale is the language of the emails sent to the user
+	Locale string `json:"locale"`
+	// IsAdmin grants the admin routes, it can't be set through the API
+	IsAdmin bool `json:"is_admin"`
 }
 
 func (u *User) CheckPassword(password string) error {
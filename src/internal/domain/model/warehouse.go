@@ -0,0 +1,28 @@
+package dmodel
+
+import "time"
+
+type Warehouse struct {
+	Id        string    `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WarehouseStock is the quantity of a product available in one warehouse
+type WarehouseStock struct {
+	WarehouseId   string `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	WarehouseName string `json:"warehouse_name"`
+	Quantity      int    `json:"quantity"`
+}
+
+type WarehouseTransfer struct {
+	ProductId       string `json:"product_id"`
+	FromWarehouseId string `json:"from_warehouse_id"`
+	ToWarehouseId   string `json:"to_warehouse_id"`
+	Quantity        int    `json:"quantity"`
+	Reason          string `json:"reason"`
+	UserId          string `json:"-"`
+}
@@ -0,0 +1,63 @@
+package dmodel
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEventTypes are the types of outbox events webhook endpoints can subscribe to
+var WebhookEventTypes = []string{
+	OrderCreated{}.EventType(),
+	OrderCompleted{}.EventType(),
+	ProductStockChanged{}.EventType(),
+	UserCreated{}.EventType(),
+}
+
+// WebhookEndpoint is a URL receiving events of the subscribed types signed with the secret.
+// The secret is shown only when the endpoint is created.
+type WebhookEndpoint struct {
+	Id         string    `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDead      WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is the delivery of an event to an endpoint. Log has the attempts of the delivery
+// when it is requested by id.
+type WebhookDelivery struct {
+	Id            int64                 `json:"id"`
+	EndpointId    string                `json:"endpoint_id"`
+	EventId       string                `json:"event_id"`
+	EventType     string                `json:"event_type"`
+	Payload       json.RawMessage       `json:"payload"`
+	OccurredAt    time.Time             `json:"occurred_at"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	LastError     string                `json:"last_error,omitempty"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	DeliveredAt   *time.Time            `json:"delivered_at,omitempty"`
+	Log           []WebhookAttempt      `json:"log,omitempty"`
+	// URL and Secret are of the endpoint, they are set for deliveries claimed to be sent
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookAttempt is a request made for a delivery, StatusCode is nil if there was no response
+type WebhookAttempt struct {
+	Id          int64     `json:"id"`
+	DeliveryId  int64     `json:"-"`
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int       `json:"duration_ms"`
+}
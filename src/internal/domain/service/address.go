@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+// countryRule is the address rule of the country with the compiled postal code pattern
+type countryRule struct {
+	dmodel.AddressRule
+	postalCode *regexp.Regexp
+}
+
+type addressService struct {
+	storage             storage.AddressStorage
+	rules               map[string]countryRule
+	onlyListedCountries bool
+	logger              *logging.Logger
+}
+
+// NewAddressService returns the address service validating addresses by the per-country rules,
+// addresses in countries without rules are rejected if onlyListedCountries is set
+func NewAddressService(s storage.AddressStorage, rules []dmodel.AddressRule, onlyListedCountries bool,
+	l *logging.Logger) (*addressService, error) {
+	compiled := make(map[string]countryRule, len(rules))
+	for _, r := range rules {
+		cr := countryRule{AddressRule: r}
+		if r.PostalCodePattern != "" {
+			re, err := regexp.Compile("^(?:" + r.PostalCodePattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid postal code pattern of country %s: %w", r.Country, err)
+			}
+			cr.postalCode = re
+		}
+		compiled[r.Country] = cr
+	}
+	return &addressService{
+		storage:             s,
+		rules:               compiled,
+		onlyListedCountries: onlyListedCountries,
+		logger:              l,
+	}, nil
+}
+
+func (s *addressService) Create(ctx context.Context, userId string, req *cmodel.AddressDTO) (r dmodel.Address, err error) {
+	newAddress := req.ToAddress()
+	if err = s.validate(newAddress); err != nil {
+		return r, err
+	}
+	newAddress.Id = uuid.New().String()
+	newAddress.UserId = userId
+	newAddress.CreatedAt = time.Now()
+	r, err = s.storage.Create(ctx, newAddress)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to create address of user (id %s), error: %w", userId, err)
+	}
+	return r, nil
+}
+
+func (s *addressService) GetAllByUserId(ctx context.Context, userId string) (r cmodel.AddressesResponse, err error) {
+	addresses, err := s.storage.FindAllByUserId(ctx, userId)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find addresses of user (id %s), error: %w", userId, err)
+	}
+	r.Addresses = addresses
+	return r, nil
+}
+
+func (s *addressService) GetOneById(ctx context.Context, userId, id string) (r dmodel.Address, err error) {
+	r, err = s.storage.FindOneById(ctx, userId, id)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find address (id %s), error: %w", id, err)
+	}
+	return r, nil
+}
+
+func (s *addressService) Update(ctx context.Context, userId, id string, req *cmodel.AddressDTO) (r dmodel.Address, err error) {
+	address := req.ToAddress()
+	if err = s.validate(address); err != nil {
+		return r, err
+	}
+	address.Id = id
+	address.UserId = userId
+	r, err = s.storage.Update(ctx, address)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to update address (id %s), error: %w", id, err)
+	}
+	return r, nil
+}
+
+func (s *addressService) Delete(ctx context.Context, userId, id string) error {
+	if err := s.storage.Delete(ctx, userId, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete address (id %s), error: %w", id, err)
+	}
+	return nil
+}
+
+// validate checks the address against the rule of its country
+func (s *addressService) validate(a *dmodel.Address) error {
+	rule, ok := s.rules[a.Country]
+	if !ok {
+		if s.onlyListedCountries {
+			return fmt.Errorf("%w: addresses in country %s are not accepted", apperror.ErrInvalidAddress, a.Country)
+		}
+		return nil
+	}
+
+	problems := make([]string, 0)
+	switch {
+	case a.PostalCode == "" && rule.PostalCodeRequired:
+		problems = append(problems, "postal code is required")
+	case a.PostalCode != "" && rule.postalCode != nil && !rule.postalCode.MatchString(a.PostalCode):
+		problems = append(problems, fmt.Sprintf("postal code %q has invalid format", a.PostalCode))
+	}
+	switch {
+	case a.Region == "" && rule.RegionRequired:
+		problems = append(problems, "region is required")
+	case a.Region != "" && len(rule.Regions) > 0 && !containsString(rule.Regions, strings.ToUpper(a.Region)):
+		problems = append(problems, fmt.Sprintf("region %q is unknown", a.Region))
+	}
+	if a.Phone == "" && rule.PhoneRequired {
+		problems = append(problems, "phone is required")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w for country %s: %s", apperror.ErrInvalidAddress, a.Country, strings.Join(problems, ", "))
+	}
+	if len(rule.Regions) > 0 {
+		a.Region = strings.ToUpper(a.Region)
+	}
+	return nil
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
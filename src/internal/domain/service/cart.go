@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+// cartTokenSize is the number of random bytes in the anonymous cart token
+const cartTokenSize = 24
+
+// cartService keeps carts of users and anonymous visitors, a cart is identified by the user id
+// or, if it is empty, by the anonymous token
+type cartService struct {
+	storage      storage.CartStorage
+	orderService OrderService
+	logger       *logging.Logger
+}
+
+func NewCartService(s storage.CartStorage, os OrderService, l *logging.Logger) *cartService {
+	return &cartService{
+		storage:      s,
+		orderService: os,
+		logger:       l,
+	}
+}
+
+func (s *cartService) Get(ctx context.Context, userId, token string) (r dmodel.Cart, err error) {
+	if userId == "" && token == "" {
+		return r, apperror.ErrNotFound
+	}
+	r, err = s.storage.FindOne(ctx, userId, token)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find cart, error: %w", err)
+	}
+	priceCart(&r)
+	return r, nil
+}
+
+// AddItem adds the product to the cart creating the cart if there is none,
+// a new anonymous cart gets a new token
+func (s *cartService) AddItem(ctx context.Context, userId, token, currency string, req *cmodel.AddCartItemDTO) (r dmodel.Cart, err error) {
+	c, err := s.getOrCreate(ctx, userId, token, currency)
+	if err != nil {
+		return r, err
+	}
+	if err = s.storage.AddItem(ctx, c.Id, req.ProductId, req.Quantity); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to add product (id %s) to cart (id %s), error: %w", req.ProductId, c.Id, err)
+	}
+	return s.Get(ctx, userId, c.Token)
+}
+
+func (s *cartService) SetItemQuantity(ctx context.Context, userId, token, productId string, quantity int) (r dmodel.Cart, err error) {
+	c, err := s.Get(ctx, userId, token)
+	if err != nil {
+		return r, err
+	}
+	if err = s.storage.SetItemQuantity(ctx, c.Id, productId, quantity); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to set quantity of product (id %s) in cart (id %s), error: %w", productId, c.Id, err)
+	}
+	return s.Get(ctx, userId, token)
+}
+
+func (s *cartService) DeleteItem(ctx context.Context, userId, token, productId string) (r dmodel.Cart, err error) {
+	c, err := s.Get(ctx, userId, token)
+	if err != nil {
+		return r, err
+	}
+	if err = s.storage.DeleteItem(ctx, c.Id, productId); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to delete product (id %s) from cart (id %s), error: %w", productId, c.Id, err)
+	}
+	return s.Get(ctx, userId, token)
+}
+
+// Merge moves products of the anonymous cart to the cart of the user who logged in,
+// an unknown token is ignored
+func (s *cartService) Merge(ctx context.Context, token, userId string) error {
+	if err := s.storage.Merge(ctx, token, userId); err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil
+		}
+		s.logger.Error(err)
+		return fmt.Errorf("failed to merge cart into cart of user (id %s), error: %w", userId, err)
+	}
+	return nil
+}
+
+// Checkout converts the cart of the user to a draft order in the cart currency reserving stock
+// for all its products, the cart is emptied in the transaction creating the order
+func (s *cartService) Checkout(ctx context.Context, userId string, req *cmodel.CheckoutCartDTO) (r dmodel.Order, err error) {
+	c, err := s.Get(ctx, userId, "")
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, apperror.ErrEmptyCart
+		}
+		return r, err
+	}
+	if len(c.Items) == 0 {
+		return r, apperror.ErrEmptyCart
+	}
+
+	return s.orderService.CreateFromCart(ctx, &cmodel.CreateOrderDTO{
+		UserId:         userId,
+		Currency:       c.Currency,
+		ShippingRegion: req.ShippingRegion,
+	}, c.Id)
+}
+
+func (s *cartService) getOrCreate(ctx context.Context, userId, token, currency string) (r dmodel.Cart, err error) {
+	if userId != "" || token != "" {
+		r, err = s.storage.FindOne(ctx, userId, token)
+		if err == nil {
+			return r, nil
+		}
+		if !errors.Is(err, apperror.ErrNotFound) {
+			s.logger.Error(err)
+			return r, fmt.Errorf("failed to find cart, error: %w", err)
+		}
+	}
+
+	newCart := &dmodel.Cart{
+		Id:        uuid.New().String(),
+		Currency:  currency,
+		CreatedAt: time.Now(),
+	}
+	if userId != "" {
+		newCart.UserId = &userId
+	} else if newCart.Token, err = newCartToken(); err != nil {
+		return r, fmt.Errorf("failed to generate cart token, error: %w", err)
+	}
+
+	r, err = s.storage.Create(ctx, newCart)
+	if errors.Is(err, apperror.ErrAlreadyExists) {
+		// the cart of the user was created by a concurrent request
+		r, err = s.storage.FindOne(ctx, userId, "")
+	}
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrUnsupportedCurrency) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create cart, error: %w", err)
+	}
+	return r, nil
+}
+
+// priceCart sets the line totals, price changes and availability and the cart subtotal
+func priceCart(c *dmodel.Cart) {
+	c.Subtotal = dmodel.Money{Currency: c.Currency}
+	for k := range c.Items {
+		i := &c.Items[k]
+		i.PriceChanged = i.Price.Amount != i.AddedPrice.Amount
+		i.Total = dmodel.Money{Amount: i.Price.Amount * int64(i.Quantity), Currency: c.Currency}
+		i.InStock = i.Available >= i.Quantity
+		c.Subtotal.Amount += i.Total.Amount
+	}
+}
+
+func newCartToken() (string, error) {
+	b := make([]byte, cartTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type currencyService struct {
+	storage storage.CurrencyStorage
+	logger  *logging.Logger
+}
+
+func NewCurrencyService(s storage.CurrencyStorage, l *logging.Logger) *currencyService {
+	return &currencyService{
+		storage: s,
+		logger:  l,
+	}
+}
+
+func (s *currencyService) GetRates(ctx context.Context) (r cmodel.ExchangeRatesResponse, err error) {
+	rates, err := s.storage.FindAllRates(ctx)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find exchange rates, error: %w", err)
+	}
+	for _, er := range rates {
+		if er.IsBase {
+			r.Base = er.Currency
+		}
+	}
+	r.Rates = rates
+	return r, nil
+}
+
+func (s *currencyService) SetRates(ctx context.Context, req *cmodel.SetExchangeRatesDTO) error {
+	rates, err := s.storage.FindAllRates(ctx)
+	if err != nil {
+		s.logger.Error(err)
+		return fmt.Errorf("failed to find exchange rates, error: %w", err)
+	}
+	var base string
+	for _, er := range rates {
+		if er.IsBase {
+			base = er.Currency
+		}
+	}
+
+	newRates := req.ToExchangeRates()
+	for _, er := range newRates {
+		if er.Currency == base && er.Rate != 1 {
+			return apperror.ErrBaseCurrencyRate
+		}
+	}
+
+	if err = s.storage.SetRates(ctx, newRates); err != nil {
+		s.logger.Error(err)
+		return fmt.Errorf("failed to set exchange rates, error: %w", err)
+	}
+	return nil
+}
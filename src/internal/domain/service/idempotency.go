@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type idempotencyService struct {
+	storage     storage.IdempotencyStorage
+	ttl         time.Duration
+	lockTimeout time.Duration
+	logger      *logging.Logger
+}
+
+// NewIdempotencyService creates the service keeping the responses of requests with idempotency keys for ttl.
+// A request holds its key for lockTimeout, a retry after it runs the request again if no response was stored.
+func NewIdempotencyService(s storage.IdempotencyStorage, ttl, lockTimeout time.Duration, l *logging.Logger) *idempotencyService {
+	return &idempotencyService{
+		storage:     s,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+		logger:      l,
+	}
+}
+
+// Begin takes the key for the request k and returns true if the request should run, then its result
+// must be passed to Complete or Release. Otherwise the stored request with the response to replay is returned.
+// Reusing the key for another request or while the first one is in progress is an error.
+func (s *idempotencyService) Begin(ctx context.Context, k *dmodel.IdempotencyKey) (r dmodel.IdempotencyKey, run bool, err error) {
+	r, run, err = s.storage.Lock(ctx, k, s.ttl, s.lockTimeout)
+	if err != nil {
+		s.logger.Error(err)
+		return r, false, fmt.Errorf("failed to lock idempotency key, error: %w", err)
+	}
+	if run {
+		return r, true, nil
+	}
+	if r.Fingerprint != k.Fingerprint {
+		return r, false, apperror.ErrIdempotencyMismatch
+	}
+	if !r.Completed() {
+		return r, false, apperror.ErrIdempotencyConflict
+	}
+	return r, false, nil
+}
+
+// Complete stores the response of the request, it is replayed to the retries of the request
+func (s *idempotencyService) Complete(ctx context.Context, k *dmodel.IdempotencyKey) error {
+	if err := s.storage.Complete(ctx, k); err != nil {
+		s.logger.Error(err)
+		return fmt.Errorf("failed to store response of idempotency key, error: %w", err)
+	}
+	return nil
+}
+
+// Release frees the key of the request which failed without a response worth replaying, so it can be retried
+func (s *idempotencyService) Release(ctx context.Context, k *dmodel.IdempotencyKey) error {
+	if err := s.storage.Release(ctx, k); err != nil {
+		s.logger.Error(err)
+		return fmt.Errorf("failed to release idempotency key, error: %w", err)
+	}
+	return nil
+}
+
+// Cleanup deletes the expired keys and returns the number of deleted keys
+func (s *idempotencyService) Cleanup(ctx context.Context) (int64, error) {
+	n, err := s.storage.DeleteExpired(ctx)
+	if err != nil {
+		s.logger.Error(err)
+		return 0, fmt.Errorf("failed to delete expired idempotency keys, error: %w", err)
+	}
+	return n, nil
+}
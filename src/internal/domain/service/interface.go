@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
 	"github.com/slava-911/test-task-0723/internal/domain/model"
@@ -11,21 +13,152 @@ type UserService interface {
 	Create(ctx context.Context, req *cmodel.CreateUserDTO) (dmodel.User, error)
 	GetOneByEmail(ctx context.Context, email, password string) (dmodel.User, error)
 	GetOneById(ctx context.Context, id string) (dmodel.User, error)
+	GetManyByIds(ctx context.Context, ids []string) (map[string]dmodel.User, error)
 	Update(ctx context.Context, id string, chFields map[string]string, oldPass string) error
 	Delete(ctx context.Context, id string) error
 }
 
 type OrderService interface {
 	Create(ctx context.Context, req *cmodel.CreateOrderDTO) (dmodel.Order, error)
-	GetAllByUserId(ctx context.Context, id string, limit, offset int) (cmodel.OrdersResponse, error)
+	CreateFromCart(ctx context.Context, req *cmodel.CreateOrderDTO, cartId string) (dmodel.Order, error)
+	GetAllByUserId(ctx context.Context, id string, limit, offset int, withItems bool) (cmodel.OrdersResponse, error)
 	GetOneById(ctx context.Context, id string) (dmodel.Order, error)
-	Complete(ctx context.Context, id string) error
-	AddProduct(ctx context.Context, productId, orderId string, quantity int) error
-	DeleteProduct(ctx context.Context, productId, orderId string) error
+	GetItemsByOrderIds(ctx context.Context, ids []string) (map[string][]dmodel.OrderItem, error)
+	GetHistory(ctx context.Context, userId, id, productId string, limit, offset int) (cmodel.OrderHistoryResponse, error)
+	ApplyCoupon(ctx context.Context, userId, id, code string) (dmodel.Order, error)
+	RemoveCoupon(ctx context.Context, userId, id string) error
+	Checkout(ctx context.Context, userId, id string, confirmPrices bool) (dmodel.Order, error)
+	SetShippingRegion(ctx context.Context, userId, id, region string) (dmodel.Order, error)
+	SetAddresses(ctx context.Context, userId, id string, req *cmodel.SetOrderAddressesDTO) (dmodel.Order, error)
+	SetShippingMethod(ctx context.Context, userId, id string, methodId *string) (dmodel.Order, error)
+	AddProduct(ctx context.Context, userId, productId, orderId string, quantity int) error
+	SetProductQuantity(ctx context.Context, userId, productId, orderId string, quantity int) error
+	DeleteProduct(ctx context.Context, userId, productId, orderId string) error
+	ReleaseExpiredReservations(ctx context.Context) (int, error)
 }
 
 type ProductService interface {
 	Create(ctx context.Context, req *cmodel.CreateProductDTO) (dmodel.Product, error)
-	GetOneById(ctx context.Context, id string) (dmodel.Product, error)
+	GetOneById(ctx context.Context, id, currency string) (dmodel.Product, error)
+	GetManyByIds(ctx context.Context, ids []string, currency string) (map[string]dmodel.Product, error)
 	Update(ctx context.Context, req *cmodel.UpdateProductDTO) error
+	AddPrice(ctx context.Context, req *cmodel.CreateProductPriceDTO) (dmodel.ProductPrice, error)
+	GetPrices(ctx context.Context, id, currency string, limit, offset int) (cmodel.ProductPricesResponse, error)
+	DeletePrice(ctx context.Context, productId string, id int64) error
+}
+
+type StockService interface {
+	AddMovement(ctx context.Context, req *cmodel.CreateStockMovementDTO) (dmodel.StockMovement, error)
+	Transfer(ctx context.Context, req *cmodel.CreateStockTransferDTO) error
+	GetMovementsByProductId(ctx context.Context, id string, limit, offset int) (cmodel.StockMovementsResponse, error)
+	GetReconciliation(ctx context.Context, all bool) (cmodel.StockReconciliationResponse, error)
+}
+
+type WarehouseService interface {
+	Create(ctx context.Context, req *cmodel.CreateWarehouseDTO) (dmodel.Warehouse, error)
+	GetAll(ctx context.Context) (cmodel.WarehousesResponse, error)
+	SetDefault(ctx context.Context, id string) error
+}
+
+type CurrencyService interface {
+	GetRates(ctx context.Context) (cmodel.ExchangeRatesResponse, error)
+	SetRates(ctx context.Context, req *cmodel.SetExchangeRatesDTO) error
+}
+
+type PromotionService interface {
+	Create(ctx context.Context, req *cmodel.CreatePromotionDTO) (dmodel.Promotion, error)
+	GetAll(ctx context.Context, limit, offset int) (cmodel.PromotionsResponse, error)
+}
+
+type TaxService interface {
+	Create(ctx context.Context, req *cmodel.CreateTaxRuleDTO) (dmodel.TaxRule, error)
+	GetAll(ctx context.Context) (cmodel.TaxRulesResponse, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type PaymentService interface {
+	Create(ctx context.Context, userId, orderId string) (dmodel.Payment, error)
+	GetAllByOrderId(ctx context.Context, userId, orderId string) (cmodel.PaymentsResponse, error)
+	Capture(ctx context.Context, id string) (dmodel.Payment, error)
+	HandleWebhook(ctx context.Context, provider string, header http.Header, body []byte) error
+	Refund(ctx context.Context, orderId string, req *cmodel.CreateRefundDTO) (cmodel.RefundResponse, error)
+	GetRefundsByOrderId(ctx context.Context, userId, orderId string) (cmodel.RefundsResponse, error)
+}
+
+type ReturnService interface {
+	Create(ctx context.Context, userId, orderId string, req *cmodel.CreateReturnDTO) (dmodel.Return, error)
+	GetAllByOrderId(ctx context.Context, userId, orderId string) (cmodel.ReturnsResponse, error)
+	GetAll(ctx context.Context, status string, limit, offset int) (cmodel.ReturnsResponse, error)
+	Approve(ctx context.Context, id, note string) (dmodel.Return, error)
+	Reject(ctx context.Context, id, note string) (dmodel.Return, error)
+	Receive(ctx context.Context, id string) (dmodel.Return, error)
+}
+
+type CartService interface {
+	Get(ctx context.Context, userId, token string) (dmodel.Cart, error)
+	AddItem(ctx context.Context, userId, token, currency string, req *cmodel.AddCartItemDTO) (dmodel.Cart, error)
+	SetItemQuantity(ctx context.Context, userId, token, productId string, quantity int) (dmodel.Cart, error)
+	DeleteItem(ctx context.Context, userId, token, productId string) (dmodel.Cart, error)
+	Merge(ctx context.Context, token, userId string) error
+	Checkout(ctx context.Context, userId string, req *cmodel.CheckoutCartDTO) (dmodel.Order, error)
+}
+
+type AddressService interface {
+	Create(ctx context.Context, userId string, req *cmodel.AddressDTO) (dmodel.Address, error)
+	GetAllByUserId(ctx context.Context, userId string) (cmodel.AddressesResponse, error)
+	GetOneById(ctx context.Context, userId, id string) (dmodel.Address, error)
+	Update(ctx context.Context, userId, id string, req *cmodel.AddressDTO) (dmodel.Address, error)
+	Delete(ctx context.Context, userId, id string) error
+}
+
+type ShippingService interface {
+	Create(ctx context.Context, req *cmodel.CreateShippingMethodDTO) (dmodel.ShippingMethod, error)
+	GetAll(ctx context.Context, currency string) (cmodel.ShippingMethodsResponse, error)
+	Delete(ctx context.Context, id string) error
+	GetQuotes(ctx context.Context, orderId, addressId string) (cmodel.ShippingQuotesResponse, error)
+}
+
+type ShipmentService interface {
+	Create(ctx context.Context, userId, orderId string, req *cmodel.CreateShipmentDTO) (dmodel.Shipment, error)
+	GetAllByOrderId(ctx context.Context, orderId string) (cmodel.ShipmentsResponse, error)
+	Deliver(ctx context.Context, id string, deliveredAt *time.Time) (dmodel.Shipment, error)
+	GetUnfulfilled(ctx context.Context, limit, offset int) (cmodel.FulfillmentOrdersResponse, error)
+}
+
+type InvoiceService interface {
+	GetPDF(ctx context.Context, userId, orderId string) ([]byte, dmodel.Invoice, error)
+}
+
+type OrderStreamService interface {
+	Watch(ctx context.Context, userId, orderId string, send func(cmodel.OrderStreamEvent) error) error
+}
+
+type NotificationService interface {
+	UserSignedUp(ctx context.Context, u dmodel.User)
+	PasswordChanged(ctx context.Context, userId string)
+	OrderPlaced(ctx context.Context, o dmodel.Order)
+	OrderShipped(ctx context.Context, o dmodel.Order, sh dmodel.Shipment)
+	DeliverDue(ctx context.Context) (int, error)
+}
+
+type OutboxService interface {
+	Relay(ctx context.Context) (int, error)
+	Cleanup(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type WebhookService interface {
+	Create(ctx context.Context, req *cmodel.CreateWebhookDTO) (dmodel.WebhookEndpoint, error)
+	GetAll(ctx context.Context) (cmodel.WebhooksResponse, error)
+	Delete(ctx context.Context, id string) error
+	GetDeliveries(ctx context.Context, endpointId, status string, limit, offset int) (cmodel.WebhookDeliveriesResponse, error)
+	GetDelivery(ctx context.Context, id int64) (dmodel.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int64) (dmodel.WebhookDelivery, error)
+	DeliverDue(ctx context.Context) (int, error)
+}
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, k *dmodel.IdempotencyKey) (dmodel.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, k *dmodel.IdempotencyKey) error
+	Release(ctx context.Context, k *dmodel.IdempotencyKey) error
+	Cleanup(ctx context.Context) (int64, error)
 }
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/invoice"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type invoiceService struct {
+	storage      storage.InvoiceStorage
+	orderService OrderService
+	logger       *logging.Logger
+}
+
+func NewInvoiceService(s storage.InvoiceStorage, os OrderService, l *logging.Logger) *invoiceService {
+	return &invoiceService{
+		storage:      s,
+		orderService: os,
+		logger:       l,
+	}
+}
+
+// GetPDF returns the invoice of the order of the user as PDF, the invoice is issued when the order is checked out.
+// ErrNotFound is returned for orders of other users.
+func (s *invoiceService) GetPDF(ctx context.Context, userId, orderId string) (r []byte, inv dmodel.Invoice, err error) {
+	o, err := s.orderService.GetOneById(ctx, orderId)
+	if err != nil {
+		return r, inv, err
+	}
+	if o.UserId != userId {
+		return r, inv, fmt.Errorf("order of the user is %w", apperror.ErrNotFound)
+	}
+	if !o.Completed {
+		return r, inv, apperror.ErrOrderNotCheckedOut
+	}
+
+	inv, err = s.storage.FindOneByOrderId(ctx, orderId)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, inv, err
+		}
+		return r, inv, fmt.Errorf("failed to get invoice of order (id %s), error: %w", orderId, err)
+	}
+
+	var buf bytes.Buffer
+	if err = invoice.WritePDF(&buf, &inv, &o); err != nil {
+		s.logger.Error(err)
+		return r, inv, fmt.Errorf("failed to render invoice %s, error: %w", inv.Number, err)
+	}
+	return buf.Bytes(), inv, nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/notification"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+const (
+	// notificationBatch is the number of notifications sent by one DeliverDue call
+	notificationBatch = 20
+	// notificationLease is the time a claimed notification is hidden from other replicas while it is sent
+	notificationLease = 2 * time.Minute
+	// maxRetryDelay caps the exponential backoff of retries
+	maxRetryDelay = 6 * time.Hour
+)
+
+type notificationService struct {
+	storage         storage.NotificationStorage
+	userStorage     storage.UserStorage
+	currencyStorage storage.CurrencyStorage
+	renderer        *notification.Renderer
+	sender          notification.Sender
+	maxAttempts     int
+	retryDelay      time.Duration
+	logger          *logging.Logger
+}
+
+func NewNotificationService(s storage.NotificationStorage, us storage.UserStorage, cs storage.CurrencyStorage,
+	r *notification.Renderer, sender notification.Sender, maxAttempts int, retryDelay time.Duration,
+	l *logging.Logger) *notificationService {
+	return &notificationService{
+		storage:         s,
+		userStorage:     us,
+		currencyStorage: cs,
+		renderer:        r,
+		sender:          sender,
+		maxAttempts:     maxAttempts,
+		retryDelay:      retryDelay,
+		logger:          l,
+	}
+}
+
+// The event methods queue the email of the event and only log errors,
+// a failed notification must not fail the operation it reports
+
+func (s *notificationService) UserSignedUp(ctx context.Context, u dmodel.User) {
+	s.enqueue(ctx, dmodel.EventUserSignedUp, u, notification.Data{})
+}
+
+func (s *notificationService) PasswordChanged(ctx context.Context, userId string) {
+	u, err := s.userStorage.FindOneById(ctx, userId)
+	if err != nil {
+		s.logger.Errorf("failed to notify of event %s, failed to find user (id %s), error: %v",
+			dmodel.EventPasswordChanged, userId, err)
+		return
+	}
+	s.enqueue(ctx, dmodel.EventPasswordChanged, u, notification.Data{})
+}
+
+func (s *notificationService) OrderPlaced(ctx context.Context, o dmodel.Order) {
+	u, err := s.userStorage.FindOneById(ctx, o.UserId)
+	if err != nil {
+		s.logger.Errorf("failed to notify of event %s, failed to find user (id %s), error: %v",
+			dmodel.EventOrderPlaced, o.UserId, err)
+		return
+	}
+	order, err := s.orderData(ctx, &o)
+	if err != nil {
+		s.logger.Errorf("failed to notify of event %s of order (id %s), error: %v", dmodel.EventOrderPlaced, o.Id, err)
+		return
+	}
+	s.enqueue(ctx, dmodel.EventOrderPlaced, u, notification.Data{Order: order})
+}
+
+func (s *notificationService) OrderShipped(ctx context.Context, o dmodel.Order, sh dmodel.Shipment) {
+	u, err := s.userStorage.FindOneById(ctx, o.UserId)
+	if err != nil {
+		s.logger.Errorf("failed to notify of event %s, failed to find user (id %s), error: %v",
+			dmodel.EventOrderShipped, o.UserId, err)
+		return
+	}
+	order, err := s.orderData(ctx, &o)
+	if err != nil {
+		s.logger.Errorf("failed to notify of event %s of order (id %s), error: %v", dmodel.EventOrderShipped, o.Id, err)
+		return
+	}
+
+	descriptions := make(map[string]string, len(o.Items))
+	for _, i := range o.Items {
+		descriptions[i.ProductId] = i.Description
+	}
+	shipment := &notification.ShipmentData{
+		Carrier:        sh.Carrier,
+		TrackingNumber: sh.TrackingNumber,
+		Lines:          make([]notification.LineData, 0, len(sh.Items)),
+	}
+	for _, i := range sh.Items {
+		shipment.Lines = append(shipment.Lines, notification.LineData{
+			Description: descriptions[i.ProductId],
+			Quantity:    i.Quantity,
+		})
+	}
+	s.enqueue(ctx, dmodel.EventOrderShipped, u, notification.Data{Order: order, Shipment: shipment})
+}
+
+// enqueue renders the email of the event in the locale of the user and queues it
+func (s *notificationService) enqueue(ctx context.Context, event dmodel.NotificationEvent, u dmodel.User,
+	data notification.Data) {
+	data.Name = u.FirstName
+	subject, body, err := s.renderer.Render(event, u.Locale, data)
+	if err != nil {
+		s.logger.Errorf("failed to render notification of event %s, error: %v", event, err)
+		return
+	}
+
+	_, err = s.storage.Create(ctx, &dmodel.Notification{
+		Id:        uuid.New().String(),
+		Event:     event,
+		UserId:    &u.Id,
+		Recipient: u.Email,
+		Subject:   subject,
+		Body:      body,
+		Status:    dmodel.NotificationPending,
+	})
+	if err != nil {
+		s.logger.Errorf("failed to queue notification of event %s to user (id %s), error: %v", event, u.Id, err)
+	}
+}
+
+// orderData returns the order as shown in emails, with the amounts formatted in its currency
+func (s *notificationService) orderData(ctx context.Context, o *dmodel.Order) (*notification.OrderData, error) {
+	rates, err := s.currencyStorage.FindAllRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find exchange rates, error: %w", err)
+	}
+	minorUnits := 2
+	for _, er := range rates {
+		if er.Currency == o.Currency {
+			minorUnits = er.MinorUnits
+			break
+		}
+	}
+
+	r := &notification.OrderData{
+		Number:   o.Number,
+		Total:    dmodel.FormatAmount(o.Total.Amount, minorUnits),
+		Currency: o.Currency,
+		Lines:    make([]notification.LineData, 0, len(o.Items)),
+	}
+	for _, i := range o.Items {
+		r.Lines = append(r.Lines, notification.LineData{
+			Description: i.Description,
+			Quantity:    i.Quantity,
+			Amount:      dmodel.FormatAmount(i.Total.Amount-i.Discount.Amount, minorUnits),
+		})
+	}
+	return r, nil
+}
+
+// DeliverDue sends the queued notifications due to be sent and returns the number of sent ones.
+// A failed notification is retried with exponential backoff until it runs out of attempts.
+func (s *notificationService) DeliverDue(ctx context.Context) (sent int, err error) {
+	due, err := s.storage.ClaimDue(ctx, notificationBatch, notificationLease)
+	if err != nil {
+		s.logger.Error(err)
+		return 0, fmt.Errorf("failed to claim due notifications, error: %w", err)
+	}
+
+	for _, n := range due {
+		sendErr := s.sender.Send(ctx, notification.Message{
+			Id:      n.Id,
+			To:      n.Recipient,
+			Subject: n.Subject,
+			Body:    n.Body,
+		})
+		if sendErr == nil {
+			if err = s.storage.MarkSent(ctx, n.Id); err != nil {
+				s.logger.Error(err)
+				return sent, fmt.Errorf("failed to mark notification (id %s) sent, error: %w", n.Id, err)
+			}
+			sent++
+			continue
+		}
+
+		var retryAfter *time.Duration
+		if n.Attempts < s.maxAttempts {
+			d := backoff(s.retryDelay, n.Attempts)
+			retryAfter = &d
+			s.logger.Warnf("failed to send notification (id %s), attempt %d, retry in %s, error: %v",
+				n.Id, n.Attempts, d, sendErr)
+		} else {
+			s.logger.Errorf("failed to send notification (id %s), giving up after %d attempts, error: %v",
+				n.Id, n.Attempts, sendErr)
+		}
+		if err = s.storage.MarkFailed(ctx, n.Id, sendErr.Error(), retryAfter); err != nil {
+			s.logger.Error(err)
+			return sent, fmt.Errorf("failed to mark notification (id %s) failed, error: %w", n.Id, err)
+		}
+	}
+	return sent, nil
+}
+
+// backoff returns the delay before the retry following the attempt, doubled with every attempt
+func backoff(base time.Duration, attempt int) time.Duration {
+	d := base
+	for n := 1; n < attempt && d < maxRetryDelay; n++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
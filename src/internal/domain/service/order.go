@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,15 +15,35 @@ import (
 	"github.com/slava-911/test-task-0723/pkg/logging"
 )
 
+// releaseBatchSize is the max number of orders released by one ReleaseExpiredReservations call
+const releaseBatchSize = 100
+
 type orderService struct {
-	storage storage.OrderStorage
-	logger  *logging.Logger
+	storage             storage.OrderStorage
+	taxStorage          storage.TaxStorage
+	shippingStorage     storage.ShippingStorage
+	notificationService NotificationService
+	reservationTTL      time.Duration
+	pricesIncludeTax    bool
+	// invoiceSeller and invoicePrefix are the seller and the number prefix of invoices issued at checkout
+	invoiceSeller dmodel.InvoiceParty
+	invoicePrefix string
+	logger        *logging.Logger
 }
 
-func NewOrderService(s storage.OrderStorage, l *logging.Logger) *orderService {
+func NewOrderService(s storage.OrderStorage, ts storage.TaxStorage, ss storage.ShippingStorage, ns NotificationService,
+	reservationTTL time.Duration, pricesIncludeTax bool, invoiceSeller dmodel.InvoiceParty, invoicePrefix string,
+	l *logging.Logger) *orderService {
 	return &orderService{
-		storage: s,
-		logger:  l,
+		storage:             s,
+		taxStorage:          ts,
+		shippingStorage:     ss,
+		notificationService: ns,
+		reservationTTL:      reservationTTL,
+		pricesIncludeTax:    pricesIncludeTax,
+		invoiceSeller:       invoiceSeller,
+		invoicePrefix:       invoicePrefix,
+		logger:              l,
 	}
 }
 
@@ -38,12 +59,45 @@ func (s *orderService) Create(ctx context.Context, req *cmodel.CreateOrderDTO) (
 	return r, nil
 }
 
-func (s *orderService) GetAllByUserId(ctx context.Context, id string, limit, offset int) (r cmodel.OrdersResponse, err error) {
+// CreateFromCart creates the order with the products of the cart reserving stock for them and empties the cart
+func (s *orderService) CreateFromCart(ctx context.Context, req *cmodel.CreateOrderDTO, cartId string) (r dmodel.Order, err error) {
+	newOrder := req.ToOrder()
+	newOrder.Id = uuid.New().String()
+	newOrder.CreatedAt = time.Now()
+	r, err = s.storage.CreateFromCart(ctx, newOrder, cartId, newOrder.CreatedAt.Add(s.reservationTTL))
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrInsufficientStock) ||
+			errors.Is(err, apperror.ErrUnsupportedCurrency) || errors.Is(err, apperror.ErrEmptyCart) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create order from cart (id %s), error: %w", cartId, err)
+	}
+	return s.GetOneById(ctx, r.Id)
+}
+
+func (s *orderService) GetAllByUserId(ctx context.Context, id string, limit, offset int, withItems bool) (r cmodel.OrdersResponse, err error) {
 	orders, err := s.storage.FindAllByUserId(ctx, id, limit, offset)
 	if err != nil {
 		s.logger.Error(err)
 		return r, err
 	}
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.Id)
+	}
+	items, err := s.GetItemsByOrderIds(ctx, ids)
+	if err != nil {
+		return r, err
+	}
+	if err = s.price(ctx, orders, items); err != nil {
+		return r, err
+	}
+	if withItems {
+		for i := range orders {
+			orders[i].Items = items[orders[i].Id]
+		}
+	}
 	r = cmodel.OrdersResponse{
 		Limit:  limit,
 		Offset: offset,
@@ -58,24 +112,297 @@ func (s *orderService) GetOneById(ctx context.Context, id string) (r dmodel.Orde
 		s.logger.Error(err)
 		return r, err
 	}
+	items, err := s.GetItemsByOrderIds(ctx, []string{id})
+	if err != nil {
+		return r, err
+	}
+	orders := []dmodel.Order{r}
+	if err = s.price(ctx, orders, items); err != nil {
+		return r, err
+	}
+	r = orders[0]
+	r.Items = items[id]
 	return r, nil
 }
 
-func (s *orderService) Complete(ctx context.Context, id string) error {
-	if err := s.storage.Complete(ctx, id); err != nil {
+// price calculates discounts and taxes of the orders and their items and sets the order totals,
+// completed orders keep the amounts frozen when they were completed
+func (s *orderService) price(ctx context.Context, orders []dmodel.Order, items map[string][]dmodel.OrderItem) error {
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.Id)
+	}
+	coupons, err := s.storage.FindCouponsByOrderIds(ctx, ids)
+	if err != nil {
 		s.logger.Error(err)
+		return fmt.Errorf("failed to find coupons by order ids, error: %w", err)
+	}
+	orderCoupons := make(map[string]dmodel.OrderCoupon, len(coupons))
+	for _, c := range coupons {
+		orderCoupons[c.OrderId] = c
+	}
+
+	var rules []dmodel.TaxRule
+	for _, o := range orders {
+		if !o.Completed {
+			if rules, err = s.taxStorage.FindAll(ctx); err != nil {
+				s.logger.Error(err)
+				return fmt.Errorf("failed to find tax rules, error: %w", err)
+			}
+			break
+		}
+	}
+
+	methods := make(map[string]*dmodel.ShippingMethod)
+	now := time.Now()
+	for n := range orders {
+		o := &orders[n]
+		var coupon *dmodel.OrderCoupon
+		if c, ok := orderCoupons[o.Id]; ok {
+			coupon = &c
+		}
+
+		if !o.Completed {
+			var method *dmodel.ShippingMethod
+			if o.ShippingMethodId != nil {
+				key := *o.ShippingMethodId + o.Currency
+				if _, ok := methods[key]; !ok {
+					if methods[key], err = s.shippingMethod(ctx, *o.ShippingMethodId, o.Currency); err != nil {
+						return err
+					}
+				}
+				method = methods[key]
+			}
+			// a draft order the chosen method no longer applies to is priced without shipping, its checkout fails
+			_ = priceOrder(o, items[o.Id], coupon, method, rules, s.pricesIncludeTax, now)
+			continue
+		}
+		o.Discounts = make([]dmodel.OrderDiscount, 0)
+		if coupon != nil {
+			o.Coupon = coupon.Promotion.Code
+			if coupon.Discount != nil && *coupon.Discount > 0 {
+				o.Discounts = append(o.Discounts, orderDiscount(coupon.Promotion, *coupon.Discount, o.Currency))
+			}
+		}
+	}
+	return nil
+}
+
+// orderShippingMethod returns the shipping method chosen for the order with the amounts in the order currency,
+// nil if no method is chosen
+func (s *orderService) orderShippingMethod(ctx context.Context, o *dmodel.Order) (*dmodel.ShippingMethod, error) {
+	if o.ShippingMethodId == nil {
+		return nil, nil
+	}
+	return s.shippingMethod(ctx, *o.ShippingMethodId, o.Currency)
+}
+
+// shippingMethod returns the shipping method with the amounts in the currency, nil if it was deleted
+func (s *orderService) shippingMethod(ctx context.Context, id, currency string) (*dmodel.ShippingMethod, error) {
+	m, err := s.shippingStorage.FindOneById(ctx, id, currency)
+	if err != nil {
 		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, nil
+		}
+		s.logger.Error(err)
+		return nil, fmt.Errorf("failed to find shipping method (id %s), error: %w", id, err)
+	}
+	return &m, nil
+}
+
+// priceOrder calculates the discounts and taxes of the draft order lines, the cost of shipping the order
+// with the method and sets the order totals. Shipping is not taxed. ErrShippingUnavailable is returned
+// if the order can't be shipped with the method, the order is priced without shipping then.
+func priceOrder(o *dmodel.Order, lines []dmodel.OrderItem, coupon *dmodel.OrderCoupon, method *dmodel.ShippingMethod,
+	rules []dmodel.TaxRule, pricesIncludeTax bool, at time.Time) error {
+	o.Discounts = make([]dmodel.OrderDiscount, 0)
+	var discount, tax int64
+	if coupon != nil {
+		o.Coupon = coupon.Promotion.Code
+		for k, d := range promotionDiscounts(coupon.Promotion, *o, lines, at) {
+			lines[k].Discount.Amount = d
+			discount += d
+		}
+		if discount > 0 {
+			o.Discounts = append(o.Discounts, orderDiscount(coupon.Promotion, discount, o.Currency))
+		}
+	}
+	for k := range lines {
+		i := &lines[k]
+		i.TaxRate = taxRate(rules, i.Tags, o.ShippingRegion)
+		i.Tax.Amount = lineTax(i.Total.Amount-i.Discount.Amount, i.TaxRate, pricesIncludeTax)
+		tax += i.Tax.Amount
+	}
+
+	var err error
+	o.Shipping.Amount = 0
+	if method != nil {
+		cost, ok := shippingCost(method, lines, o.Subtotal.Amount-discount, o.ShippingRegion)
+		if ok {
+			o.ShippingMethod = method.Name
+			o.Shipping.Amount = cost
+		} else {
+			err = fmt.Errorf("%w: method %s", apperror.ErrShippingUnavailable, method.Code)
+		}
+	}
+
+	o.PricesIncludeTax = pricesIncludeTax
+	o.Tax.Amount = tax
+	o.Total.Amount = o.Subtotal.Amount - discount + o.Shipping.Amount
+	if !pricesIncludeTax {
+		o.Total.Amount += tax
+	}
+	return err
+}
+
+func orderDiscount(p dmodel.Promotion, amount int64, currency string) dmodel.OrderDiscount {
+	return dmodel.OrderDiscount{
+		PromotionId: p.Id,
+		Code:        p.Code,
+		Name:        p.Name,
+		Amount:      dmodel.Money{Amount: amount, Currency: currency},
+	}
+}
+
+func (s *orderService) ApplyCoupon(ctx context.Context, userId, id, code string) (r dmodel.Order, err error) {
+	if _, err = s.storage.ApplyCoupon(ctx, userId, id, strings.ToUpper(code)); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrCouponNotApplicable) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to apply coupon to order (id %s), error: %w", id, err)
+	}
+	return s.GetOneById(ctx, id)
+}
+
+func (s *orderService) RemoveCoupon(ctx context.Context, userId, id string) error {
+	if err := s.storage.RemoveCoupon(ctx, userId, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderCompleted) {
 			return err
 		}
-		return fmt.Errorf("failed to complete order, error: %w", err)
+		return fmt.Errorf("failed to remove coupon from order (id %s), error: %w", id, err)
 	}
 	return nil
 }
 
-func (s *orderService) AddProduct(ctx context.Context, productId, orderId string, quantity int) error {
-	if err := s.storage.AddProduct(ctx, productId, orderId, quantity); err != nil {
+func (s *orderService) GetItemsByOrderIds(ctx context.Context, ids []string) (r map[string][]dmodel.OrderItem, err error) {
+	items, err := s.storage.FindItemsByOrderIds(ctx, ids)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find items by order ids, error: %w", err)
+	}
+	r = make(map[string][]dmodel.OrderItem, len(ids))
+	for _, id := range ids {
+		r[id] = make([]dmodel.OrderItem, 0)
+	}
+	for _, i := range items {
+		r[i.OrderId] = append(r[i.OrderId], i)
+	}
+	return r, nil
+}
+
+// GetHistory returns the content history of the order of the user, orders of other users are not found
+func (s *orderService) GetHistory(ctx context.Context, userId, id, productId string, limit, offset int) (r cmodel.OrderHistoryResponse, err error) {
+	events, err := s.storage.FindHistoryByOrderId(ctx, userId, id, productId, limit, offset)
+	if err != nil {
 		s.logger.Error(err)
 		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find history of order (id %s), error: %w", id, err)
+	}
+	r = cmodel.OrderHistoryResponse{
+		Limit:  limit,
+		Offset: offset,
+		Events: events,
+	}
+	return r, nil
+}
+
+// Checkout validates the order and completes it with the amounts it has at the moment issuing its invoice,
+// changed product prices fail the checkout unless confirmPrices is set. Orders of other users are not found.
+func (s *orderService) Checkout(ctx context.Context, userId, id string, confirmPrices bool) (r dmodel.Order, err error) {
+	rules, err := s.taxStorage.FindAll(ctx)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find tax rules, error: %w", err)
+	}
+	now := time.Now()
+	price := func(o *dmodel.Order, coupon *dmodel.OrderCoupon) error {
+		method, err := s.orderShippingMethod(ctx, o)
+		if err != nil {
+			return err
+		}
+		return priceOrder(o, o.Items, coupon, method, rules, s.pricesIncludeTax, now)
+	}
+
+	inv := &dmodel.Invoice{
+		Id: uuid.New().String(),
+		// the time is kept in UTC with seconds precision, so the invoice read back renders the same way
+		IssuedAt: now.UTC().Truncate(time.Second),
+		Seller:   s.invoiceSeller,
+	}
+
+	if err = s.storage.Checkout(ctx, userId, id, confirmPrices, price, inv, s.invoicePrefix); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderCompleted) ||
+			errors.Is(err, apperror.ErrEmptyOrder) || errors.Is(err, apperror.ErrPricesChanged) ||
+			errors.Is(err, apperror.ErrInsufficientStock) || errors.Is(err, apperror.ErrShippingUnavailable) ||
+			errors.Is(err, apperror.ErrCouponNotApplicable) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to check out order (id %s), error: %w", id, err)
+	}
+	if r, err = s.GetOneById(ctx, id); err != nil {
+		return r, err
+	}
+	s.notificationService.OrderPlaced(ctx, r)
+	return r, nil
+}
+
+func (s *orderService) SetShippingRegion(ctx context.Context, userId, id, region string) (r dmodel.Order, err error) {
+	if err = s.storage.SetShippingRegion(ctx, userId, id, region); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderCompleted) ||
+			errors.Is(err, apperror.ErrShippingAddressSet) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to set shipping region of order (id %s), error: %w", id, err)
+	}
+	return s.GetOneById(ctx, id)
+}
+
+// SetAddresses chooses the shipping and billing addresses of the order, the order is then taxed
+// for the region of the shipping address
+func (s *orderService) SetAddresses(ctx context.Context, userId, id string, req *cmodel.SetOrderAddressesDTO) (r dmodel.Order, err error) {
+	if err = s.storage.SetAddresses(ctx, userId, id, req.ShippingAddressId, req.BillingAddressId); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderCompleted) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to set addresses of order (id %s), error: %w", id, err)
+	}
+	return s.GetOneById(ctx, id)
+}
+
+// SetShippingMethod chooses the method the draft order is shipped with, nil removes the chosen method
+func (s *orderService) SetShippingMethod(ctx context.Context, userId, id string, methodId *string) (r dmodel.Order, err error) {
+	if err = s.storage.SetShippingMethod(ctx, userId, id, methodId); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderCompleted) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to set shipping method of order (id %s), error: %w", id, err)
+	}
+	return s.GetOneById(ctx, id)
+}
+
+func (s *orderService) AddProduct(ctx context.Context, userId, productId, orderId string, quantity int) error {
+	if err := s.storage.AddProduct(ctx, userId, productId, orderId, quantity, time.Now().Add(s.reservationTTL)); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrInsufficientStock) ||
+			errors.Is(err, apperror.ErrOrderCompleted) {
 			return err
 		}
 		return fmt.Errorf("failed to add product (id %s) to order (id %s), error: %w", productId, orderId, err)
@@ -83,13 +410,34 @@ func (s *orderService) AddProduct(ctx context.Context, productId, orderId string
 	return nil
 }
 
-func (s *orderService) DeleteProduct(ctx context.Context, productId, orderId string) error {
-	if err := s.storage.DeleteProduct(ctx, productId, orderId); err != nil {
+func (s *orderService) SetProductQuantity(ctx context.Context, userId, productId, orderId string, quantity int) error {
+	if err := s.storage.SetProductQuantity(ctx, userId, productId, orderId, quantity, time.Now().Add(s.reservationTTL)); err != nil {
 		s.logger.Error(err)
-		if errors.Is(err, apperror.ErrNotFound) {
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrInsufficientStock) ||
+			errors.Is(err, apperror.ErrOrderCompleted) {
+			return err
+		}
+		return fmt.Errorf("failed to set quantity of product (id %s) in order (id %s), error: %w", productId, orderId, err)
+	}
+	return nil
+}
+
+func (s *orderService) DeleteProduct(ctx context.Context, userId, productId, orderId string) error {
+	if err := s.storage.DeleteProduct(ctx, userId, productId, orderId); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderCompleted) {
 			return err
 		}
 		return fmt.Errorf("failed to delete product (id %s) from order (id %s), error: %w", productId, orderId, err)
 	}
 	return nil
 }
+
+func (s *orderService) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	released, err := s.storage.ReleaseExpiredReservations(ctx, releaseBatchSize)
+	if err != nil {
+		s.logger.Error(err)
+		return released, fmt.Errorf("failed to release expired reservations, error: %w", err)
+	}
+	return released, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	"github.com/slava-911/test-task-0723/internal/realtime"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type orderStreamService struct {
+	orderService OrderService
+	hub          *realtime.Hub
+	pingInterval time.Duration
+	logger       *logging.Logger
+}
+
+func NewOrderStreamService(os OrderService, h *realtime.Hub, pingInterval time.Duration, l *logging.Logger) *orderStreamService {
+	return &orderStreamService{
+		orderService: os,
+		hub:          h,
+		pingInterval: pingInterval,
+		logger:       l,
+	}
+}
+
+// Watch sends the order of the user and then the order again every time its status, lines or totals change,
+// until ctx is done, the order is deleted or the app shuts down. The error of finding the order
+// is returned before anything is sent, so the caller can still respond with it. Orders of other users
+// are not found. An error of send ends the watch and is returned.
+func (s *orderStreamService) Watch(ctx context.Context, userId, orderId string, send func(cmodel.OrderStreamEvent) error) error {
+	// subscribe before the first load so changes made in between are not missed
+	sub := s.hub.Subscribe(orderId)
+	defer s.hub.Unsubscribe(sub)
+
+	o, err := s.orderService.GetOneById(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if o.UserId != userId {
+		return fmt.Errorf("order of the user is %w", apperror.ErrNotFound)
+	}
+	last, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	if err = send(cmodel.OrderStreamEvent{Type: cmodel.OrderStreamOrder, Order: &o}); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err = send(cmodel.OrderStreamEvent{Type: cmodel.OrderStreamPing}); err != nil {
+				return err
+			}
+		case _, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			o, err = s.orderService.GetOneById(ctx, orderId)
+			if err != nil {
+				if errors.Is(err, apperror.ErrNotFound) {
+					return send(cmodel.OrderStreamEvent{Type: cmodel.OrderStreamDeleted})
+				}
+				if ctx.Err() != nil {
+					return nil
+				}
+				// the order is sent with the next change or the stream is reopened by the client
+				s.logger.WithError(err).Errorf("failed to reload order %s for its stream", orderId)
+				continue
+			}
+			b, err := json.Marshal(o)
+			if err != nil {
+				return err
+			}
+			// changes of columns which are not in the order, like the reservation expiry, are not sent
+			if bytes.Equal(b, last) {
+				continue
+			}
+			last = b
+			if err = send(cmodel.OrderStreamEvent{Type: cmodel.OrderStreamOrder, Order: &o}); err != nil {
+				return err
+			}
+		}
+	}
+}
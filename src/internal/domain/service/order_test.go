@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test scenario:
+// 1. Price an order with taxes added to prices and with taxes included in prices and a coupon
+// 2. Check that shipping is free over the threshold and not taxed
+// 3. Check that an unavailable shipping method returns ErrShippingUnavailable and the order is priced without it
+func TestPriceOrder(t *testing.T) {
+	rules := []dmodel.TaxRule{{Rate: 19, Region: "DE"}}
+	percent10 := &dmodel.OrderCoupon{Promotion: dmodel.Promotion{Code: "TEN", Kind: dmodel.PromotionPercentage, Value: 10}}
+	freeOver := dmodel.Money{Amount: 2000, Currency: "EUR"}
+
+	tests := []struct {
+		name             string
+		coupon           *dmodel.OrderCoupon
+		method           *dmodel.ShippingMethod
+		rules            []dmodel.TaxRule
+		pricesIncludeTax bool
+		wantDiscount     int64
+		wantShipping     int64
+		wantTax          int64
+		wantTotal        int64
+		wantErr          error
+	}{
+		{
+			name:      "tax added to prices",
+			rules:     rules,
+			wantTax:   380,
+			wantTotal: 2380,
+		},
+		{
+			name:             "tax included in prices with coupon",
+			coupon:           percent10,
+			rules:            rules,
+			pricesIncludeTax: true,
+			wantDiscount:     200,
+			wantTax:          288,
+			wantTotal:        1800,
+		},
+		{
+			name:         "untaxed shipping",
+			method:       &dmodel.ShippingMethod{Name: "Post", Kind: dmodel.ShippingFlat, Price: dmodel.Money{Amount: 500}},
+			rules:        rules,
+			wantShipping: 500,
+			wantTax:      380,
+			wantTotal:    2880,
+		},
+		{
+			name: "free shipping over threshold",
+			method: &dmodel.ShippingMethod{Name: "Post", Kind: dmodel.ShippingFlat, Price: dmodel.Money{Amount: 500},
+				FreeOver: &freeOver},
+			wantTotal: 2000,
+		},
+		{
+			name: "discount below free shipping threshold",
+			method: &dmodel.ShippingMethod{Name: "Post", Kind: dmodel.ShippingFlat, Price: dmodel.Money{Amount: 500},
+				FreeOver: &freeOver},
+			coupon:       percent10,
+			wantDiscount: 200,
+			wantShipping: 500,
+			wantTotal:    2300,
+		},
+		{
+			name: "unavailable shipping",
+			method: &dmodel.ShippingMethod{Code: "fr", Kind: dmodel.ShippingFlat, Price: dmodel.Money{Amount: 500},
+				Regions: []string{"FR"}},
+			rules:     rules,
+			wantTax:   380,
+			wantTotal: 2380,
+			wantErr:   apperror.ErrShippingUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []dmodel.OrderItem{testLine(1000, 1), testLine(500, 2)}
+			o := testOrder(lines)
+			err := priceOrder(&o, lines, tt.coupon, tt.method, tt.rules, tt.pricesIncludeTax, time.Now())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var discount int64
+			for _, d := range o.Discounts {
+				discount += d.Amount.Amount
+			}
+			assert.Equal(t, tt.wantDiscount, discount)
+			assert.Equal(t, tt.wantShipping, o.Shipping.Amount)
+			assert.Equal(t, tt.wantTax, o.Tax.Amount)
+			assert.Equal(t, tt.wantTotal, o.Total.Amount)
+			assert.Equal(t, tt.pricesIncludeTax, o.PricesIncludeTax)
+		})
+	}
+}
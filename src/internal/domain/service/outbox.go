@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/broker"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+// outboxBatch is the max number of events published by one Relay call
+const outboxBatch = 100
+
+type outboxService struct {
+	storage storage.OutboxStorage
+	broker  broker.Broker
+	logger  *logging.Logger
+}
+
+func NewOutboxService(s storage.OutboxStorage, b broker.Broker, l *logging.Logger) *outboxService {
+	return &outboxService{
+		storage: s,
+		broker:  b,
+		logger:  l,
+	}
+}
+
+// Relay publishes the next batch of outbox events to the broker and returns the number of published events.
+// Events of a failed batch stay in the outbox and are published again, so the broker may get them twice.
+func (s *outboxService) Relay(ctx context.Context) (int, error) {
+	published, err := s.storage.PublishPending(ctx, outboxBatch, func(events []dmodel.OutboxEvent) error {
+		msgs := make([]broker.Message, 0, len(events))
+		for _, e := range events {
+			msgs = append(msgs, broker.Message{
+				Id:         e.EventId,
+				Type:       e.Type,
+				Key:        e.AggregateId,
+				Payload:    e.Payload,
+				OccurredAt: e.OccurredAt,
+			})
+		}
+		return s.broker.Publish(ctx, msgs)
+	})
+	if err != nil {
+		s.logger.Error(err)
+		return 0, fmt.Errorf("failed to publish outbox events, error: %w", err)
+	}
+	return published, nil
+}
+
+// Cleanup deletes events published more than retention ago
+func (s *outboxService) Cleanup(ctx context.Context, retention time.Duration) (int64, error) {
+	deleted, err := s.storage.DeletePublished(ctx, retention)
+	if err != nil {
+		s.logger.Error(err)
+		return 0, fmt.Errorf("failed to delete published outbox events, error: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/payment"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+// eventStatuses are the payment statuses reported by the provider events, other events are ignored
+var eventStatuses = map[string]dmodel.PaymentStatus{
+	payment.EventAuthorized: dmodel.PaymentAuthorized,
+	payment.EventCaptured:   dmodel.PaymentCaptured,
+	payment.EventFailed:     dmodel.PaymentFailed,
+}
+
+type paymentService struct {
+	storage  storage.PaymentStorage
+	provider payment.PaymentProvider
+	logger   *logging.Logger
+}
+
+func NewPaymentService(s storage.PaymentStorage, p payment.PaymentProvider, l *logging.Logger) *paymentService {
+	return &paymentService{
+		storage:  s,
+		provider: p,
+		logger:   l,
+	}
+}
+
+// Create creates a payment of the checked out order total of the user at the provider,
+// the returned payment has the client secret to complete it
+func (s *paymentService) Create(ctx context.Context, userId, orderId string) (r dmodel.Payment, err error) {
+	newPayment := &dmodel.Payment{
+		Id:        uuid.New().String(),
+		OrderId:   orderId,
+		Provider:  s.provider.Name(),
+		Status:    dmodel.PaymentPending,
+		CreatedAt: time.Now(),
+	}
+	r, err = s.storage.Create(ctx, userId, newPayment)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderNotCheckedOut) ||
+			errors.Is(err, apperror.ErrOrderPaid) || errors.Is(err, apperror.ErrNothingToPay) ||
+			errors.Is(err, apperror.ErrAlreadyExists) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create payment for order (id %s), error: %w", orderId, err)
+	}
+
+	intent, err := s.provider.CreateIntent(ctx, payment.IntentRequest{
+		PaymentId: r.Id,
+		OrderId:   orderId,
+		Amount:    r.Amount.Amount,
+		Currency:  r.Amount.Currency,
+	})
+	if err != nil {
+		s.logger.Error(err)
+		if failErr := s.storage.Fail(ctx, r.Id); failErr != nil {
+			s.logger.Error(failErr)
+		}
+		return r, fmt.Errorf("failed to create payment (id %s) at provider %s, error: %w", r.Id, r.Provider, err)
+	}
+	if err = s.storage.SetIntent(ctx, r.Id, intent.Id); err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to set intent of payment (id %s), error: %w", r.Id, err)
+	}
+	r.IntentId = intent.Id
+	r.ClientSecret = intent.ClientSecret
+	return r, nil
+}
+
+func (s *paymentService) GetAllByOrderId(ctx context.Context, userId, orderId string) (r cmodel.PaymentsResponse, err error) {
+	payments, err := s.storage.FindAllByOrderId(ctx, userId, orderId)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find payments of order (id %s), error: %w", orderId, err)
+	}
+	r.Payments = payments
+	return r, nil
+}
+
+// Capture captures the rest of the authorized payment amount
+func (s *paymentService) Capture(ctx context.Context, id string) (r dmodel.Payment, err error) {
+	p, err := s.storage.FindOneById(ctx, id)
+	if err != nil {
+		s.logger.Error(err)
+		return r, err
+	}
+	if p.Status != dmodel.PaymentAuthorized || p.IntentId == "" {
+		return r, fmt.Errorf("%w: payment is %s", apperror.ErrPaymentState, p.Status)
+	}
+
+	amount := p.Amount.Amount - p.CapturedAmount.Amount
+	if err = s.provider.Capture(ctx, p.IntentId, amount); err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to capture payment (id %s) at provider %s, error: %w", id, p.Provider, err)
+	}
+
+	// the capture is applied as an event, so the webhook of the provider about it is ignored
+	r, err = s.storage.ApplyEvent(ctx, &dmodel.PaymentEvent{
+		Provider: p.Provider,
+		EventId:  "capture-" + p.Id,
+		Type:     payment.EventCaptured,
+		IntentId: p.IntentId,
+		Status:   dmodel.PaymentCaptured,
+		Amount:   p.Amount.Amount,
+	})
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to mark payment (id %s) captured, error: %w", id, err)
+	}
+	return r, nil
+}
+
+// HandleWebhook verifies the webhook request of the provider and applies the payment event it reports
+func (s *paymentService) HandleWebhook(ctx context.Context, provider string, header http.Header, body []byte) error {
+	if provider != s.provider.Name() {
+		return fmt.Errorf("%w: payment provider %s is not configured", apperror.ErrNotFound, provider)
+	}
+	e, err := s.provider.VerifyWebhook(header, body)
+	if err != nil {
+		s.logger.Error(err)
+		return err
+	}
+	status, ok := eventStatuses[e.Type]
+	if !ok {
+		s.logger.Debugf("payment event %s of type %s ignored", e.Id, e.Type)
+		return nil
+	}
+
+	_, err = s.storage.ApplyEvent(ctx, &dmodel.PaymentEvent{
+		Provider: provider,
+		EventId:  e.Id,
+		Type:     e.Type,
+		IntentId: e.IntentId,
+		Status:   status,
+		Amount:   e.Amount,
+	})
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrCaptureAmount) {
+			return err
+		}
+		return fmt.Errorf("failed to apply payment event (id %s), error: %w", e.Id, err)
+	}
+	return nil
+}
+
+// Refund refunds a part of the captured payment of the order at the provider,
+// the amount is reserved before the provider is called, so concurrent refunds can't exceed the captured amount
+func (s *paymentService) Refund(ctx context.Context, orderId string, req *cmodel.CreateRefundDTO) (r cmodel.RefundResponse, err error) {
+	newRefund := &dmodel.Refund{
+		Id:        uuid.New().String(),
+		OrderId:   orderId,
+		Amount:    dmodel.Money{Amount: req.Amount},
+		Status:    dmodel.RefundPending,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	}
+	if req.ReturnId != "" {
+		newRefund.ReturnId = &req.ReturnId
+	}
+	ref, p, err := s.storage.CreateRefund(ctx, newRefund)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrPaymentState) ||
+			errors.Is(err, apperror.ErrReturnState) || errors.Is(err, apperror.ErrRefundAmount) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create refund for order (id %s), error: %w", orderId, err)
+	}
+
+	providerRefundId, err := s.provider.Refund(ctx, p.IntentId, ref.Amount.Amount)
+	if err != nil {
+		s.logger.Error(err)
+		if failErr := s.storage.FailRefund(ctx, ref.Id); failErr != nil {
+			s.logger.Error(failErr)
+		}
+		return r, fmt.Errorf("failed to refund payment (id %s) at provider %s, error: %w", p.Id, p.Provider, err)
+	}
+	if err = s.storage.CompleteRefund(ctx, ref.Id, providerRefundId); err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to complete refund (id %s), error: %w", ref.Id, err)
+	}
+	ref.Status = dmodel.RefundSucceeded
+	ref.ProviderRefundId = providerRefundId
+	return cmodel.RefundResponse{Refund: ref, Payment: p}, nil
+}
+
+func (s *paymentService) GetRefundsByOrderId(ctx context.Context, userId, orderId string) (r cmodel.RefundsResponse, err error) {
+	refunds, err := s.storage.FindRefundsByOrderId(ctx, userId, orderId)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find refunds of order (id %s), error: %w", orderId, err)
+	}
+	r.Refunds = refunds
+	return r, nil
+}
@@ -36,8 +36,8 @@ func (s *productService) Create(ctx context.Context, req *cmodel.CreateProductDT
 	return r, nil
 }
 
-func (s *productService) GetOneById(ctx context.Context, id string) (r dmodel.Product, err error) {
-	r, err = s.storage.FindOneById(ctx, id)
+func (s *productService) GetOneById(ctx context.Context, id, currency string) (r dmodel.Product, err error) {
+	r, err = s.storage.FindOneById(ctx, id, currency)
 	if err != nil {
 		s.logger.Error(err)
 		return r, err
@@ -45,14 +45,71 @@ func (s *productService) GetOneById(ctx context.Context, id string) (r dmodel.Pr
 	return r, nil
 }
 
+func (s *productService) GetManyByIds(ctx context.Context, ids []string, currency string) (r map[string]dmodel.Product, err error) {
+	products, err := s.storage.FindManyByIds(ctx, ids, currency)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrUnsupportedCurrency) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find products by ids, error: %w", err)
+	}
+	r = make(map[string]dmodel.Product, len(products))
+	for _, p := range products {
+		r[p.Id] = p
+	}
+	return r, nil
+}
+
 func (s *productService) Update(ctx context.Context, req *cmodel.UpdateProductDTO) error {
 	product := req.ToProduct()
 	if err := s.storage.Update(ctx, product); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrInsufficientStock) {
+			return err
+		}
+		return fmt.Errorf("failed to update product, error: %w", err)
+	}
+	return nil
+}
+
+func (s *productService) AddPrice(ctx context.Context, req *cmodel.CreateProductPriceDTO) (r dmodel.ProductPrice, err error) {
+	r, err = s.storage.AddPrice(ctx, req.ToProductPrice())
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrUnsupportedCurrency) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to add price of product (id %s), error: %w", req.ProductId, err)
+	}
+	return r, nil
+}
+
+func (s *productService) GetPrices(ctx context.Context, id, currency string, limit, offset int) (r cmodel.ProductPricesResponse, err error) {
+	if _, err = s.storage.FindOneById(ctx, id, currency); err != nil {
+		s.logger.Error(err)
+		return r, err
+	}
+	prices, err := s.storage.FindPricesByProductId(ctx, id, currency, limit, offset)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find prices of product (id %s), error: %w", id, err)
+	}
+	r = cmodel.ProductPricesResponse{
+		Limit:  limit,
+		Offset: offset,
+		Prices: prices,
+	}
+	return r, nil
+}
+
+func (s *productService) DeletePrice(ctx context.Context, productId string, id int64) error {
+	if err := s.storage.DeletePrice(ctx, productId, id); err != nil {
 		s.logger.Error(err)
 		if errors.Is(err, apperror.ErrNotFound) {
 			return err
 		}
-		return fmt.Errorf("failed to update user, error: %w", err)
+		return fmt.Errorf("failed to delete price (id %d) of product (id %s), error: %w", id, productId, err)
 	}
 	return nil
 }
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type promotionService struct {
+	storage storage.PromotionStorage
+	logger  *logging.Logger
+}
+
+func NewPromotionService(s storage.PromotionStorage, l *logging.Logger) *promotionService {
+	return &promotionService{
+		storage: s,
+		logger:  l,
+	}
+}
+
+func (s *promotionService) Create(ctx context.Context, req *cmodel.CreatePromotionDTO) (r dmodel.Promotion, err error) {
+	newPromotion := req.ToPromotion()
+	newPromotion.Id = uuid.New().String()
+	newPromotion.CreatedAt = time.Now()
+	r, err = s.storage.Create(ctx, newPromotion)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrAlreadyExists) || errors.Is(err, apperror.ErrUnsupportedCurrency) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create promotion, error: %w", err)
+	}
+	return r, nil
+}
+
+func (s *promotionService) GetAll(ctx context.Context, limit, offset int) (r cmodel.PromotionsResponse, err error) {
+	promotions, err := s.storage.FindAll(ctx, limit, offset)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find promotions, error: %w", err)
+	}
+	r = cmodel.PromotionsResponse{
+		Limit:      limit,
+		Offset:     offset,
+		Promotions: promotions,
+	}
+	return r, nil
+}
+
+// promotionDiscounts returns the discounts the promotion gives to the items of the order at the moment,
+// all zero if the order is not eligible. The discount never exceeds the cost of the discounted lines,
+// percentage and fixed discounts are split between the lines in proportion to their totals.
+func promotionDiscounts(p dmodel.Promotion, o dmodel.Order, items []dmodel.OrderItem, at time.Time) []int64 {
+	discounts := make([]int64, len(items))
+	if !p.IsActive(at) || (p.Currency != "" && p.Currency != o.Currency) || o.Subtotal.Amount < p.MinOrderValue {
+		return discounts
+	}
+
+	var eligible, discount int64
+	lines := make([]int, 0, len(items))
+	for n, i := range items {
+		if p.Tag != "" && !hasTag(i.Tags, p.Tag) {
+			continue
+		}
+		eligible += i.Total.Amount
+		lines = append(lines, n)
+		if p.Kind == dmodel.PromotionBuyXGetY {
+			free := i.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+			discounts[n] = int64(free) * i.Price.Amount
+		}
+	}
+	if p.Kind == dmodel.PromotionBuyXGetY || eligible == 0 {
+		return discounts
+	}
+
+	switch p.Kind {
+	case dmodel.PromotionPercentage:
+		discount = eligible * p.Value / 100
+	case dmodel.PromotionFixed:
+		discount = p.Value
+	}
+	if discount > eligible {
+		discount = eligible
+	}
+
+	// the last line gets the remainder of the rounding
+	rest := discount
+	for k, n := range lines {
+		if k == len(lines)-1 {
+			discounts[n] = rest
+			break
+		}
+		discounts[n] = discount * items[n].Total.Amount / eligible
+		rest -= discounts[n]
+	}
+	return discounts
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
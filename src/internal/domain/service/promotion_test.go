@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLine(price int64, quantity int, tags ...string) dmodel.OrderItem {
+	return dmodel.OrderItem{
+		Price:    dmodel.Money{Amount: price, Currency: "EUR"},
+		Quantity: quantity,
+		Total:    dmodel.Money{Amount: price * int64(quantity), Currency: "EUR"},
+		Tags:     tags,
+	}
+}
+
+func testOrder(lines []dmodel.OrderItem) dmodel.Order {
+	o := dmodel.Order{Currency: "EUR", ShippingRegion: "DE"}
+	for _, i := range lines {
+		o.Subtotal.Amount += i.Total.Amount
+	}
+	o.Subtotal.Currency = o.Currency
+	return o
+}
+
+// Test scenario:
+// 1. Split percentage and fixed discounts between the eligible lines in proportion to their totals
+// 2. Check that the last line gets the rounding remainder and the discount never exceeds the lines cost
+// 3. Check buy X get Y promotions and that ineligible orders get no discount
+func TestPromotionDiscounts(t *testing.T) {
+	now := time.Now()
+	ended := now.Add(-time.Hour)
+	tests := []struct {
+		name  string
+		p     dmodel.Promotion
+		items []dmodel.OrderItem
+		want  []int64
+	}{
+		{
+			name:  "percentage split in proportion",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionPercentage, Value: 10},
+			items: []dmodel.OrderItem{testLine(1000, 1), testLine(1000, 2)},
+			want:  []int64{100, 200},
+		},
+		{
+			name:  "rounding remainder on the last line",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionPercentage, Value: 10},
+			items: []dmodel.OrderItem{testLine(333, 1), testLine(333, 1), testLine(334, 1)},
+			want:  []int64{33, 33, 34},
+		},
+		{
+			name:  "fixed limited to lines cost",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionFixed, Value: 5000, Currency: "EUR"},
+			items: []dmodel.OrderItem{testLine(400, 1), testLine(600, 1)},
+			want:  []int64{400, 600},
+		},
+		{
+			name:  "limited to tagged lines",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionPercentage, Value: 50, Tag: "sale"},
+			items: []dmodel.OrderItem{testLine(1000, 1, "sale"), testLine(1000, 1)},
+			want:  []int64{500, 0},
+		},
+		{
+			name:  "buy 2 get 1",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1},
+			items: []dmodel.OrderItem{testLine(100, 7), testLine(100, 2)},
+			want:  []int64{200, 0},
+		},
+		{
+			name:  "minimum order value not reached",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionPercentage, Value: 10, MinOrderValue: 5000},
+			items: []dmodel.OrderItem{testLine(1000, 1)},
+			want:  []int64{0},
+		},
+		{
+			name:  "other currency",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionFixed, Value: 100, Currency: "USD"},
+			items: []dmodel.OrderItem{testLine(1000, 1)},
+			want:  []int64{0},
+		},
+		{
+			name:  "ended",
+			p:     dmodel.Promotion{Kind: dmodel.PromotionPercentage, Value: 10, EndsAt: &ended},
+			items: []dmodel.OrderItem{testLine(1000, 1)},
+			want:  []int64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, promotionDiscounts(tt.p, testOrder(tt.items), tt.items, now))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type returnService struct {
+	storage storage.ReturnStorage
+	logger  *logging.Logger
+}
+
+func NewReturnService(s storage.ReturnStorage, l *logging.Logger) *returnService {
+	return &returnService{
+		storage: s,
+		logger:  l,
+	}
+}
+
+// Create requests a return of the lines of the checked out order of the user
+func (s *returnService) Create(ctx context.Context, userId, orderId string, req *cmodel.CreateReturnDTO) (r dmodel.Return, err error) {
+	newReturn := req.ToReturn()
+	newReturn.Id = uuid.New().String()
+	newReturn.OrderId = orderId
+	newReturn.UserId = userId
+	newReturn.Status = dmodel.ReturnRequested
+	newReturn.CreatedAt = time.Now()
+	r, err = s.storage.Create(ctx, newReturn)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderNotCheckedOut) ||
+			errors.Is(err, apperror.ErrReturnQuantity) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create return for order (id %s), error: %w", orderId, err)
+	}
+	return r, nil
+}
+
+func (s *returnService) GetAllByOrderId(ctx context.Context, userId, orderId string) (r cmodel.ReturnsResponse, err error) {
+	returns, err := s.storage.FindAllByOrderId(ctx, userId, orderId)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find returns of order (id %s), error: %w", orderId, err)
+	}
+	r.Returns = returns
+	return r, nil
+}
+
+func (s *returnService) GetAll(ctx context.Context, status string, limit, offset int) (r cmodel.ReturnsResponse, err error) {
+	returns, err := s.storage.FindAll(ctx, status, limit, offset)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find returns, error: %w", err)
+	}
+	r = cmodel.ReturnsResponse{
+		Limit:   limit,
+		Offset:  offset,
+		Returns: returns,
+	}
+	return r, nil
+}
+
+func (s *returnService) Approve(ctx context.Context, id, note string) (dmodel.Return, error) {
+	return s.review(ctx, id, dmodel.ReturnApproved, note)
+}
+
+func (s *returnService) Reject(ctx context.Context, id, note string) (dmodel.Return, error) {
+	return s.review(ctx, id, dmodel.ReturnRejected, note)
+}
+
+func (s *returnService) review(ctx context.Context, id string, status dmodel.ReturnStatus, note string) (r dmodel.Return, err error) {
+	if err = s.storage.Review(ctx, id, status, note); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrReturnState) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to review return (id %s), error: %w", id, err)
+	}
+	return s.storage.FindOneById(ctx, id)
+}
+
+// Receive restocks the products of the approved return
+func (s *returnService) Receive(ctx context.Context, id string) (r dmodel.Return, err error) {
+	if err = s.storage.Receive(ctx, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrReturnState) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to receive return (id %s), error: %w", id, err)
+	}
+	return s.storage.FindOneById(ctx, id)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type shipmentService struct {
+	storage             storage.ShipmentStorage
+	orderService        OrderService
+	notificationService NotificationService
+	logger              *logging.Logger
+}
+
+func NewShipmentService(s storage.ShipmentStorage, os OrderService, ns NotificationService, l *logging.Logger) *shipmentService {
+	return &shipmentService{
+		storage:             s,
+		orderService:        os,
+		notificationService: ns,
+		logger:              l,
+	}
+}
+
+// Create ships the lines of the paid order in a parcel handed over to the carrier by the staff member
+func (s *shipmentService) Create(ctx context.Context, userId, orderId string, req *cmodel.CreateShipmentDTO) (r dmodel.Shipment, err error) {
+	newShipment := req.ToShipment()
+	newShipment.Id = uuid.New().String()
+	newShipment.OrderId = orderId
+	newShipment.Status = dmodel.ShipmentShipped
+	newShipment.UserId = &userId
+	newShipment.ShippedAt = time.Now()
+	r, err = s.storage.Create(ctx, newShipment)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrOrderNotCheckedOut) ||
+			errors.Is(err, apperror.ErrOrderNotPaid) || errors.Is(err, apperror.ErrShipmentQuantity) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create shipment for order (id %s), error: %w", orderId, err)
+	}
+
+	if o, err := s.orderService.GetOneById(ctx, orderId); err != nil {
+		s.logger.Errorf("failed to notify of shipment (id %s), error: %v", r.Id, err)
+	} else {
+		s.notificationService.OrderShipped(ctx, o, r)
+	}
+	return r, nil
+}
+
+func (s *shipmentService) GetAllByOrderId(ctx context.Context, orderId string) (r cmodel.ShipmentsResponse, err error) {
+	shipments, err := s.storage.FindAllByOrderId(ctx, orderId)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find shipments of order (id %s), error: %w", orderId, err)
+	}
+	r.Shipments = shipments
+	return r, nil
+}
+
+// Deliver marks the shipment delivered at the moment, now if it is nil
+func (s *shipmentService) Deliver(ctx context.Context, id string, deliveredAt *time.Time) (r dmodel.Shipment, err error) {
+	at := time.Now()
+	if deliveredAt != nil {
+		at = *deliveredAt
+	}
+	if err = s.storage.Deliver(ctx, id, at); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrShipmentState) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to deliver shipment (id %s), error: %w", id, err)
+	}
+	return s.storage.FindOneById(ctx, id)
+}
+
+// GetUnfulfilled returns paid orders waiting to be shipped with their lines not shipped yet
+func (s *shipmentService) GetUnfulfilled(ctx context.Context, limit, offset int) (r cmodel.FulfillmentOrdersResponse, err error) {
+	orders, err := s.storage.FindUnfulfilled(ctx, limit, offset)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find unfulfilled orders, error: %w", err)
+	}
+	r = cmodel.FulfillmentOrdersResponse{
+		Limit:  limit,
+		Offset: offset,
+		Orders: orders,
+	}
+	return r, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type shippingService struct {
+	storage        storage.ShippingStorage
+	orderService   OrderService
+	addressService AddressService
+	logger         *logging.Logger
+}
+
+func NewShippingService(s storage.ShippingStorage, os OrderService, as AddressService, l *logging.Logger) *shippingService {
+	return &shippingService{
+		storage:        s,
+		orderService:   os,
+		addressService: as,
+		logger:         l,
+	}
+}
+
+func (s *shippingService) Create(ctx context.Context, req *cmodel.CreateShippingMethodDTO) (r dmodel.ShippingMethod, err error) {
+	newMethod := req.ToShippingMethod()
+	newMethod.Id = uuid.New().String()
+	newMethod.CreatedAt = time.Now()
+	r, err = s.storage.Create(ctx, newMethod)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrAlreadyExists) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create shipping method, error: %w", err)
+	}
+	return r, nil
+}
+
+func (s *shippingService) GetAll(ctx context.Context, currency string) (r cmodel.ShippingMethodsResponse, err error) {
+	methods, err := s.storage.FindAll(ctx, currency)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrUnsupportedCurrency) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find shipping methods, error: %w", err)
+	}
+	r.ShippingMethods = methods
+	return r, nil
+}
+
+func (s *shippingService) Delete(ctx context.Context, id string) error {
+	if err := s.storage.Delete(ctx, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete shipping method (id %s), error: %w", id, err)
+	}
+	return nil
+}
+
+// GetQuotes returns the costs of shipping the order with the methods available for it. The order is shipped
+// to the address of the order user if addressId is set, to the shipping region of the order otherwise.
+func (s *shippingService) GetQuotes(ctx context.Context, orderId, addressId string) (r cmodel.ShippingQuotesResponse, err error) {
+	o, err := s.orderService.GetOneById(ctx, orderId)
+	if err != nil {
+		return r, err
+	}
+	r.Region = o.ShippingRegion
+	if addressId != "" {
+		a, err := s.addressService.GetOneById(ctx, o.UserId, addressId)
+		if err != nil {
+			return r, err
+		}
+		r.Region = a.TaxRegion()
+	}
+
+	methods, err := s.storage.FindAll(ctx, o.Currency)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find shipping methods, error: %w", err)
+	}
+
+	net := o.Subtotal.Amount
+	for _, d := range o.Discounts {
+		net -= d.Amount.Amount
+	}
+	r.Quotes = make([]dmodel.ShippingQuote, 0, len(methods))
+	for n := range methods {
+		m := &methods[n]
+		cost, ok := shippingCost(m, o.Items, net, r.Region)
+		if !ok {
+			continue
+		}
+		r.Quotes = append(r.Quotes, dmodel.ShippingQuote{
+			MethodId: m.Id,
+			Code:     m.Code,
+			Name:     m.Name,
+			Price:    dmodel.Money{Amount: cost, Currency: o.Currency},
+		})
+	}
+	return r, nil
+}
+
+// shippingCost returns the cost of shipping the lines with the subtotal minus discounts net to the region
+// with the method, false if the method is not available in the region or the order exceeds its last tier
+func shippingCost(m *dmodel.ShippingMethod, lines []dmodel.OrderItem, net int64, region string) (int64, bool) {
+	if !m.AvailableIn(region) {
+		return 0, false
+	}
+
+	var metric int64
+	for _, i := range lines {
+		switch m.Kind {
+		case dmodel.ShippingWeight:
+			metric += int64(i.Weight) * int64(i.Quantity)
+		case dmodel.ShippingQuantity:
+			metric += int64(i.Quantity)
+		}
+	}
+	price := m.Price.Amount
+	if m.Kind != dmodel.ShippingFlat {
+		found := false
+		for _, t := range m.Tiers {
+			if metric <= t.UpTo {
+				price, found = t.Price.Amount, true
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+
+	if m.FreeOver != nil && net >= m.FreeOver.Amount {
+		return 0, true
+	}
+	return price, true
+}
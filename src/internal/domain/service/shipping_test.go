@@ -0,0 +1,118 @@
+package service
+
+import (
+	"testing"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test scenario:
+// 1. Calculate the cost of flat, weight and quantity methods for the order lines
+// 2. Check the free shipping threshold and that the method is unavailable outside its regions
+// or when the order exceeds its last tier
+func TestShippingCost(t *testing.T) {
+	eur := func(amount int64) dmodel.Money { return dmodel.Money{Amount: amount, Currency: "EUR"} }
+	freeOver := eur(5000)
+	tiers := []dmodel.ShippingTier{{UpTo: 1000, Price: eur(300)}, {UpTo: 5000, Price: eur(700)}}
+	withWeight := func(weight, quantity int) []dmodel.OrderItem {
+		i := testLine(1000, quantity)
+		i.Weight = weight
+		return []dmodel.OrderItem{i}
+	}
+
+	tests := []struct {
+		name   string
+		m      dmodel.ShippingMethod
+		lines  []dmodel.OrderItem
+		net    int64
+		region string
+		want   int64
+		wantOk bool
+	}{
+		{
+			name:   "flat",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingFlat, Price: eur(500)},
+			lines:  withWeight(0, 1),
+			net:    1000,
+			region: "DE",
+			want:   500,
+			wantOk: true,
+		},
+		{
+			name:   "weight first tier",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingWeight, Tiers: tiers},
+			lines:  withWeight(400, 2),
+			net:    2000,
+			region: "DE",
+			want:   300,
+			wantOk: true,
+		},
+		{
+			name:   "weight next tier",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingWeight, Tiers: tiers},
+			lines:  withWeight(1500, 2),
+			net:    2000,
+			region: "DE",
+			want:   700,
+			wantOk: true,
+		},
+		{
+			name:   "weight over the last tier",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingWeight, Tiers: tiers},
+			lines:  withWeight(3000, 2),
+			net:    2000,
+			region: "DE",
+		},
+		{
+			name:   "quantity",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingQuantity, Tiers: []dmodel.ShippingTier{{UpTo: 3, Price: eur(400)}}},
+			lines:  withWeight(0, 3),
+			net:    3000,
+			region: "DE",
+			want:   400,
+			wantOk: true,
+		},
+		{
+			name:   "free over threshold",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingFlat, Price: eur(500), FreeOver: &freeOver},
+			lines:  withWeight(0, 5),
+			net:    5000,
+			region: "DE",
+			want:   0,
+			wantOk: true,
+		},
+		{
+			name:   "below free threshold",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingFlat, Price: eur(500), FreeOver: &freeOver},
+			lines:  withWeight(0, 5),
+			net:    4999,
+			region: "DE",
+			want:   500,
+			wantOk: true,
+		},
+		{
+			name:   "subregion",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingFlat, Price: eur(500), Regions: []string{"US"}},
+			lines:  withWeight(0, 1),
+			net:    1000,
+			region: "US-CA",
+			want:   500,
+			wantOk: true,
+		},
+		{
+			name:   "unavailable region",
+			m:      dmodel.ShippingMethod{Kind: dmodel.ShippingFlat, Price: eur(500), Regions: []string{"FR"}},
+			lines:  withWeight(0, 1),
+			net:    1000,
+			region: "DE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, ok := shippingCost(&tt.m, tt.lines, tt.net, tt.region)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, cost)
+		})
+	}
+}
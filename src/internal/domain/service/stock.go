@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type stockService struct {
+	storage storage.StockStorage
+	logger  *logging.Logger
+}
+
+func NewStockService(s storage.StockStorage, l *logging.Logger) *stockService {
+	return &stockService{
+		storage: s,
+		logger:  l,
+	}
+}
+
+func (s *stockService) AddMovement(ctx context.Context, req *cmodel.CreateStockMovementDTO) (r dmodel.StockMovement, err error) {
+	r, err = s.storage.AddMovement(ctx, req.ToStockMovement())
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrInsufficientStock) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to add stock movement for product (id %s), error: %w", req.ProductId, err)
+	}
+	return r, nil
+}
+
+func (s *stockService) Transfer(ctx context.Context, req *cmodel.CreateStockTransferDTO) error {
+	if err := s.storage.Transfer(ctx, req.ToWarehouseTransfer()); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrInsufficientStock) {
+			return err
+		}
+		return fmt.Errorf("failed to transfer product (id %s) between warehouses, error: %w", req.ProductId, err)
+	}
+	return nil
+}
+
+func (s *stockService) GetMovementsByProductId(ctx context.Context, id string, limit, offset int) (r cmodel.StockMovementsResponse, err error) {
+	movements, err := s.storage.FindMovementsByProductId(ctx, id, limit, offset)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find stock movements of product (id %s), error: %w", id, err)
+	}
+	r = cmodel.StockMovementsResponse{
+		Limit:     limit,
+		Offset:    offset,
+		Movements: movements,
+	}
+	return r, nil
+}
+
+func (s *stockService) GetReconciliation(ctx context.Context, all bool) (r cmodel.StockReconciliationResponse, err error) {
+	drifts, err := s.storage.FindDrifts(ctx, all)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to reconcile stock, error: %w", err)
+	}
+	for _, d := range drifts {
+		if d.Drift != 0 {
+			r.DriftCount++
+			s.logger.Warnf("stock of product (id %s) drifted from ledger by %d", d.ProductId, d.Drift)
+		}
+	}
+	r.Products = drifts
+	return r, nil
+}
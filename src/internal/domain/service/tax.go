@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type taxService struct {
+	storage storage.TaxStorage
+	logger  *logging.Logger
+}
+
+func NewTaxService(s storage.TaxStorage, l *logging.Logger) *taxService {
+	return &taxService{
+		storage: s,
+		logger:  l,
+	}
+}
+
+func (s *taxService) Create(ctx context.Context, req *cmodel.CreateTaxRuleDTO) (r dmodel.TaxRule, err error) {
+	newRule := req.ToTaxRule()
+	newRule.Id = uuid.New().String()
+	newRule.CreatedAt = time.Now()
+	r, err = s.storage.Create(ctx, newRule)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrAlreadyExists) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create tax rule, error: %w", err)
+	}
+	return r, nil
+}
+
+func (s *taxService) GetAll(ctx context.Context) (r cmodel.TaxRulesResponse, err error) {
+	rules, err := s.storage.FindAll(ctx)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find tax rules, error: %w", err)
+	}
+	r.TaxRules = rules
+	return r, nil
+}
+
+func (s *taxService) Delete(ctx context.Context, id string) error {
+	if err := s.storage.Delete(ctx, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete tax rule (id %s), error: %w", id, err)
+	}
+	return nil
+}
+
+// taxRate returns the rate of the most specific rule matching the product tags and the region,
+// the higher rate wins between equally specific rules
+func taxRate(rules []dmodel.TaxRule, tags []string, region string) float64 {
+	var best *dmodel.TaxRule
+	for n := range rules {
+		r := &rules[n]
+		if !r.Matches(tags, region) {
+			continue
+		}
+		if best == nil || r.Specificity() > best.Specificity() ||
+			(r.Specificity() == best.Specificity() && r.Rate > best.Rate) {
+			best = r
+		}
+	}
+	if best == nil {
+		return 0
+	}
+	return best.Rate
+}
+
+// lineTax returns the tax of the taxable amount, the amount includes the tax if pricesIncludeTax is set
+func lineTax(taxable int64, rate float64, pricesIncludeTax bool) int64 {
+	if taxable <= 0 || rate == 0 {
+		return 0
+	}
+	if pricesIncludeTax {
+		return taxable - int64(math.Round(float64(taxable)*100/(100+rate)))
+	}
+	return int64(math.Round(float64(taxable) * rate / 100))
+}
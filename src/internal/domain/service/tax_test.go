@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test scenario:
+// 1. Pick the rate of the most specific rule for the product tags and the region
+// 2. Check that the higher rate wins between equally specific rules and that no matching rule means no tax
+func TestTaxRate(t *testing.T) {
+	rules := []dmodel.TaxRule{
+		{Rate: 19, Region: "DE"},
+		{Rate: 7, Region: "DE", Tag: "food"},
+		{Rate: 3, Tag: "book"},
+		{Rate: 5, Region: "US"},
+		{Rate: 10, Region: "US"},
+		{Rate: 8, Region: "US-CA"},
+	}
+	tests := []struct {
+		name   string
+		tags   []string
+		region string
+		want   float64
+	}{
+		{"region", nil, "DE", 19},
+		{"tag wins over region", []string{"food"}, "DE", 7},
+		{"tag without region wins over region", []string{"book"}, "DE", 3},
+		{"subregion wins over region", nil, "US-CA", 8},
+		{"higher of equally specific rules", nil, "US-NY", 10},
+		{"no matching rule", nil, "FR", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, taxRate(rules, tt.tags, tt.region))
+		})
+	}
+}
+
+// Test scenario:
+// 1. Calculate the tax added to prices without tax and the tax included in prices
+// 2. Check rounding to minor units and that nothing is taxed with a zero rate or a non-positive amount
+func TestLineTax(t *testing.T) {
+	tests := []struct {
+		name             string
+		taxable          int64
+		rate             float64
+		pricesIncludeTax bool
+		want             int64
+	}{
+		{"exclusive", 1000, 19, false, 190},
+		{"exclusive rounded", 999, 19, false, 190},
+		{"inclusive", 1190, 19, true, 190},
+		{"inclusive rounded", 999, 19, true, 160},
+		{"zero rate", 1000, 0, false, 0},
+		{"fully discounted line", 0, 19, true, 0},
+		{"negative amount", -100, 19, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lineTax(tt.taxable, tt.rate, tt.pricesIncludeTax))
+		})
+	}
+}
@@ -14,14 +14,16 @@ import (
 )
 
 type userService struct {
-	storage storage.UserStorage
-	logger  *logging.Logger
+	storage             storage.UserStorage
+	notificationService NotificationService
+	logger              *logging.Logger
 }
 
-func NewUserService(s storage.UserStorage, l *logging.Logger) *userService {
+func NewUserService(s storage.UserStorage, ns NotificationService, l *logging.Logger) *userService {
 	return &userService{
-		storage: s,
-		logger:  l,
+		storage:             s,
+		notificationService: ns,
+		logger:              l,
 	}
 }
 
@@ -37,8 +39,12 @@ func (s *userService) Create(ctx context.Context, req *cmodel.CreateUserDTO) (r
 	r, err = s.storage.Create(ctx, newUser)
 	if err != nil {
 		s.logger.Error(err)
-		return r, err
+		if errors.Is(err, apperror.ErrAlreadyExists) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to create user, error: %w", err)
 	}
+	s.notificationService.UserSignedUp(ctx, r)
 	return r, nil
 }
 
@@ -70,6 +76,19 @@ func (s *userService) GetOneById(ctx context.Context, id string) (r dmodel.User,
 	return r, nil
 }
 
+func (s *userService) GetManyByIds(ctx context.Context, ids []string) (r map[string]dmodel.User, err error) {
+	users, err := s.storage.FindManyByIds(ctx, ids)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find users by ids, error: %w", err)
+	}
+	r = make(map[string]dmodel.User, len(users))
+	for _, u := range users {
+		r[u.Id] = u
+	}
+	return r, nil
+}
+
 func (s *userService) Update(ctx context.Context, id string, chFields map[string]string, oldPass string) error {
 
 	if oldPass != "" {
@@ -96,12 +115,15 @@ func (s *userService) Update(ctx context.Context, id string, chFields map[string
 
 	if err := s.storage.Update(ctx, id, chFields); err != nil {
 		s.logger.Error(err)
-		if errors.Is(err, apperror.ErrNotFound) {
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, apperror.ErrAlreadyExists) {
 			return err
 		}
 		return fmt.Errorf("failed to update user, error: %w", err)
 	}
 
+	if oldPass != "" {
+		s.notificationService.PasswordChanged(ctx, id)
+	}
 	return nil
 }
 
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+type warehouseService struct {
+	storage storage.WarehouseStorage
+	logger  *logging.Logger
+}
+
+func NewWarehouseService(s storage.WarehouseStorage, l *logging.Logger) *warehouseService {
+	return &warehouseService{
+		storage: s,
+		logger:  l,
+	}
+}
+
+func (s *warehouseService) Create(ctx context.Context, req *cmodel.CreateWarehouseDTO) (r dmodel.Warehouse, err error) {
+	newWarehouse := req.ToWarehouse()
+	newWarehouse.Id = uuid.New().String()
+	newWarehouse.CreatedAt = time.Now()
+	r, err = s.storage.Create(ctx, newWarehouse)
+	if err != nil {
+		s.logger.Error(err)
+		return r, err
+	}
+	return r, nil
+}
+
+func (s *warehouseService) GetAll(ctx context.Context) (r cmodel.WarehousesResponse, err error) {
+	warehouses, err := s.storage.FindAll(ctx)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find warehouses, error: %w", err)
+	}
+	r.Warehouses = warehouses
+	return r, nil
+}
+
+func (s *warehouseService) SetDefault(ctx context.Context, id string) error {
+	if err := s.storage.SetDefault(ctx, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to set default warehouse (id %s), error: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	cmodel "github.com/slava-911/test-task-0723/internal/controller/http/model"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/storage"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+// Headers of webhook requests. The signature is the hex HMAC-SHA256 of "<timestamp>.<body>" with the secret
+// of the endpoint, receivers check it and reject old timestamps to prevent replays.
+const (
+	WebhookIdHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// webhookBatch is the number of deliveries sent by one DeliverDue call
+	webhookBatch = 20
+	// webhookLease is the time a claimed delivery is hidden from other replicas while it is sent
+	webhookLease = 5 * time.Minute
+	// webhookErrorBody is the max number of bytes of an error response kept in the delivery log
+	webhookErrorBody = 512
+)
+
+type webhookService struct {
+	storage     storage.WebhookStorage
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration
+	logger      *logging.Logger
+}
+
+func NewWebhookService(s storage.WebhookStorage, timeout time.Duration, maxAttempts int, retryDelay time.Duration,
+	l *logging.Logger) *webhookService {
+	return &webhookService{
+		storage:     s,
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		logger:      l,
+	}
+}
+
+// Create registers the endpoint and returns it with the secret, the only time the secret is shown
+func (s *webhookService) Create(ctx context.Context, req *cmodel.CreateWebhookDTO) (r dmodel.WebhookEndpoint, err error) {
+	newEndpoint := req.ToWebhookEndpoint()
+	newEndpoint.Id = uuid.New().String()
+	newEndpoint.CreatedAt = time.Now()
+	if newEndpoint.Secret == "" {
+		b := make([]byte, 32)
+		if _, err = rand.Read(b); err != nil {
+			return r, fmt.Errorf("failed to generate webhook secret, error: %w", err)
+		}
+		newEndpoint.Secret = "whsec_" + hex.EncodeToString(b)
+	}
+
+	r, err = s.storage.CreateEndpoint(ctx, newEndpoint)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to create webhook endpoint, error: %w", err)
+	}
+	return r, nil
+}
+
+func (s *webhookService) GetAll(ctx context.Context) (r cmodel.WebhooksResponse, err error) {
+	endpoints, err := s.storage.FindAllEndpoints(ctx)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find webhook endpoints, error: %w", err)
+	}
+	r.Webhooks = endpoints
+	return r, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, id string) error {
+	if err := s.storage.DeleteEndpoint(ctx, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete webhook endpoint (id %s), error: %w", id, err)
+	}
+	return nil
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, endpointId, status string, limit, offset int) (r cmodel.WebhookDeliveriesResponse, err error) {
+	deliveries, err := s.storage.FindDeliveries(ctx, endpointId, status, limit, offset)
+	if err != nil {
+		s.logger.Error(err)
+		return r, fmt.Errorf("failed to find deliveries of webhook endpoint (id %s), error: %w", endpointId, err)
+	}
+	r.Limit = limit
+	r.Offset = offset
+	r.Deliveries = deliveries
+	return r, nil
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, id int64) (r dmodel.WebhookDelivery, err error) {
+	r, err = s.storage.FindDeliveryById(ctx, id)
+	if err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to find webhook delivery (id %d), error: %w", id, err)
+	}
+	return r, nil
+}
+
+// Redeliver sends the delivery again with all attempts, e.g. a dead delivery after the endpoint is fixed
+func (s *webhookService) Redeliver(ctx context.Context, id int64) (r dmodel.WebhookDelivery, err error) {
+	if err = s.storage.Redeliver(ctx, id); err != nil {
+		s.logger.Error(err)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return r, err
+		}
+		return r, fmt.Errorf("failed to redeliver webhook delivery (id %d), error: %w", id, err)
+	}
+	return s.GetDelivery(ctx, id)
+}
+
+// DeliverDue sends the deliveries due to be sent and returns the number of sent ones. A failed delivery
+// is retried with exponential backoff, it is dead when it runs out of attempts.
+func (s *webhookService) DeliverDue(ctx context.Context) (sent int, err error) {
+	due, err := s.storage.ClaimDue(ctx, webhookBatch, webhookLease)
+	if err != nil {
+		s.logger.Error(err)
+		return 0, fmt.Errorf("failed to claim due webhook deliveries, error: %w", err)
+	}
+
+	for n := range due {
+		d := &due[n]
+		a := s.send(ctx, d)
+
+		status := dmodel.WebhookDelivered
+		var retryAfter time.Duration
+		switch {
+		case a.Error == "":
+			sent++
+		case d.Attempts < s.maxAttempts:
+			status = dmodel.WebhookPending
+			retryAfter = backoff(s.retryDelay, d.Attempts)
+			s.logger.Warnf("failed to deliver webhook (id %d), attempt %d, retry in %s, error: %s",
+				d.Id, d.Attempts, retryAfter, a.Error)
+		default:
+			status = dmodel.WebhookDead
+			s.logger.Errorf("failed to deliver webhook (id %d), dead after %d attempts, error: %s",
+				d.Id, d.Attempts, a.Error)
+		}
+
+		if err = s.storage.RecordAttempt(ctx, a, status, retryAfter); err != nil {
+			s.logger.Error(err)
+			// the endpoint was deleted while the delivery was sent
+			if errors.Is(err, apperror.ErrNotFound) {
+				continue
+			}
+			return sent, fmt.Errorf("failed to record attempt of webhook delivery (id %d), error: %w", d.Id, err)
+		}
+	}
+	return sent, nil
+}
+
+// send posts the event of the delivery to the endpoint and returns the attempt, a response other than 2xx
+// is an error
+func (s *webhookService) send(ctx context.Context, d *dmodel.WebhookDelivery) *dmodel.WebhookAttempt {
+	a := &dmodel.WebhookAttempt{DeliveryId: d.Id, AttemptedAt: time.Now()}
+
+	body, err := json.Marshal(struct {
+		Id         string          `json:"id"`
+		Type       string          `json:"type"`
+		OccurredAt time.Time       `json:"occurred_at"`
+		Data       json.RawMessage `json:"data"`
+	}{d.EventId, d.EventType, d.OccurredAt.UTC(), d.Payload})
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	timestamp := strconv.FormatInt(a.AttemptedAt.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "test-task-webhooks/1.0")
+	req.Header.Set(WebhookIdHeader, d.EventId)
+	req.Header.Set(WebhookEventHeader, d.EventType)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(d.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	a.DurationMs = int(time.Since(a.AttemptedAt).Milliseconds())
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	defer resp.Body.Close()
+
+	a.StatusCode = &resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorBody))
+		a.Error = fmt.Sprintf("endpoint responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(b))
+		return a
+	}
+	// the body is drained so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookErrorBody))
+	return a
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of the webhook body sent at the timestamp with the secret
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
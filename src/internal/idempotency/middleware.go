@@ -0,0 +1,173 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	"github.com/slava-911/test-task-0723/internal/controller/http/handler"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/internal/domain/service"
+	"github.com/slava-911/test-task-0723/internal/jwt"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+)
+
+const (
+	// HeaderKey is the request header with the key chosen by the client, retries of a request send the same key
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses replayed from a previous request with the key
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with the response body and replayed with it
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, echo.HeaderContentDisposition,
+	echo.HeaderSetCookie}
+
+// Middleware makes POST, PUT, PATCH and DELETE requests with the Idempotency-Key header run once per key
+// of the user or the anonymous cart. Retries of the request get the stored response of the first one, the key
+// can't be reused with another method, path or body. Requests failing with a server error are not stored and
+// can be retried. Anonymous requests without a cart token ignore the key, as their responses can't be told
+// apart from responses to other clients.
+func Middleware(s service.IdempotencyService, logger *logging.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderKey)
+			if key == "" || !isMutating(req.Method) {
+				return next(c)
+			}
+			scope := keyScope(c, logger)
+			if scope == "" {
+				return next(c)
+			}
+			if len(key) > maxKeyLength {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Sprintf("%s must be at most %d characters", HeaderKey, maxKeyLength))
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err).Error())
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			k := &dmodel.IdempotencyKey{
+				Scope:       scope,
+				Key:         key,
+				Method:      req.Method,
+				Path:        req.URL.RequestURI(),
+				Fingerprint: fingerprint(req.Method, req.URL.RequestURI(), body),
+			}
+			stored, run, err := s.Begin(req.Context(), k)
+			if err != nil {
+				wrappedErr := fmt.Errorf("failed to use %s %q: %w", HeaderKey, key, err)
+				switch {
+				case errors.Is(err, apperror.ErrIdempotencyMismatch):
+					return echo.NewHTTPError(http.StatusUnprocessableEntity, wrappedErr.Error())
+				case errors.Is(err, apperror.ErrIdempotencyConflict):
+					return echo.NewHTTPError(http.StatusConflict, wrappedErr.Error())
+				default:
+					return echo.NewHTTPError(http.StatusInternalServerError, wrappedErr.Error())
+				}
+			}
+			if !run {
+				return replay(c, &stored)
+			}
+
+			res := c.Response()
+			rec := &bodyRecorder{ResponseWriter: res.Writer}
+			res.Writer = rec
+			// the error is written here so its response is stored like any other
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+			res.Writer = rec.ResponseWriter
+
+			// the response is stored after the request ended, with the client gone its context may be done
+			ctx := context.Background()
+			if res.Status >= http.StatusInternalServerError {
+				if err = s.Release(ctx, k); err != nil {
+					logger.WithError(err).Errorf("failed to release %s %q", HeaderKey, key)
+				}
+				return nil
+			}
+			k.StatusCode = res.Status
+			k.ResponseHeaders = make(http.Header)
+			for _, h := range replayedHeaders {
+				if v := res.Header().Values(h); len(v) > 0 {
+					k.ResponseHeaders[h] = v
+				}
+			}
+			k.ResponseBody = rec.body.Bytes()
+			if err = s.Complete(ctx, k); err != nil {
+				logger.WithError(err).Errorf("failed to store response of %s %q", HeaderKey, key)
+			}
+			return nil
+		}
+	}
+}
+
+// keyScope returns the scope keys of the request are unique in: the id of the authorized user
+// or the token of the anonymous cart, empty for other anonymous requests
+func keyScope(c echo.Context, logger *logging.Logger) string {
+	if userId := jwt.UserIdFromRequest(c.Request(), logger); userId != "" {
+		return userId
+	}
+	if token := handler.CartToken(c); token != "" {
+		return "cart:" + token
+	}
+	return ""
+}
+
+// replay writes the stored response of the request with the key
+func replay(c echo.Context, k *dmodel.IdempotencyKey) error {
+	res := c.Response()
+	for h, v := range k.ResponseHeaders {
+		res.Header()[h] = v
+	}
+	res.Header().Set(HeaderReplayed, "true")
+	res.WriteHeader(k.StatusCode)
+	_, err := res.Write(k.ResponseBody)
+	return err
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// fingerprint identifies the request by its method, path with the query and body
+func fingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + uri + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder copies the response body written through it
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *bodyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,157 @@
+package invoice
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	lineHeight = 5.0
+)
+
+// column is a column of the table of invoice lines
+type column struct {
+	title string
+	width float64
+	align string
+}
+
+var columns = []column{
+	{title: "Description", width: 62, align: "L"},
+	{title: "Qty", width: 14, align: "R"},
+	{title: "Unit price", width: 26, align: "R"},
+	{title: "Discount", width: 22, align: "R"},
+	{title: "Tax rate", width: 18, align: "R"},
+	{title: "Tax", width: 20, align: "R"},
+	{title: "Amount", width: 28, align: "R"},
+}
+
+// WritePDF renders the invoice of the completed order as PDF. The document depends only on the invoice
+// and the frozen order amounts, so rendering the same invoice again gives the same bytes.
+func WritePDF(w io.Writer, inv *dmodel.Invoice, o *dmodel.Order) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(inv.IssuedAt)
+	pdf.SetModificationDate(inv.IssuedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle("Invoice "+inv.Number, true)
+	pdf.SetAuthor(inv.Seller.Name, true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, lineHeight, tr(fmt.Sprintf("Invoice %s, page %d of {nb}", inv.Number, pdf.PageNo())),
+			"", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "INVOICE", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	details := [][2]string{
+		{"Invoice number", inv.Number},
+		{"Issue date", inv.IssuedAt.Format(dateLayout)},
+		{"Order number", o.Number},
+		{"Currency", o.Currency},
+	}
+	if o.CheckedOutAt != nil {
+		details = append(details, [2]string{"Order date", o.CheckedOutAt.Format(dateLayout)})
+	}
+	for _, d := range details {
+		pdf.CellFormat(35, lineHeight, tr(d[0]+":"), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, lineHeight, tr(d[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	top := pdf.GetY()
+	writeParty(pdf, tr, "Seller", inv.Seller, 15)
+	sellerBottom := pdf.GetY()
+	pdf.SetY(top)
+	writeParty(pdf, tr, "Bill to", inv.Buyer, 110)
+	if pdf.GetY() < sellerBottom {
+		pdf.SetY(sellerBottom)
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for _, c := range columns {
+		pdf.CellFormat(c.width, 7, c.title, "1", 0, c.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	m := func(amount int64) string {
+		return dmodel.FormatAmount(amount, inv.MinorUnits)
+	}
+	for _, i := range o.Items {
+		values := []string{
+			tr(i.Description),
+			strconv.Itoa(i.Quantity),
+			m(i.Price.Amount),
+			m(i.Discount.Amount),
+			strconv.FormatFloat(i.TaxRate, 'f', -1, 64) + "%",
+			m(i.Tax.Amount),
+			m(i.Total.Amount - i.Discount.Amount),
+		}
+		// long descriptions are cut to one line, the table keeps a fixed row height
+		lines := pdf.SplitLines([]byte(values[0]), columns[0].width-2)
+		if len(lines) > 1 {
+			values[0] = strings.TrimSpace(string(lines[0])) + "..."
+		}
+		for n, c := range columns {
+			pdf.CellFormat(c.width, 6, values[n], "1", 0, c.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	totals := [][2]string{{"Subtotal", m(o.Subtotal.Amount)}}
+	for _, d := range o.Discounts {
+		totals = append(totals, [2]string{fmt.Sprintf("Discount %s", d.Code), "-" + m(d.Amount.Amount)})
+	}
+	if o.ShippingMethod != "" || o.Shipping.Amount != 0 {
+		totals = append(totals, [2]string{strings.TrimSpace("Shipping " + o.ShippingMethod), m(o.Shipping.Amount)})
+	}
+	if o.PricesIncludeTax {
+		totals = append(totals, [2]string{"Included tax", m(o.Tax.Amount)})
+	} else {
+		totals = append(totals, [2]string{"Tax", m(o.Tax.Amount)})
+	}
+	for _, t := range totals {
+		pdf.CellFormat(140, lineHeight+1, tr(t[0]), "", 0, "R", false, 0, "")
+		pdf.CellFormat(50, lineHeight+1, t[1], "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(140, 8, "Total", "T", 0, "R", false, 0, "")
+	pdf.CellFormat(50, 8, m(o.Total.Amount), "T", 1, "R", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+// writeParty writes the title and the details of the party in a column starting at x
+func writeParty(pdf *gofpdf.Fpdf, tr func(string) string, title string, p dmodel.InvoiceParty, x float64) {
+	pdf.SetX(x)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(85, lineHeight, title, "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	lines := append([]string{p.Name}, p.Address...)
+	if p.TaxId != "" {
+		lines = append(lines, "Tax ID: "+p.TaxId)
+	}
+	if p.Email != "" {
+		lines = append(lines, p.Email)
+	}
+	for _, l := range lines {
+		pdf.SetX(x)
+		pdf.CellFormat(85, lineHeight, tr(l), "", 1, "L", false, 0, "")
+	}
+}
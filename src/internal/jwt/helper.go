@@ -18,6 +18,8 @@ var _ Helper = &helper{}
 type UserClaims struct {
 	jwt.RegisteredClaims
 	Email string `json:"email"`
+	// Admin is set in tokens of the admin user, it is taken from the user and not from the email
+	Admin bool `json:"admin,omitempty"`
 }
 
 type RT struct {
@@ -68,6 +70,7 @@ func (h *helper) GenerateAccessToken(u dmodel.User) ([]byte, error) {
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 60)),
 		},
 		Email: u.Email,
+		Admin: u.IsAdmin,
 	}
 	token, err := builder.Build(claims)
 	if err != nil {
@@ -2,57 +2,129 @@ package jwt
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/cristalhq/jwt/v4"
 	"github.com/labstack/echo/v4"
+	"github.com/slava-911/test-task-0723/internal/actor"
 	"github.com/slava-911/test-task-0723/internal/config"
 	"github.com/slava-911/test-task-0723/pkg/logging"
 )
 
-type key string
+// AccessTokenParam is the query parameter with the token of stream requests
+const AccessTokenParam = "access_token"
 
-const ctxKey key = "user_id"
+var errMalformedToken = errors.New("malformed token")
 
 func Middleware(h echo.HandlerFunc, logger *logging.Logger) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		authHeader := strings.Split(c.Request().Header.Get("Authorization"), "Bearer ")
-		if len(authHeader) != 2 {
-			logger.Error("Malformed token")
-			return echo.NewHTTPError(http.StatusUnauthorized, "the correct token is required for authorization")
-		}
-
-		logger.Debug("create jwt verifier")
-		jwtToken := authHeader[1]
-		verifier, err := jwt.NewVerifierHS(jwt.HS256, []byte(config.GetConfig().JWT.Secret))
+		uc, err := claimsFromRequest(c.Request(), logger)
 		if err != nil {
-			logger.Error(err)
+			if errors.Is(err, errMalformedToken) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "the correct token is required for authorization")
+			}
 			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
 		}
 
-		logger.Debug("parse and verify token")
-		newToken, err := jwt.Parse([]byte(jwtToken), verifier)
-		if err != nil {
-			logger.Error(err)
-			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		c.SetRequest(c.Request().WithContext(actor.ContextWithUserId(c.Request().Context(), uc.ID)))
+		c.Set("user_id", uc.ID)
+		return h(c)
+	}
+}
+
+// OptionalMiddleware authorizes the request like Middleware if it has the Authorization header,
+// requests without it are passed anonymously without user_id
+func OptionalMiddleware(h echo.HandlerFunc, logger *logging.Logger) echo.HandlerFunc {
+	authorized := Middleware(h, logger)
+	return func(c echo.Context) error {
+		if c.Request().Header.Get("Authorization") == "" {
+			return h(c)
+		}
+		return authorized(c)
+	}
+}
+
+// StreamMiddleware authorizes the request like Middleware, the token can also be passed in the access_token
+// query parameter as browsers can't set the Authorization header of EventSource and WebSocket requests
+func StreamMiddleware(h echo.HandlerFunc, logger *logging.Logger) echo.HandlerFunc {
+	authorized := Middleware(h, logger)
+	return func(c echo.Context) error {
+		r := c.Request()
+		if token := c.QueryParam(AccessTokenParam); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
 		}
+		return authorized(c)
+	}
+}
 
-		logger.Debug("parse user claims")
-		var uc UserClaims
-		err = json.Unmarshal(newToken.Claims(), &uc)
+// AdminMiddleware allows the request only for the admin user from the app config
+func AdminMiddleware(h echo.HandlerFunc, logger *logging.Logger) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		uc, err := claimsFromRequest(c.Request(), logger)
 		if err != nil {
-			logger.Error(err)
+			if errors.Is(err, errMalformedToken) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "the correct token is required for authorization")
+			}
 			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
 		}
-		if valid := uc.IsValidAt(time.Now()); !valid {
-			logger.Error("token has been expired")
-			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		if !uc.Admin {
+			logger.Errorf("user %s is not an admin", uc.ID)
+			return echo.NewHTTPError(http.StatusForbidden, "admin rights are required")
 		}
 
-		//c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), ctxKey, uc.ID)))
+		c.SetRequest(c.Request().WithContext(actor.ContextWithUserId(c.Request().Context(), uc.ID)))
 		c.Set("user_id", uc.ID)
 		return h(c)
 	}
 }
+
+// UserIdFromRequest returns the id of the user authorized by the Authorization header of the request,
+// an empty string if the request has no valid token
+func UserIdFromRequest(r *http.Request, logger *logging.Logger) string {
+	if r.Header.Get("Authorization") == "" {
+		return ""
+	}
+	uc, err := claimsFromRequest(r, logger)
+	if err != nil {
+		return ""
+	}
+	return uc.ID
+}
+
+func claimsFromRequest(r *http.Request, logger *logging.Logger) (uc UserClaims, err error) {
+	authHeader := strings.Split(r.Header.Get("Authorization"), "Bearer ")
+	if len(authHeader) != 2 {
+		logger.Error("Malformed token")
+		return uc, errMalformedToken
+	}
+
+	logger.Debug("create jwt verifier")
+	jwtToken := authHeader[1]
+	verifier, err := jwt.NewVerifierHS(jwt.HS256, []byte(config.GetConfig().JWT.Secret))
+	if err != nil {
+		logger.Error(err)
+		return uc, err
+	}
+
+	logger.Debug("parse and verify token")
+	newToken, err := jwt.Parse([]byte(jwtToken), verifier)
+	if err != nil {
+		logger.Error(err)
+		return uc, err
+	}
+
+	logger.Debug("parse user claims")
+	err = json.Unmarshal(newToken.Claims(), &uc)
+	if err != nil {
+		logger.Error(err)
+		return uc, err
+	}
+	if valid := uc.IsValidAt(time.Now()); !valid {
+		logger.Error("token has been expired")
+		return uc, errors.New("token has been expired")
+	}
+	return uc, nil
+}
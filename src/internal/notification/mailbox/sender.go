@@ -0,0 +1,39 @@
+package mailbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/notification"
+)
+
+// Sender is a stand-in for a mail server for development, it writes every email to a .eml file
+// in the mailbox directory
+type Sender struct {
+	dir  string
+	from string
+}
+
+func NewSender(dir, from string) (*Sender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mailbox directory %s: %w", dir, err)
+	}
+	return &Sender{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (s *Sender) Send(_ context.Context, m notification.Message) error {
+	now := time.Now()
+	b, err := m.Format(s.from, now)
+	if err != nil {
+		return err
+	}
+	// a retried email overwrites the file written by the previous attempt
+	name := filepath.Join(s.dir, m.Id+".eml")
+	return os.WriteFile(name, b, 0o644)
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"time"
+)
+
+// Sender delivers emails, an error means the email may be sent again later
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// Message is an email to a single recipient. Id is the id of the notification, it is used as Message-ID
+// so the recipient server can drop a duplicate of a retried email.
+type Message struct {
+	Id      string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Format returns the message from the sender as a plain text email in RFC 5322 format
+func (m *Message) Format(from string, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", m.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@notifications>", m.Id)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h[0], h[1])
+	}
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(m.Body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
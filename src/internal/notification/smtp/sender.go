@@ -0,0 +1,98 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	netsmtp "net/smtp"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/notification"
+)
+
+// Sender sends emails through an SMTP server, the connection is upgraded with STARTTLS
+// if the server supports it
+type Sender struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSender(host, port, username, password, from string) *Sender {
+	return &Sender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (s *Sender) Send(ctx context.Context, m notification.Message) error {
+	b, err := m.Format(s.from, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.host, s.port))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	c, err := netsmtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err = c.Auth(netsmtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	from, err := envelopeAddress(s.from)
+	if err != nil {
+		return err
+	}
+	if err = c.Mail(from); err != nil {
+		return err
+	}
+	if err = c.Rcpt(m.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(b); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// envelopeAddress returns the bare address of the From header, e.g. shop@example.com of "Shop <shop@example.com>"
+func envelopeAddress(from string) (string, error) {
+	a, err := mail.ParseAddress(from)
+	if err != nil {
+		return "", err
+	}
+	return a.Address, nil
+}
@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// Data is what the templates of the events are rendered with, the amounts are already formatted
+type Data struct {
+	Name  string
+	Order *OrderData
+	// Shipment is set for shipped orders
+	Shipment *ShipmentData
+}
+
+type OrderData struct {
+	Number   string
+	Total    string
+	Currency string
+	Lines    []LineData
+}
+
+type LineData struct {
+	Description string
+	Quantity    int
+	Amount      string
+}
+
+type ShipmentData struct {
+	Carrier        string
+	TrackingNumber string
+	Lines          []LineData
+}
+
+// Renderer renders the subject and the body of events from the templates/<locale>/<event>.tmpl files,
+// a template defines the "subject" and "body" templates
+type Renderer struct {
+	// templates are by locale and event
+	templates map[string]map[dmodel.NotificationEvent]*template.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{templates: make(map[string]map[dmodel.NotificationEvent]*template.Template)}
+	err := fs.WalkDir(templatesFS, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		locale := path.Base(path.Dir(p))
+		event := dmodel.NotificationEvent(strings.TrimSuffix(path.Base(p), ".tmpl"))
+		t, err := template.ParseFS(templatesFS, p)
+		if err != nil {
+			return fmt.Errorf("failed to parse template %s: %w", p, err)
+		}
+		for _, name := range []string{"subject", "body"} {
+			if t.Lookup(name) == nil {
+				return fmt.Errorf("template %s does not define %q", p, name)
+			}
+		}
+		if r.templates[locale] == nil {
+			r.templates[locale] = make(map[dmodel.NotificationEvent]*template.Template)
+		}
+		r.templates[locale][event] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Render returns the subject and the body of the event in the locale, in the default locale
+// if there is no translation
+func (r *Renderer) Render(event dmodel.NotificationEvent, locale string, data Data) (subject, body string, err error) {
+	t, ok := r.templates[locale][event]
+	if !ok {
+		if t, ok = r.templates[dmodel.DefaultLocale][event]; !ok {
+			return "", "", fmt.Errorf("there is no template of event %s", event)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = t.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+	buf.Reset()
+	if err = t.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", err
+	}
+	return subject, strings.TrimSpace(buf.String()) + "\n", nil
+}
@@ -0,0 +1,125 @@
+package fake
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/slava-911/test-task-0723/internal/payment"
+)
+
+// SignatureHeader is the header with the hex HMAC-SHA256 of the webhook body
+const SignatureHeader = "X-Fake-Signature"
+
+type intent struct {
+	amount   int64
+	captured int64
+	refunded int64
+}
+
+// provider is an in-memory payment provider for local runs and tests. Payments are completed
+// by posting webhook events to the app signed with the secret.
+type provider struct {
+	sync.Mutex
+	secret  string
+	intents map[string]*intent
+	// byPayment makes creating an intent for the same payment idempotent
+	byPayment map[string]string
+}
+
+func NewProvider(secret string) payment.PaymentProvider {
+	return &provider{
+		secret:    secret,
+		intents:   make(map[string]*intent),
+		byPayment: make(map[string]string),
+	}
+}
+
+func (p *provider) Name() string {
+	return "fake"
+}
+
+func (p *provider) CreateIntent(_ context.Context, req payment.IntentRequest) (payment.Intent, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	id, ok := p.byPayment[req.PaymentId]
+	if !ok {
+		id = "fake_pi_" + uuid.New().String()
+		p.intents[id] = &intent{amount: req.Amount}
+		p.byPayment[req.PaymentId] = id
+	}
+	return payment.Intent{Id: id, ClientSecret: id + "_secret"}, nil
+}
+
+func (p *provider) Capture(_ context.Context, intentId string, amount int64) error {
+	p.Lock()
+	defer p.Unlock()
+
+	i, ok := p.intents[intentId]
+	if !ok {
+		return payment.ErrUnknownIntent
+	}
+	if i.captured+amount > i.amount {
+		return fmt.Errorf("capture of %d exceeds the uncaptured amount %d", amount, i.amount-i.captured)
+	}
+	i.captured += amount
+	return nil
+}
+
+func (p *provider) Refund(_ context.Context, intentId string, amount int64) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	i, ok := p.intents[intentId]
+	if !ok {
+		return "", payment.ErrUnknownIntent
+	}
+	if i.refunded+amount > i.captured {
+		return "", fmt.Errorf("refund of %d exceeds the refundable amount %d", amount, i.captured-i.refunded)
+	}
+	i.refunded += amount
+	return "fake_re_" + uuid.New().String(), nil
+}
+
+// webhookEvent is the body of the fake provider webhooks
+type webhookEvent struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	IntentId string `json:"intent_id"`
+	Amount   int64  `json:"amount"`
+}
+
+func (p *provider) VerifyWebhook(header http.Header, body []byte) (e payment.Event, err error) {
+	signature, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil || len(signature) == 0 || !hmac.Equal(signature, Sign(p.secret, body)) {
+		return e, payment.ErrInvalidSignature
+	}
+
+	var we webhookEvent
+	if err = json.Unmarshal(body, &we); err != nil {
+		return e, fmt.Errorf("%w: %v", payment.ErrInvalidEvent, err)
+	}
+	if we.Id == "" || we.Type == "" || we.IntentId == "" {
+		return e, fmt.Errorf("%w: id, type and intent_id are required", payment.ErrInvalidEvent)
+	}
+	return payment.Event{
+		Id:       we.Id,
+		Type:     we.Type,
+		IntentId: we.IntentId,
+		Amount:   we.Amount,
+	}, nil
+}
+
+// Sign returns the HMAC-SHA256 of the webhook body
+func Sign(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
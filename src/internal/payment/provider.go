@@ -0,0 +1,56 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidEvent     = errors.New("invalid webhook event")
+	ErrUnknownIntent    = errors.New("unknown payment intent")
+)
+
+// Event types reported by providers in webhooks
+const (
+	EventAuthorized = "payment.authorized"
+	EventCaptured   = "payment.captured"
+	EventFailed     = "payment.failed"
+	EventRefunded   = "payment.refunded"
+)
+
+// PaymentProvider is a payment service the orders are paid through
+type PaymentProvider interface {
+	// Name is the name the provider is configured and its payments are stored with
+	Name() string
+	// CreateIntent creates a payment of the amount at the provider, the client completes it with the client secret
+	CreateIntent(ctx context.Context, req IntentRequest) (Intent, error)
+	// Capture captures the amount of the authorized payment
+	Capture(ctx context.Context, intentId string, amount int64) error
+	// Refund returns the amount of the captured payment to the customer and returns the id of the refund
+	Refund(ctx context.Context, intentId string, amount int64) (string, error)
+	// VerifyWebhook checks the signature of the webhook request and returns the event it reports
+	VerifyWebhook(header http.Header, body []byte) (Event, error)
+}
+
+type IntentRequest struct {
+	// PaymentId is our id of the payment, providers use it as the idempotency key
+	PaymentId string
+	OrderId   string
+	// Amount is in minor units of the currency
+	Amount   int64
+	Currency string
+}
+
+type Intent struct {
+	Id           string
+	ClientSecret string
+}
+
+type Event struct {
+	Id       string
+	Type     string
+	IntentId string
+	Amount   int64
+}
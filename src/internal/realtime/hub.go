@@ -0,0 +1,108 @@
+package realtime
+
+import (
+	"sync"
+)
+
+// Subscription receives a signal on C when the order it is subscribed to changes. Signals are not queued,
+// a subscriber that is busy gets one signal for all changes made meanwhile and reloads the order once.
+// C is closed when the hub is closed.
+type Subscription struct {
+	C       <-chan struct{}
+	c       chan struct{}
+	orderId string
+}
+
+// Hub fans out the order changes of the app replica to the subscribers of the changed orders
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe subscribes to the changes of the order, the subscription must be cancelled with Unsubscribe
+func (h *Hub) Subscribe(orderId string) *Subscription {
+	c := make(chan struct{}, 1)
+	sub := &Subscription{C: c, c: c, orderId: orderId}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(c)
+		return sub
+	}
+	if h.subs[orderId] == nil {
+		h.subs[orderId] = make(map[*Subscription]struct{})
+	}
+	h.subs[orderId][sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.subs[sub.orderId]
+	if !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.orderId)
+	}
+}
+
+// Publish signals the subscribers of the order that it has changed
+func (h *Hub) Publish(orderId string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[orderId] {
+		signal(sub)
+	}
+}
+
+// PublishAll signals all subscribers, it is used when changes may have been missed
+func (h *Hub) PublishAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subs := range h.subs {
+		for sub := range subs {
+			signal(sub)
+		}
+	}
+}
+
+// Close closes the channels of all subscriptions so the streams of the subscribers end,
+// it is called when the app shuts down
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			close(sub.c)
+		}
+	}
+	h.subs = make(map[string]map[*Subscription]struct{})
+}
+
+// signal sends a signal to the subscription unless it already has a pending one
+func signal(sub *Subscription) {
+	select {
+	case sub.c <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// Channel is the PostgreSQL notification channel the order triggers notify with the id of the changed order
+const Channel = "order_changes"
+
+// Listener listens to the order changes made by all app replicas and publishes them to the hub of this replica
+type Listener struct {
+	db             postgresql.Client
+	hub            *Hub
+	reconnectDelay time.Duration
+	logger         *logging.Logger
+}
+
+func NewListener(c postgresql.Client, h *Hub, reconnectDelay time.Duration, l *logging.Logger) *Listener {
+	return &Listener{
+		db:             c,
+		hub:            h,
+		reconnectDelay: reconnectDelay,
+		logger:         l,
+	}
+}
+
+// Run listens until ctx is done. The connection is taken out of the pool for the whole time,
+// when it breaks a new one is opened and all subscribers are signalled as changes may have been missed.
+func (l *Listener) Run(ctx context.Context) {
+	reconnected := false
+	for {
+		err := l.listen(ctx, reconnected)
+		if ctx.Err() != nil {
+			return
+		}
+		l.logger.WithError(err).Errorf("listening to %s failed, reconnecting in %s", Channel, l.reconnectDelay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.reconnectDelay):
+		}
+		reconnected = true
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, reconnected bool) error {
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection stays subscribed to the channel, so it is not returned to the pool
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+
+	l.logger.Tracef("executing SQL query to listen to %s", Channel)
+	if _, err = pgConn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if reconnected {
+		l.hub.PublishAll()
+	}
+
+	for {
+		n, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		if n.Channel == Channel {
+			l.hub.Publish(n.Payload)
+		}
+	}
+}
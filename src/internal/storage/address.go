@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// addressFields are the columns of user_addresses table a read by scanAddress
+const addressFields = `
+	a.id, a.user_id, a.name, a.line1, a.line2, a.city, a.region, a.postal_code, a.country, a.phone,
+	a.is_default_billing, a.is_default_shipping, a.created_at, a.updated_at`
+
+// orderAddressJSON builds the copy of the address a kept in the order, its keys are the ones of dmodel.OrderAddress
+const orderAddressJSON = `jsonb_build_object(
+	'name', a.name, 'line1', a.line1, 'line2', a.line2, 'city', a.city, 'region', a.region,
+	'postal_code', a.postal_code, 'country', a.country, 'phone', a.phone)`
+
+type addressStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewAddressStorage(c postgresql.Client, l *logging.Logger) *addressStorage {
+	return &addressStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// Create creates the address of the user, a new default address replaces the previous default one
+func (s *addressStorage) Create(ctx context.Context, a *dmodel.Address) (r dmodel.Address, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to create address")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := resetDefaultAddresses(ctx, tx, a); err != nil {
+			return err
+		}
+
+		q := `
+			INSERT INTO user_addresses
+				(id, user_id, name, line1, line2, city, region, postal_code, country, phone,
+				 is_default_billing, is_default_shipping, created_at, updated_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)`
+
+		_, err := tx.Exec(ctx, q, a.Id, a.UserId, a.Name, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country,
+			a.Phone, a.DefaultBilling, a.DefaultShipping, a.CreatedAt)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	a.UpdatedAt = a.CreatedAt
+	return *a, nil
+}
+
+func (s *addressStorage) FindAllByUserId(ctx context.Context, userId string) (r []dmodel.Address, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + addressFields + `
+		FROM
+		    user_addresses a
+		WHERE
+		    a.user_id = $1
+		ORDER BY
+		    a.created_at, a.id`
+
+	s.logger.Trace("executing SQL query to find addresses by user id")
+
+	rows, err := s.db.Query(ctx, q, userId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.Address, 0)
+	for rows.Next() {
+		var a dmodel.Address
+		if err = scanAddress(rows, &a); err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, a)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func (s *addressStorage) FindOneById(ctx context.Context, userId, id string) (r dmodel.Address, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + addressFields + `
+		FROM
+		    user_addresses a
+		WHERE
+		    a.id = $1 AND a.user_id = $2`
+
+	s.logger.Trace("executing SQL query to find address by id")
+
+	if err = scanAddress(s.db.QueryRow(ctx, q, id, userId), &r); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r, apperror.ErrNotFound
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// Update replaces the address of the user, copies of the address in checked out orders don't change
+func (s *addressStorage) Update(ctx context.Context, a *dmodel.Address) (r dmodel.Address, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to update address")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := resetDefaultAddresses(ctx, tx, a); err != nil {
+			return err
+		}
+
+		q := `
+			UPDATE
+			    user_addresses a
+			SET
+			    name = $3, line1 = $4, line2 = $5, city = $6, region = $7, postal_code = $8, country = $9, phone = $10,
+			    is_default_billing = $11, is_default_shipping = $12, updated_at = now()
+			WHERE
+			    a.id = $1 AND a.user_id = $2
+			RETURNING` + addressFields
+
+		row := tx.QueryRow(ctx, q, a.Id, a.UserId, a.Name, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country,
+			a.Phone, a.DefaultBilling, a.DefaultShipping)
+		if err := scanAddress(row, &r); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+func (s *addressStorage) Delete(ctx context.Context, userId, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    user_addresses
+		WHERE
+		    id = $1 AND user_id = $2`
+
+	s.logger.Trace("executing SQL query to delete address")
+
+	tag, err := s.db.Exec(ctx, q, id, userId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// resetDefaultAddresses clears the default flags of other addresses of the user the address becomes default for,
+// the addresses of the user are locked, so concurrent changes of the defaults are serialized
+func resetDefaultAddresses(ctx context.Context, tx pgx.Tx, a *dmodel.Address) error {
+	q := `
+		SELECT
+		    a.id
+		FROM
+		    user_addresses a
+		WHERE
+		    a.user_id = $1
+		FOR UPDATE`
+
+	rows, err := tx.Query(ctx, q, a.UserId)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	q = `
+		UPDATE
+		    user_addresses
+		SET
+		    is_default_billing = is_default_billing AND NOT $3,
+		    is_default_shipping = is_default_shipping AND NOT $4,
+		    updated_at = now()
+		WHERE
+		    user_id = $1 AND id <> $2 AND ((is_default_billing AND $3) OR (is_default_shipping AND $4))`
+
+	_, err = tx.Exec(ctx, q, a.UserId, a.Id, a.DefaultBilling, a.DefaultShipping)
+	return err
+}
+
+// scanAddress scans addressFields into a
+func scanAddress(row pgx.Row, a *dmodel.Address) error {
+	return row.Scan(&a.Id, &a.UserId, &a.Name, &a.Line1, &a.Line2, &a.City, &a.Region, &a.PostalCode, &a.Country,
+		&a.Phone, &a.DefaultBilling, &a.DefaultShipping, &a.CreatedAt, &a.UpdatedAt)
+}
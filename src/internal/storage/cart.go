@@ -0,0 +1,349 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+type cartStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewCartStorage(c postgresql.Client, l *logging.Logger) *cartStorage {
+	return &cartStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// Create creates an empty cart of the user or with the anonymous token,
+// ErrAlreadyExists is returned if the user already has a cart
+func (s *cartStorage) Create(ctx context.Context, c *dmodel.Cart) (r dmodel.Cart, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("resolving currency of cart")
+
+	if c.Currency, err = resolveCurrency(ctx, s.db, c.Currency); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	q := `
+		INSERT INTO carts
+			(id, user_id, token, currency, created_at, updated_at)
+		VALUES
+			($1, $2, NULLIF($3, ''), $4, $5, $5)
+		ON CONFLICT DO NOTHING`
+
+	s.logger.Trace("executing SQL query to create cart")
+
+	tag, err := s.db.Exec(ctx, q, c.Id, c.UserId, c.Token, c.Currency, c.CreatedAt)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	if tag.RowsAffected() == 0 {
+		return r, apperror.ErrAlreadyExists
+	}
+
+	c.Items = make([]dmodel.CartItem, 0)
+	c.UpdatedAt = c.CreatedAt
+	return *c, nil
+}
+
+// FindOne returns the cart of the user or, if userId is empty, the anonymous cart with the token.
+// The items have the current product prices in the cart currency and the quantities in stock.
+func (s *cartStorage) FindOne(ctx context.Context, userId, token string) (r dmodel.Cart, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    c.id, c.user_id, COALESCE(c.token, ''), c.currency, c.created_at, c.updated_at
+		FROM
+		    carts c
+		WHERE
+		    c.user_id = $1`
+	owner := userId
+	if userId == "" {
+		q = `
+			SELECT
+			    c.id, c.user_id, COALESCE(c.token, ''), c.currency, c.created_at, c.updated_at
+			FROM
+			    carts c
+			WHERE
+			    c.token = $1 AND c.user_id IS NULL`
+		owner = token
+	}
+
+	s.logger.Trace("executing SQL query to find cart")
+
+	err = s.db.QueryRow(ctx, q, owner).Scan(&r.Id, &r.UserId, &r.Token, &r.Currency, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r, apperror.ErrNotFound
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	q = `
+		SELECT
+		    cc.product_id, p.description, cc.quantity,
+		    product_price(p.id, c.currency, now()), cc.added_price, p.quantity
+		FROM
+		    carts_content cc
+		JOIN carts c
+		ON c.id = cc.cart_id
+		JOIN products p
+		ON p.id = cc.product_id
+		WHERE
+		    cc.cart_id = $1
+		ORDER BY
+		    cc.created_at, cc.product_id`
+
+	s.logger.Trace("executing SQL query to find cart items")
+
+	rows, err := s.db.Query(ctx, q, r.Id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r.Items = make([]dmodel.CartItem, 0)
+	for rows.Next() {
+		i := dmodel.CartItem{
+			Price:      dmodel.Money{Currency: r.Currency},
+			AddedPrice: dmodel.Money{Currency: r.Currency},
+		}
+		err = rows.Scan(&i.ProductId, &i.Description, &i.Quantity, &i.Price.Amount, &i.AddedPrice.Amount, &i.Available)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r.Items = append(r.Items, i)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// AddItem adds the product to the cart, adding a product that is already in the cart increases its quantity
+func (s *cartStorage) AddItem(ctx context.Context, cartId, productId string, quantity int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		INSERT INTO carts_content
+			(cart_id, product_id, quantity, added_price)
+		SELECT
+			c.id, p.id, $3, product_price(p.id, c.currency, now())
+		FROM
+		    products p, carts c
+		WHERE
+		    p.id = $2 AND c.id = $1
+		ON CONFLICT (cart_id, product_id) DO UPDATE
+		SET
+		    quantity = carts_content.quantity + EXCLUDED.quantity
+		RETURNING product_id`
+
+	s.logger.Trace("executing SQL query to add product to cart")
+
+	var id string
+	if err := s.db.QueryRow(ctx, q, cartId, productId, quantity).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.ErrNotFound
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return s.touch(ctx, cartId)
+}
+
+func (s *cartStorage) SetItemQuantity(ctx context.Context, cartId, productId string, quantity int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    carts_content
+		SET
+		    quantity = $3
+		WHERE
+		    cart_id = $1 AND product_id = $2`
+
+	s.logger.Trace("executing SQL query to set quantity of product in cart")
+
+	tag, err := s.db.Exec(ctx, q, cartId, productId, quantity)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return s.touch(ctx, cartId)
+}
+
+func (s *cartStorage) DeleteItem(ctx context.Context, cartId, productId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    carts_content
+		WHERE
+		    cart_id = $1 AND product_id = $2`
+
+	s.logger.Trace("executing SQL query to delete product from cart")
+
+	tag, err := s.db.Exec(ctx, q, cartId, productId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return s.touch(ctx, cartId)
+}
+
+// Merge moves the anonymous cart with the token to the user. If the user already has a cart,
+// the products are added to it and the anonymous cart is deleted, otherwise the anonymous cart becomes the user's.
+func (s *cartStorage) Merge(ctx context.Context, token, userId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to merge carts")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    c.id
+			FROM
+			    carts c
+			WHERE
+			    c.token = $1 AND c.user_id IS NULL
+			FOR UPDATE`
+
+		var anonymousId string
+		if err := tx.QueryRow(ctx, q, token).Scan(&anonymousId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+
+		q = `
+			SELECT
+			    c.id
+			FROM
+			    carts c
+			WHERE
+			    c.user_id = $1
+			FOR UPDATE`
+
+		var userCartId string
+		if err := tx.QueryRow(ctx, q, userId).Scan(&userCartId); err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			q = `
+				UPDATE
+				    carts
+				SET
+				    user_id = $2, token = NULL, updated_at = now()
+				WHERE
+				    id = $1`
+
+			_, err = tx.Exec(ctx, q, anonymousId, userId)
+			return err
+		}
+
+		// prices added in another currency are replaced with the current ones in the user cart currency
+		q = `
+			INSERT INTO carts_content
+				(cart_id, product_id, quantity, added_price, created_at)
+			SELECT
+				u.id, cc.product_id, cc.quantity,
+				CASE WHEN a.currency = u.currency
+				     THEN cc.added_price
+				     ELSE product_price(cc.product_id, u.currency, now()) END,
+				cc.created_at
+			FROM
+			    carts_content cc
+			JOIN carts a
+			ON a.id = cc.cart_id
+			JOIN carts u
+			ON u.id = $2
+			WHERE
+			    cc.cart_id = $1
+			ON CONFLICT (cart_id, product_id) DO UPDATE
+			SET
+			    quantity = carts_content.quantity + EXCLUDED.quantity`
+
+		if _, err := tx.Exec(ctx, q, anonymousId, userCartId); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM carts WHERE id = $1`, anonymousId); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `UPDATE carts SET updated_at = now() WHERE id = $1`, userCartId)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// touch sets the time the cart was updated, anonymous carts not updated for long can be deleted
+func (s *cartStorage) touch(ctx context.Context, cartId string) error {
+	q := `
+		UPDATE
+		    carts
+		SET
+		    updated_at = now()
+		WHERE
+		    id = $1`
+
+	if _, err := s.db.Exec(ctx, q, cartId); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+type currencyStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewCurrencyStorage(c postgresql.Client, l *logging.Logger) *currencyStorage {
+	return &currencyStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+func (s *currencyStorage) FindAllRates(ctx context.Context) (r []dmodel.ExchangeRate, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    er.currency, er.rate, er.minor_units, er.is_base, er.updated_at
+		FROM
+		    exchange_rates er
+		ORDER BY
+		    er.is_base DESC, er.currency`
+
+	s.logger.Trace("executing SQL query to find all exchange rates")
+
+	rows, err := s.db.Query(ctx, q)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.ExchangeRate, 0)
+	for rows.Next() {
+		var er dmodel.ExchangeRate
+		if err = rows.Scan(&er.Currency, &er.Rate, &er.MinorUnits, &er.IsBase, &er.UpdatedAt); err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, er)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// SetRates adds new currencies and updates rates of the existing ones.
+// Minor units of existing currencies are kept, because stored amounts are in these units.
+func (s *currencyStorage) SetRates(ctx context.Context, rates []dmodel.ExchangeRate) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to set exchange rates")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			INSERT INTO exchange_rates
+				(currency, rate, minor_units, is_base)
+			VALUES
+				($1, $2, $3, false)
+			ON CONFLICT (currency) DO UPDATE
+			SET
+			    rate = EXCLUDED.rate, updated_at = now()`
+
+		for _, er := range rates {
+			if _, err := tx.Exec(ctx, q, er.Currency, er.Rate, er.MinorUnits); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// querier is implemented by both the connection pool and transactions
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// resolveCurrency returns the currency if it has an exchange rate or the base currency if currency is empty
+func resolveCurrency(ctx context.Context, db querier, currency string) (string, error) {
+	q := `
+		SELECT
+		    er.currency
+		FROM
+		    exchange_rates er
+		WHERE
+		    er.currency = $1 OR ($1 = '' AND er.is_base)`
+
+	var r string
+	if err := db.QueryRow(ctx, q, currency).Scan(&r); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r, apperror.ErrUnsupportedCurrency
+		}
+		return r, err
+	}
+	return r, nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+type idempotencyStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewIdempotencyStorage(c postgresql.Client, l *logging.Logger) *idempotencyStorage {
+	return &idempotencyStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// Lock takes the key for the request k until lockTimeout and keeps it for ttl. A key is taken if it is new,
+// expired, or held by the same request which did not store its response before its lock timed out.
+// Otherwise the key is not taken and the stored request is returned.
+func (s *idempotencyStorage) Lock(ctx context.Context, k *dmodel.IdempotencyKey, ttl, lockTimeout time.Duration) (
+	r dmodel.IdempotencyKey, locked bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	lq := `
+		INSERT INTO idempotency_keys
+			(scope, key, method, path, fingerprint, locked_until, expires_at)
+		VALUES
+			($1, $2, $3, $4, $5, now() + $6 * interval '1 millisecond', now() + $7 * interval '1 millisecond')
+		ON CONFLICT (scope, key) DO UPDATE
+		SET
+		    method = EXCLUDED.method,
+		    path = EXCLUDED.path,
+		    fingerprint = EXCLUDED.fingerprint,
+		    status_code = NULL,
+		    response_headers = NULL,
+		    response_body = NULL,
+		    created_at = now(),
+		    locked_until = EXCLUDED.locked_until,
+		    expires_at = EXCLUDED.expires_at
+		WHERE
+		    idempotency_keys.expires_at < now()
+		    OR (idempotency_keys.status_code IS NULL
+		        AND idempotency_keys.locked_until < now()
+		        AND idempotency_keys.fingerprint = EXCLUDED.fingerprint)
+		RETURNING true`
+
+	fq := `
+		SELECT
+		    ik.scope, ik.key, ik.method, ik.path, ik.fingerprint,
+		    COALESCE(ik.status_code, 0), ik.response_headers, ik.response_body
+		FROM
+		    idempotency_keys ik
+		WHERE
+		    ik.scope = $1 AND ik.key = $2`
+
+	// the stored key can be released or expire and be deleted between the queries, then it is taken again
+	for attempt := 0; attempt < 2; attempt++ {
+		s.logger.Trace("executing SQL query to lock idempotency key")
+
+		err = s.db.QueryRow(ctx, lq, k.Scope, k.Key, k.Method, k.Path, k.Fingerprint,
+			lockTimeout.Milliseconds(), ttl.Milliseconds()).Scan(&locked)
+		if err == nil {
+			return *k, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			break
+		}
+
+		s.logger.Trace("executing SQL query to find idempotency key")
+
+		err = s.db.QueryRow(ctx, fq, k.Scope, k.Key).Scan(&r.Scope, &r.Key, &r.Method, &r.Path, &r.Fingerprint,
+			&r.StatusCode, &r.ResponseHeaders, &r.ResponseBody)
+		if err == nil {
+			return r, false, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			break
+		}
+	}
+	if detErr := postgresql.DetailedPgError(err); detErr != nil {
+		return r, false, detErr
+	}
+	return r, false, err
+}
+
+// Complete stores the response of the request holding the key
+func (s *idempotencyStorage) Complete(ctx context.Context, k *dmodel.IdempotencyKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    idempotency_keys
+		SET
+		    status_code = $3,
+		    response_headers = $4,
+		    response_body = $5
+		WHERE
+		    scope = $1 AND key = $2 AND fingerprint = $6 AND status_code IS NULL`
+
+	s.logger.Trace("executing SQL query to store response of idempotency key")
+
+	_, err := s.db.Exec(ctx, q, k.Scope, k.Key, k.StatusCode, k.ResponseHeaders, k.ResponseBody, k.Fingerprint)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// Release deletes the key held by the request without a stored response, so the request can be retried
+func (s *idempotencyStorage) Release(ctx context.Context, k *dmodel.IdempotencyKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    idempotency_keys
+		WHERE
+		    scope = $1 AND key = $2 AND fingerprint = $3 AND status_code IS NULL`
+
+	s.logger.Trace("executing SQL query to release idempotency key")
+
+	if _, err := s.db.Exec(ctx, q, k.Scope, k.Key, k.Fingerprint); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteExpired deletes the expired keys and returns the number of deleted keys
+func (s *idempotencyStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    idempotency_keys ik
+		WHERE
+		    ik.expires_at < now()`
+
+	s.logger.Trace("executing SQL query to delete expired idempotency keys")
+
+	tag, err := s.db.Exec(ctx, q)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return 0, detErr
+		}
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test scenario:
+//  1. Lock a new key from several goroutines and check that exactly one of them takes it
+//  2. Store the response and check that the next lock returns it instead of taking the key
+//  3. Check that a key released without a response can be taken again
+func TestIdempotencyKeyIsTakenOnce(t *testing.T) {
+	c := newTestClient(t)
+	s := NewIdempotencyStorage(c, logging.NewLogger("error"))
+	ctx := context.Background()
+
+	k := dmodel.IdempotencyKey{
+		Scope:       uuid.New().String(),
+		Key:         uuid.New().String(),
+		Method:      http.MethodPost,
+		Path:        "/orders",
+		Fingerprint: "fingerprint",
+	}
+
+	const workers = 5
+	var wg sync.WaitGroup
+	results := make(chan bool, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, locked, err := s.Lock(ctx, &k, time.Hour, time.Minute)
+			assert.NoError(t, err)
+			results <- locked
+		}()
+	}
+	wg.Wait()
+	close(results)
+	taken := 0
+	for locked := range results {
+		if locked {
+			taken++
+		}
+	}
+	require.Equal(t, 1, taken, "the key must be taken by one request")
+
+	k.StatusCode = http.StatusCreated
+	k.ResponseHeaders = http.Header{"Content-Type": {"application/json"}}
+	k.ResponseBody = []byte(`{"id":"1"}`)
+	require.NoError(t, s.Complete(ctx, &k))
+
+	r, locked, err := s.Lock(ctx, &k, time.Hour, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, locked)
+	assert.Equal(t, http.StatusCreated, r.StatusCode)
+	assert.Equal(t, "application/json", r.ResponseHeaders.Get("Content-Type"))
+	assert.Equal(t, k.ResponseBody, r.ResponseBody)
+
+	released := dmodel.IdempotencyKey{Scope: k.Scope, Key: uuid.New().String(), Method: http.MethodPost,
+		Path: "/orders", Fingerprint: "fingerprint"}
+	_, locked, err = s.Lock(ctx, &released, time.Hour, time.Minute)
+	require.NoError(t, err)
+	require.True(t, locked)
+	require.NoError(t, s.Release(ctx, &released))
+	_, locked, err = s.Lock(ctx, &released, time.Hour, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, locked, "a released key must be taken again")
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/slava-911/test-task-0723/internal/domain/model"
 )
@@ -10,21 +11,156 @@ type UserStorage interface {
 	Create(ctx context.Context, req *dmodel.User) (dmodel.User, error)
 	FindOneByEmail(ctx context.Context, email string) (dmodel.User, error)
 	FindOneById(ctx context.Context, id string) (dmodel.User, error)
+	FindManyByIds(ctx context.Context, ids []string) ([]dmodel.User, error)
 	Update(ctx context.Context, id string, chFields map[string]string) error
+	SetAdmin(ctx context.Context, id string) error
 	Delete(ctx context.Context, id string) error
 }
 
 type OrderStorage interface {
 	Create(ctx context.Context, req *dmodel.Order) (dmodel.Order, error)
+	CreateFromCart(ctx context.Context, req *dmodel.Order, cartId string, reservedUntil time.Time) (dmodel.Order, error)
 	FindAllByUserId(ctx context.Context, id string, limit, offset int) ([]dmodel.Order, error)
 	FindOneById(ctx context.Context, id string) (dmodel.Order, error)
-	Complete(ctx context.Context, id string) error
-	AddProduct(ctx context.Context, productId, orderId string, quantity int) error
-	DeleteProduct(ctx context.Context, productId, orderId string) error
+	FindItemsByOrderIds(ctx context.Context, ids []string) ([]dmodel.OrderItem, error)
+	FindHistoryByOrderId(ctx context.Context, userId, id, productId string, limit, offset int) ([]dmodel.OrderContentEvent, error)
+	FindCouponsByOrderIds(ctx context.Context, ids []string) ([]dmodel.OrderCoupon, error)
+	ApplyCoupon(ctx context.Context, userId, orderId, code string) (dmodel.Promotion, error)
+	RemoveCoupon(ctx context.Context, userId, orderId string) error
+	Checkout(ctx context.Context, userId, id string, confirmPrices bool, price func(o *dmodel.Order, coupon *dmodel.OrderCoupon) error,
+		inv *dmodel.Invoice, invoicePrefix string) error
+	SetShippingRegion(ctx context.Context, userId, id, region string) error
+	SetAddresses(ctx context.Context, userId, id string, shippingId, billingId *string) error
+	SetShippingMethod(ctx context.Context, userId, id string, methodId *string) error
+	AddProduct(ctx context.Context, userId, productId, orderId string, quantity int, reservedUntil time.Time) error
+	SetProductQuantity(ctx context.Context, userId, productId, orderId string, quantity int, reservedUntil time.Time) error
+	DeleteProduct(ctx context.Context, userId, productId, orderId string) error
+	ReleaseExpiredReservations(ctx context.Context, limit int) (int, error)
 }
 
 type ProductStorage interface {
 	Create(ctx context.Context, req *dmodel.Product) (dmodel.Product, error)
-	FindOneById(ctx context.Context, id string) (dmodel.Product, error)
+	FindOneById(ctx context.Context, id, currency string) (dmodel.Product, error)
+	FindManyByIds(ctx context.Context, ids []string, currency string) ([]dmodel.Product, error)
 	Update(ctx context.Context, req *dmodel.Product) error
+	AddPrice(ctx context.Context, req *dmodel.ProductPrice) (dmodel.ProductPrice, error)
+	FindPricesByProductId(ctx context.Context, id, currency string, limit, offset int) ([]dmodel.ProductPrice, error)
+	DeletePrice(ctx context.Context, productId string, id int64) error
+}
+
+type StockStorage interface {
+	AddMovement(ctx context.Context, req *dmodel.StockMovement) (dmodel.StockMovement, error)
+	Transfer(ctx context.Context, req *dmodel.WarehouseTransfer) error
+	FindMovementsByProductId(ctx context.Context, id string, limit, offset int) ([]dmodel.StockMovement, error)
+	FindDrifts(ctx context.Context, all bool) ([]dmodel.StockDrift, error)
+}
+
+type WarehouseStorage interface {
+	Create(ctx context.Context, req *dmodel.Warehouse) (dmodel.Warehouse, error)
+	FindAll(ctx context.Context) ([]dmodel.Warehouse, error)
+	SetDefault(ctx context.Context, id string) error
+}
+
+type CurrencyStorage interface {
+	FindAllRates(ctx context.Context) ([]dmodel.ExchangeRate, error)
+	SetRates(ctx context.Context, rates []dmodel.ExchangeRate) error
+}
+
+type PromotionStorage interface {
+	Create(ctx context.Context, req *dmodel.Promotion) (dmodel.Promotion, error)
+	FindAll(ctx context.Context, limit, offset int) ([]dmodel.Promotion, error)
+}
+
+type TaxStorage interface {
+	Create(ctx context.Context, req *dmodel.TaxRule) (dmodel.TaxRule, error)
+	FindAll(ctx context.Context) ([]dmodel.TaxRule, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type PaymentStorage interface {
+	Create(ctx context.Context, userId string, req *dmodel.Payment) (dmodel.Payment, error)
+	SetIntent(ctx context.Context, id, intentId string) error
+	Fail(ctx context.Context, id string) error
+	FindOneById(ctx context.Context, id string) (dmodel.Payment, error)
+	FindAllByOrderId(ctx context.Context, userId, orderId string) ([]dmodel.Payment, error)
+	ApplyEvent(ctx context.Context, e *dmodel.PaymentEvent) (dmodel.Payment, error)
+	CreateRefund(ctx context.Context, req *dmodel.Refund) (dmodel.Refund, dmodel.Payment, error)
+	CompleteRefund(ctx context.Context, id, providerRefundId string) error
+	FailRefund(ctx context.Context, id string) error
+	FindRefundsByOrderId(ctx context.Context, userId, orderId string) ([]dmodel.Refund, error)
+}
+
+type ReturnStorage interface {
+	Create(ctx context.Context, req *dmodel.Return) (dmodel.Return, error)
+	FindOneById(ctx context.Context, id string) (dmodel.Return, error)
+	FindAllByOrderId(ctx context.Context, userId, orderId string) ([]dmodel.Return, error)
+	FindAll(ctx context.Context, status string, limit, offset int) ([]dmodel.Return, error)
+	Review(ctx context.Context, id string, status dmodel.ReturnStatus, note string) error
+	Receive(ctx context.Context, id string) error
+}
+
+type CartStorage interface {
+	Create(ctx context.Context, req *dmodel.Cart) (dmodel.Cart, error)
+	FindOne(ctx context.Context, userId, token string) (dmodel.Cart, error)
+	AddItem(ctx context.Context, cartId, productId string, quantity int) error
+	SetItemQuantity(ctx context.Context, cartId, productId string, quantity int) error
+	DeleteItem(ctx context.Context, cartId, productId string) error
+	Merge(ctx context.Context, token, userId string) error
+}
+
+type AddressStorage interface {
+	Create(ctx context.Context, req *dmodel.Address) (dmodel.Address, error)
+	FindAllByUserId(ctx context.Context, userId string) ([]dmodel.Address, error)
+	FindOneById(ctx context.Context, userId, id string) (dmodel.Address, error)
+	Update(ctx context.Context, req *dmodel.Address) (dmodel.Address, error)
+	Delete(ctx context.Context, userId, id string) error
+}
+
+type ShippingStorage interface {
+	Create(ctx context.Context, req *dmodel.ShippingMethod) (dmodel.ShippingMethod, error)
+	FindAll(ctx context.Context, currency string) ([]dmodel.ShippingMethod, error)
+	FindOneById(ctx context.Context, id, currency string) (dmodel.ShippingMethod, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type ShipmentStorage interface {
+	Create(ctx context.Context, req *dmodel.Shipment) (dmodel.Shipment, error)
+	FindOneById(ctx context.Context, id string) (dmodel.Shipment, error)
+	FindAllByOrderId(ctx context.Context, orderId string) ([]dmodel.Shipment, error)
+	Deliver(ctx context.Context, id string, deliveredAt time.Time) error
+	FindUnfulfilled(ctx context.Context, limit, offset int) ([]dmodel.FulfillmentOrder, error)
+}
+
+type InvoiceStorage interface {
+	FindOneByOrderId(ctx context.Context, orderId string) (dmodel.Invoice, error)
+}
+
+type NotificationStorage interface {
+	Create(ctx context.Context, req *dmodel.Notification) (dmodel.Notification, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]dmodel.Notification, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id, lastError string, retryAfter *time.Duration) error
+}
+
+type OutboxStorage interface {
+	PublishPending(ctx context.Context, limit int, publish func(events []dmodel.OutboxEvent) error) (int, error)
+	DeletePublished(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type WebhookStorage interface {
+	CreateEndpoint(ctx context.Context, req *dmodel.WebhookEndpoint) (dmodel.WebhookEndpoint, error)
+	FindAllEndpoints(ctx context.Context) ([]dmodel.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+	FindDeliveries(ctx context.Context, endpointId, status string, limit, offset int) ([]dmodel.WebhookDelivery, error)
+	FindDeliveryById(ctx context.Context, id int64) (dmodel.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int64) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]dmodel.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, a *dmodel.WebhookAttempt, status dmodel.WebhookDeliveryStatus, retryAfter time.Duration) error
+}
+
+type IdempotencyStorage interface {
+	Lock(ctx context.Context, k *dmodel.IdempotencyKey, ttl, lockTimeout time.Duration) (dmodel.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, k *dmodel.IdempotencyKey) error
+	Release(ctx context.Context, k *dmodel.IdempotencyKey) error
+	DeleteExpired(ctx context.Context) (int64, error)
 }
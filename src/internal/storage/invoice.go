@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// invoiceFields are the columns of invoices table i read by scanInvoice
+const invoiceFields = `
+	i.id, i.order_id, i.number, i.issued_at, i.seller, i.buyer, i.minor_units`
+
+type invoiceStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewInvoiceStorage(c postgresql.Client, l *logging.Logger) *invoiceStorage {
+	return &invoiceStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// FindOneByOrderId returns the invoice issued when the order was checked out
+func (s *invoiceStorage) FindOneByOrderId(ctx context.Context, orderId string) (r dmodel.Invoice, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + invoiceFields + `
+		FROM
+		    invoices i
+		WHERE
+		    i.order_id = $1`
+
+	s.logger.Trace("executing SQL query to find invoice by order id")
+
+	if err = scanInvoice(s.db.QueryRow(ctx, q, orderId), &r); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r, apperror.ErrNotFound
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// issueInvoice issues the invoice of the order being checked out in tx with the next number of the prefix.
+// The buyer is the user who placed the order, billed to the billing address copied to the order.
+func issueInvoice(ctx context.Context, tx pgx.Tx, inv *dmodel.Invoice, prefix string) error {
+	q := `
+		SELECT
+		    COALESCE(u.firstname, ''), COALESCE(u.lastname, ''), COALESCE(u.email, ''), o.billing_address,
+		    COALESCE(er.minor_units, 2)
+		FROM
+		    orders o
+		LEFT JOIN users u
+		ON u.id = o.user_id
+		LEFT JOIN exchange_rates er
+		ON er.currency = o.currency
+		WHERE
+		    o.id = $1`
+
+	var firstName, lastName, email string
+	var billing *dmodel.OrderAddress
+	err := tx.QueryRow(ctx, q, inv.OrderId).Scan(&firstName, &lastName, &email, &billing, &inv.MinorUnits)
+	if err != nil {
+		return err
+	}
+	inv.Buyer = dmodel.InvoiceBuyer(firstName, lastName, email, billing)
+
+	q = `
+		INSERT INTO invoice_counters
+			(prefix, last_number)
+		VALUES
+			($1, 1)
+		ON CONFLICT (prefix) DO UPDATE
+		SET
+		    last_number = invoice_counters.last_number + 1
+		RETURNING last_number`
+
+	var n int64
+	if err = tx.QueryRow(ctx, q, prefix).Scan(&n); err != nil {
+		return err
+	}
+	inv.Number = fmt.Sprintf("%s%08d", prefix, n)
+
+	q = `
+		INSERT INTO invoices
+			(id, order_id, number, issued_at, seller, buyer, minor_units)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.Exec(ctx, q, inv.Id, inv.OrderId, inv.Number, inv.IssuedAt, inv.Seller, inv.Buyer, inv.MinorUnits)
+	return err
+}
+
+// scanInvoice scans invoiceFields into i
+func scanInvoice(row pgx.Row, i *dmodel.Invoice) error {
+	return row.Scan(&i.Id, &i.OrderId, &i.Number, &i.IssuedAt, &i.Seller, &i.Buyer, &i.MinorUnits)
+}
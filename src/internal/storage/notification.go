@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// notificationFields are the columns of notifications table n read by scanNotification
+const notificationFields = `
+	n.id, n.event, n.user_id, n.recipient, n.subject, n.body, n.status, n.attempts, n.last_error,
+	n.next_attempt_at, n.created_at, n.sent_at`
+
+type notificationStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewNotificationStorage(c postgresql.Client, l *logging.Logger) *notificationStorage {
+	return &notificationStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+func (s *notificationStorage) Create(ctx context.Context, n *dmodel.Notification) (r dmodel.Notification, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		INSERT INTO notifications
+			(id, event, user_id, recipient, subject, body, status)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)
+		RETURNING next_attempt_at, created_at`
+
+	s.logger.Trace("executing SQL query to create notification")
+
+	row := s.db.QueryRow(ctx, q, n.Id, n.Event, n.UserId, n.Recipient, n.Subject, n.Body, n.Status)
+	if err = row.Scan(&n.NextAttemptAt, &n.CreatedAt); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return *n, nil
+}
+
+// ClaimDue returns up to limit pending notifications due to be sent and counts the attempt of sending them.
+// The next attempt of a claimed notification is postponed by lease, so other replicas skip it while
+// it is being sent and it is retried if this replica stops before reporting the result.
+func (s *notificationStorage) ClaimDue(ctx context.Context, limit int, lease time.Duration) (r []dmodel.Notification, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    notifications n
+		SET
+		    attempts = n.attempts + 1,
+		    next_attempt_at = now() + $2 * interval '1 millisecond'
+		WHERE
+		    n.id IN (
+		        SELECT
+		            id
+		        FROM
+		            notifications
+		        WHERE
+		            status = 'pending' AND next_attempt_at <= now()
+		        ORDER BY
+		            next_attempt_at
+		        LIMIT $1
+		        FOR UPDATE SKIP LOCKED
+		    )
+		RETURNING` + notificationFields
+
+	s.logger.Trace("executing SQL query to claim due notifications")
+
+	rows, err := s.db.Query(ctx, q, limit, lease.Milliseconds())
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.Notification, 0)
+	for rows.Next() {
+		var n dmodel.Notification
+		if err = scanNotification(rows, &n); err != nil {
+			return r, err
+		}
+		r = append(r, n)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+func (s *notificationStorage) MarkSent(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    notifications n
+		SET
+		    status = 'sent', sent_at = now(), last_error = ''
+		WHERE
+		    n.id = $1`
+
+	s.logger.Trace("executing SQL query to mark notification sent")
+
+	tag, err := s.db.Exec(ctx, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// MarkFailed records the error of the last attempt and schedules the next one in retryAfter,
+// the notification fails for good if retryAfter is nil
+func (s *notificationStorage) MarkFailed(ctx context.Context, id, lastError string, retryAfter *time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    notifications n
+		SET
+		    status = CASE WHEN $3::bigint IS NULL THEN 'failed' ELSE 'pending' END,
+		    last_error = $2,
+		    next_attempt_at = COALESCE(now() + $3 * interval '1 millisecond', n.next_attempt_at)
+		WHERE
+		    n.id = $1`
+
+	s.logger.Trace("executing SQL query to mark notification failed")
+
+	var retryMs *int64
+	if retryAfter != nil {
+		ms := retryAfter.Milliseconds()
+		retryMs = &ms
+	}
+	tag, err := s.db.Exec(ctx, q, id, lastError, retryMs)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// scanNotification scans notificationFields into n
+func scanNotification(row pgx.Row, n *dmodel.Notification) error {
+	return row.Scan(&n.Id, &n.Event, &n.UserId, &n.Recipient, &n.Subject, &n.Body, &n.Status, &n.Attempts,
+		&n.LastError, &n.NextAttemptAt, &n.CreatedAt, &n.SentAt)
+}
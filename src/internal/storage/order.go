@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -29,23 +31,133 @@ func (s *orderStorage) Create(ctx context.Context, o *dmodel.Order) (r dmodel.Or
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	q := `
-		INSERT INTO orders
-			(id, user_id, created_at, completed)
-		VALUES
-			($1, $2, $3, $4)
-		RETURNING id`
+	s.logger.Trace("resolving currency of order")
+
+	if o.Currency, err = resolveCurrency(ctx, s.db, o.Currency); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	s.logger.Trace("executing Tx to create order")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			INSERT INTO orders
+				(id, user_id, created_at, completed, currency, shipping_region)
+			VALUES
+				($1, $2, $3, $4, $5, $6)
+			RETURNING id`
+
+		row := tx.QueryRow(ctx, q, o.Id, o.UserId, o.CreatedAt, false, o.Currency, o.ShippingRegion)
+		if err := row.Scan(&o.Id); err != nil {
+			return err
+		}
+		return appendEvents(ctx, tx, dmodel.OrderCreated{OrderId: o.Id, UserId: o.UserId, Currency: o.Currency})
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	fillOrderAmounts(o, nil, nil, nil)
+	return *o, nil
+}
+
+// CreateFromCart creates the order with the products of the cart, reserves stock for them and empties the cart
+// in one transaction, so the order is not created if any of the products is out of stock and the cart can't be
+// converted to two orders. ErrEmptyCart is returned if the cart has no products.
+func (s *orderStorage) CreateFromCart(ctx context.Context, o *dmodel.Order, cartId string,
+	reservedUntil time.Time) (r dmodel.Order, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("resolving currency of order")
+
+	if o.Currency, err = resolveCurrency(ctx, s.db, o.Currency); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	s.logger.Trace("executing Tx to create order from cart")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			DELETE FROM
+			    carts_content
+			WHERE
+			    cart_id = $1
+			RETURNING
+			    product_id, quantity`
 
-	s.logger.Trace("executing SQL query to create order")
+		rows, err := tx.Query(ctx, q, cartId)
+		if err != nil {
+			return err
+		}
+		var lines []dmodel.OrderLine
+		for rows.Next() {
+			var l dmodel.OrderLine
+			if err = rows.Scan(&l.ProductId, &l.Quantity); err != nil {
+				rows.Close()
+				return err
+			}
+			lines = append(lines, l)
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return apperror.ErrEmptyCart
+		}
+		// lines are reserved in the order of product ids like stock is locked everywhere else
+		sort.Slice(lines, func(i, j int) bool { return lines[i].ProductId < lines[j].ProductId })
+
+		q = `
+			UPDATE
+			    carts
+			SET
+			    updated_at = now()
+			WHERE
+			    id = $1`
+
+		if _, err = tx.Exec(ctx, q, cartId); err != nil {
+			return err
+		}
+
+		q = `
+			INSERT INTO orders
+				(id, user_id, created_at, completed, currency, shipping_region)
+			VALUES
+				($1, $2, $3, $4, $5, $6)`
 
-	row := s.db.QueryRow(ctx, q, o.Id, o.UserId, o.CreatedAt, false)
-	if err = row.Scan(&o.Id); err != nil {
+		if _, err = tx.Exec(ctx, q, o.Id, o.UserId, o.CreatedAt, false, o.Currency, o.ShippingRegion); err != nil {
+			return err
+		}
+		events := []dmodel.DomainEvent{dmodel.OrderCreated{OrderId: o.Id, UserId: o.UserId, Currency: o.Currency}}
+		for _, l := range lines {
+			if err := addLine(ctx, tx, o.Id, l.ProductId, l.Quantity); err != nil {
+				return err
+			}
+			events = append(events, dmodel.ProductAddedToOrder{OrderId: o.Id, ProductId: l.ProductId, Quantity: l.Quantity})
+		}
+		if err := appendEvents(ctx, tx, events...); err != nil {
+			return err
+		}
+		return extendReservation(ctx, tx, o.Id, reservedUntil)
+	})
+	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return r, detErr
 		}
 		return r, err
 	}
 
+	fillOrderAmounts(o, nil, nil, nil)
 	return *o, nil
 }
 
@@ -55,7 +167,11 @@ func (s *orderStorage) FindAllByUserId(ctx context.Context, id string, limit, of
 
 	q := `
 		SELECT
-			uo.id, uo.user_id, uo.created_at, uo.completed, COALESCE(SUM(oc.price * oc.quantity), 0) AS cost
+			uo.id, COALESCE(uo.number, ''), uo.user_id, uo.created_at, uo.completed, uo.checked_out_at, uo.paid_at, uo.reserved_until,
+			uo.currency, uo.shipping_region, COALESCE(uo.subtotal, SUM(oc.price * oc.quantity), 0) AS subtotal,
+			uo.tax, uo.total, COALESCE(uo.prices_include_tax, false),
+			uo.shipping_address_id, uo.billing_address_id, uo.shipping_address, uo.billing_address,
+			uo.shipping_method_id, COALESCE(uo.shipping_method, ''), uo.shipping, uo.fulfillment_status
 		FROM 
 			(SELECT *
 			FROM orders
@@ -65,7 +181,10 @@ func (s *orderStorage) FindAllByUserId(ctx context.Context, id string, limit, of
 		LEFT JOIN orders_content oc
 		ON uo.id = oc.order_id
 		GROUP BY 
-			uo.id, uo.user_id, uo.created_at, uo.completed`
+			uo.id, uo.number, uo.user_id, uo.created_at, uo.completed, uo.checked_out_at, uo.paid_at, uo.reserved_until,
+			uo.currency, uo.shipping_region, uo.subtotal, uo.tax, uo.total, uo.prices_include_tax,
+			uo.shipping_address_id, uo.billing_address_id, uo.shipping_address, uo.billing_address,
+			uo.shipping_method_id, uo.shipping_method, uo.shipping, uo.fulfillment_status`
 
 	s.logger.Trace("executing SQL query to find all orders by user id")
 
@@ -77,7 +196,11 @@ func (s *orderStorage) FindAllByUserId(ctx context.Context, id string, limit, of
 	r = make([]dmodel.Order, 0, limit)
 	for rows.Next() {
 		var o dmodel.Order
-		err = rows.Scan(&o.Id, &o.UserId, &o.CreatedAt, &o.Completed, &o.Cost)
+		var shipping, tax, total *int64
+		err = rows.Scan(&o.Id, &o.Number, &o.UserId, &o.CreatedAt, &o.Completed, &o.CheckedOutAt, &o.PaidAt, &o.ReservedUntil, &o.Currency,
+			&o.ShippingRegion, &o.Subtotal.Amount, &tax, &total, &o.PricesIncludeTax,
+			&o.ShippingAddressId, &o.BillingAddressId, &o.ShippingAddress, &o.BillingAddress,
+			&o.ShippingMethodId, &o.ShippingMethod, &shipping, &o.FulfillmentStatus)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				return r, apperror.ErrNotFound
@@ -87,6 +210,7 @@ func (s *orderStorage) FindAllByUserId(ctx context.Context, id string, limit, of
 			}
 			return r, err
 		}
+		fillOrderAmounts(&o, shipping, tax, total)
 		r = append(r, o)
 	}
 
@@ -102,7 +226,11 @@ func (s *orderStorage) FindOneById(ctx context.Context, id string) (r dmodel.Ord
 
 	q := `
 		SELECT
-			o.id, o.user_id, o.created_at, o.completed, COALESCE(SUM(oc.price * oc.quantity), 0) AS cost
+			o.id, COALESCE(o.number, ''), o.user_id, o.created_at, o.completed, o.checked_out_at, o.paid_at, o.reserved_until,
+			o.currency, o.shipping_region, COALESCE(o.subtotal, SUM(oc.price * oc.quantity), 0) AS subtotal,
+			o.tax, o.total, COALESCE(o.prices_include_tax, false),
+			o.shipping_address_id, o.billing_address_id, o.shipping_address, o.billing_address,
+			o.shipping_method_id, COALESCE(o.shipping_method, ''), o.shipping, o.fulfillment_status
 		FROM 
 			orders o
 		LEFT JOIN orders_content oc
@@ -110,13 +238,20 @@ func (s *orderStorage) FindOneById(ctx context.Context, id string) (r dmodel.Ord
 		WHERE 
 		    o.id = $1
 		GROUP BY 
-			o.id, o.user_id, o.created_at, o.completed`
+			o.id, o.number, o.user_id, o.created_at, o.completed, o.checked_out_at, o.paid_at, o.reserved_until,
+			o.currency, o.shipping_region, o.subtotal, o.tax, o.total, o.prices_include_tax,
+			o.shipping_address_id, o.billing_address_id, o.shipping_address, o.billing_address,
+			o.shipping_method_id, o.shipping_method, o.shipping, o.fulfillment_status`
 
 	s.logger.Trace("executing SQL query to find order by id")
 
 	var o dmodel.Order
+	var shipping, tax, total *int64
 	row := s.db.QueryRow(ctx, q, id)
-	err = row.Scan(&o.Id, &o.UserId, &o.CreatedAt, &o.Completed, &o.Cost)
+	err = row.Scan(&o.Id, &o.Number, &o.UserId, &o.CreatedAt, &o.Completed, &o.CheckedOutAt, &o.PaidAt, &o.ReservedUntil, &o.Currency,
+		&o.ShippingRegion, &o.Subtotal.Amount, &tax, &total, &o.PricesIncludeTax,
+		&o.ShippingAddressId, &o.BillingAddressId, &o.ShippingAddress, &o.BillingAddress,
+		&o.ShippingMethodId, &o.ShippingMethod, &shipping, &o.FulfillmentStatus)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return r, apperror.ErrNotFound
@@ -130,24 +265,335 @@ func (s *orderStorage) FindOneById(ctx context.Context, id string) (r dmodel.Ord
 	if o.Id == "" {
 		return r, apperror.ErrNotFound
 	}
+	fillOrderAmounts(&o, shipping, tax, total)
 	return o, nil
 }
 
-func (s *orderStorage) Complete(ctx context.Context, id string) error {
+// fillOrderAmounts sets the currency of the order amounts and the frozen shipping, tax and total,
+// a draft order has no shipping and tax and the total equal to the subtotal until it is priced
+func fillOrderAmounts(o *dmodel.Order, shipping, tax, total *int64) {
+	o.Subtotal.Currency = o.Currency
+	o.Shipping = dmodel.Money{Currency: o.Currency}
+	o.Tax = dmodel.Money{Currency: o.Currency}
+	o.Total = o.Subtotal
+	if shipping != nil {
+		o.Shipping.Amount = *shipping
+	}
+	if tax != nil {
+		o.Tax.Amount = *tax
+	}
+	if total != nil {
+		o.Total.Amount = *total
+	}
+}
+
+func (s *orderStorage) FindItemsByOrderIds(ctx context.Context, ids []string) (r []dmodel.OrderItem, err error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	s.logger.Trace("executing SQL query to find items by order ids")
+
+	r, err = findItems(ctx, s.db, ids)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+func findItems(ctx context.Context, db querier, ids []string) (r []dmodel.OrderItem, err error) {
 	q := `
-		UPDATE 
-		    orders
-		SET 
-		    completed = true
-		WHERE 
-		    id = $1`
+		SELECT
+		    oc.order_id, oc.product_id, oc.description, oc.price, oc.currency, oc.quantity, oc.price * oc.quantity AS total,
+		    COALESCE(oc.discount, 0), COALESCE(oc.tax_rate, 0), COALESCE(oc.tax, 0), p.tags, COALESCE(p.weight, 0)
+		FROM
+		    orders_content oc
+		JOIN products p
+		ON p.id = oc.product_id
+		WHERE
+		    oc.order_id = ANY($1)
+		ORDER BY
+		    oc.order_id, oc.product_id`
+
+	rows, err := db.Query(ctx, q, ids)
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.OrderItem, 0)
+	for rows.Next() {
+		var i dmodel.OrderItem
+		err = rows.Scan(&i.OrderId, &i.ProductId, &i.Description, &i.Price.Amount, &i.Price.Currency, &i.Quantity,
+			&i.Total.Amount, &i.Discount.Amount, &i.TaxRate, &i.Tax.Amount, &i.Tags, &i.Weight)
+		if err != nil {
+			return r, err
+		}
+		i.Total.Currency = i.Price.Currency
+		i.Discount.Currency = i.Price.Currency
+		i.Tax.Currency = i.Price.Currency
+		r = append(r, i)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// FindHistoryByOrderId returns ErrNotFound if the order with the id is not an order of the user
+func (s *orderStorage) FindHistoryByOrderId(ctx context.Context, userId, id, productId string, limit, offset int) (r []dmodel.OrderContentEvent, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	s.logger.Trace("executing SQL query to complete order")
+	s.logger.Trace("executing SQL query to check order user")
 
-	if _, err := s.db.Exec(ctx, q, id); err != nil {
+	if err = checkOrderUser(ctx, s.db, id, userId); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	q := `
+		SELECT
+		    h.id,
+		    CASE h.operation WHEN 'I' THEN 'insert' WHEN 'U' THEN 'update' ELSE 'delete' END,
+		    h.stamp, h.product_id, h.description, h.price, o.currency, h.quantity, h.user_id
+		FROM
+		    orders_content_history h
+		JOIN orders o
+		ON o.id = h.order_id
+		WHERE
+		    h.order_id = $1 AND ($2 = '' OR h.product_id::text = $2)
+		ORDER BY
+		    h.stamp, h.id
+		LIMIT $3 OFFSET $4`
+
+	s.logger.Trace("executing SQL query to find order content history")
+
+	rows, err := s.db.Query(ctx, q, id, productId, limit, offset)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.OrderContentEvent, 0, limit)
+	for rows.Next() {
+		var e dmodel.OrderContentEvent
+		err = rows.Scan(&e.Id, &e.Operation, &e.Stamp, &e.ProductId, &e.Description, &e.Price.Amount, &e.Price.Currency, &e.Quantity, &e.UserId)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, e)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func (s *orderStorage) FindCouponsByOrderIds(ctx context.Context, ids []string) (r []dmodel.OrderCoupon, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing SQL query to find coupons by order ids")
+
+	r, err = findCoupons(ctx, s.db, ids)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+func findCoupons(ctx context.Context, db querier, ids []string) (r []dmodel.OrderCoupon, err error) {
+	q := `
+		SELECT` + promotionFields + `,
+		    op.order_id, op.discount
+		FROM
+		    orders_promotions op
+		JOIN promotions p
+		ON p.id = op.promotion_id
+		WHERE
+		    op.order_id = ANY($1)`
+
+	rows, err := db.Query(ctx, q, ids)
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.OrderCoupon, 0)
+	for rows.Next() {
+		var c dmodel.OrderCoupon
+		if err = scanPromotion(rows, &c.Promotion, &c.OrderId, &c.Discount); err != nil {
+			return r, err
+		}
+		r = append(r, c)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// checkCouponLimits returns the number of checked out orders which used the promotion and
+// ErrCouponNotApplicable if one more use by the user with the order would exceed the usage limits.
+// Coupons of draft orders are not counted, so the promotion must be locked by tx to check
+// the limits again at checkout.
+func checkCouponLimits(ctx context.Context, tx pgx.Tx, p *dmodel.Promotion, userId, orderId string) (int, error) {
+	q := `
+		SELECT
+		    COUNT(*), COUNT(*) FILTER (WHERE op.user_id = $2)
+		FROM
+		    orders_promotions op
+		JOIN orders o
+		ON o.id = op.order_id
+		WHERE
+		    op.promotion_id = $1 AND op.order_id <> $3 AND o.completed`
+
+	var uses, userUses int
+	if err := tx.QueryRow(ctx, q, p.Id, userId, orderId).Scan(&uses, &userUses); err != nil {
+		return 0, err
+	}
+	if p.UsageLimit != nil && uses >= *p.UsageLimit {
+		return uses, fmt.Errorf("%w: coupon usage limit is reached", apperror.ErrCouponNotApplicable)
+	}
+	if p.UsageLimitPerUser != nil && userUses >= *p.UsageLimitPerUser {
+		return uses, fmt.Errorf("%w: coupon usage limit per user is reached", apperror.ErrCouponNotApplicable)
+	}
+	return uses, nil
+}
+
+// ApplyCoupon attaches the promotion with the code to the order replacing the previous coupon.
+// Usage limits are checked with the promotion locked and checked again at checkout.
+// Orders of other users than userId are not found.
+func (s *orderStorage) ApplyCoupon(ctx context.Context, userId, orderId, code string) (r dmodel.Promotion, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to apply coupon to order")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, orderId, userId); err != nil {
+			return err
+		}
+
+		q := `
+			SELECT
+			    o.currency, COALESCE(o.completed, false)
+			FROM
+			    orders o
+			WHERE
+			    o.id = $1
+			FOR UPDATE`
+
+		var currency string
+		var completed bool
+		if err := tx.QueryRow(ctx, q, orderId).Scan(&currency, &completed); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if completed {
+			return fmt.Errorf("%w: order is completed", apperror.ErrCouponNotApplicable)
+		}
+
+		q = `
+			SELECT` + promotionFields + `,
+			    (p.starts_at IS NULL OR p.starts_at <= now()) AND (p.ends_at IS NULL OR p.ends_at > now())
+			FROM
+			    promotions p
+			WHERE
+			    p.code = $1
+			FOR UPDATE`
+
+		var active bool
+		if err := scanPromotion(tx.QueryRow(ctx, q, code), &r, &active); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if !active {
+			return fmt.Errorf("%w: coupon is not active", apperror.ErrCouponNotApplicable)
+		}
+		if r.Currency != "" && r.Currency != currency {
+			return fmt.Errorf("%w: coupon is valid for orders in %s only", apperror.ErrCouponNotApplicable, r.Currency)
+		}
+
+		uses, err := checkCouponLimits(ctx, tx, &r, userId, orderId)
+		if err != nil {
+			return err
+		}
+		r.Uses = uses
+
+		q = `
+			INSERT INTO orders_promotions
+				(order_id, promotion_id, user_id)
+			VALUES
+				($1, $2, $3)
+			ON CONFLICT (order_id) DO UPDATE
+			SET
+			    promotion_id = EXCLUDED.promotion_id, applied_at = now()`
+
+		_, err = tx.Exec(ctx, q, orderId, r.Id, userId)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// RemoveCoupon returns ErrNotFound if the order with the orderId is not an order of the user
+func (s *orderStorage) RemoveCoupon(ctx context.Context, userId, orderId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to remove coupon from order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, orderId, userId); err != nil {
+			return err
+		}
+		if err := lockOrder(ctx, tx, orderId); err != nil {
+			return err
+		}
+
+		q := `
+			DELETE FROM
+			    orders_promotions op
+			WHERE
+			    op.order_id = $1`
+
+		tag, err := tx.Exec(ctx, q, orderId)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return apperror.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return detErr
 		}
@@ -156,77 +602,552 @@ func (s *orderStorage) Complete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (s *orderStorage) AddProduct(ctx context.Context, productId, orderId string, quantity int) error {
+// Checkout validates the draft order and completes it in one transaction. Missing reservations of the lines
+// are taken from stock, changed prices fail the checkout with ErrPricesChanged unless confirmPrices is set
+// and then the lines get the current prices. The order is priced by price, its amounts and shipping method
+// are frozen and it gets a number. The invoice inv of the order is issued with the next number of invoicePrefix.
+// Orders of other users than userId are not found.
+func (s *orderStorage) Checkout(ctx context.Context, userId, id string, confirmPrices bool,
+	price func(o *dmodel.Order, coupon *dmodel.OrderCoupon) error, inv *dmodel.Invoice, invoicePrefix string) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	tx, err := s.db.Begin(ctx)
+	s.logger.Trace("executing Tx to check out order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, id, userId); err != nil {
+			return err
+		}
+
+		q := `
+			SELECT
+			    o.id, o.user_id, o.created_at, COALESCE(o.completed, false), o.currency, o.shipping_region,
+			    o.shipping_method_id
+			FROM
+			    orders o
+			WHERE
+			    o.id = $1
+			FOR UPDATE`
+
+		var o dmodel.Order
+		err := tx.QueryRow(ctx, q, id).Scan(&o.Id, &o.UserId, &o.CreatedAt, &o.Completed, &o.Currency, &o.ShippingRegion,
+			&o.ShippingMethodId)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if o.Completed {
+			return apperror.ErrOrderCompleted
+		}
+
+		if err = checkoutPrices(ctx, tx, id, confirmPrices); err != nil {
+			return err
+		}
+
+		if o.Items, err = findItems(ctx, tx, []string{id}); err != nil {
+			return err
+		}
+		if len(o.Items) == 0 {
+			return apperror.ErrEmptyOrder
+		}
+		// a reservation released by the sweeper is taken from stock again if it is still there
+		for _, i := range o.Items {
+			if err = reserveLine(ctx, tx, id, i.ProductId); err != nil {
+				return err
+			}
+		}
+
+		// the order is taxed for the region of the address it is shipped to, not for the region set before
+		shipping, err := snapshotAddresses(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if shipping != nil {
+			o.ShippingRegion = shipping.TaxRegion()
+		}
+
+		coupons, err := findCoupons(ctx, tx, []string{id})
+		if err != nil {
+			return err
+		}
+		var coupon *dmodel.OrderCoupon
+		if len(coupons) > 0 {
+			coupon = &coupons[0]
+			// other orders may have been checked out with the coupon since it was applied
+			q = `
+				SELECT
+				    p.id
+				FROM
+				    promotions p
+				WHERE
+				    p.id = $1
+				FOR UPDATE`
+
+			if _, err = tx.Exec(ctx, q, coupon.Promotion.Id); err != nil {
+				return err
+			}
+			if _, err = checkCouponLimits(ctx, tx, &coupon.Promotion, o.UserId, id); err != nil {
+				return err
+			}
+		}
+		for _, i := range o.Items {
+			o.Subtotal.Amount += i.Total.Amount
+		}
+		fillOrderAmounts(&o, nil, nil, nil)
+		if err = price(&o, coupon); err != nil {
+			return err
+		}
+
+		if err = freezeOrder(ctx, tx, &o); err != nil {
+			return err
+		}
+		inv.OrderId = o.Id
+		if err = issueInvoice(ctx, tx, inv, invoicePrefix); err != nil {
+			return err
+		}
+		return appendEvents(ctx, tx, dmodel.OrderCompleted{OrderId: o.Id, UserId: o.UserId, Number: o.Number,
+			Total: o.Total})
+	})
 	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return detErr
 		}
 		return err
 	}
-	s.logger.Trace("Started Tx to add product to order")
+	return nil
+}
 
+// checkoutPrices compares prices of the order lines with the current product prices, lines with changed prices
+// get the current ones if confirmPrices is set, otherwise ErrPricesChanged lists the changes
+func checkoutPrices(ctx context.Context, tx pgx.Tx, orderId string, confirmPrices bool) error {
 	q := `
 		SELECT
-		    p.id, p.price, p.quantity
+		    oc.product_id, oc.price, product_price(oc.product_id, oc.currency, now())
 		FROM
-		    products p
+		    orders_content oc
 		WHERE
-		    p.id = $1`
+		    oc.order_id = $1
+		ORDER BY
+		    oc.product_id
+		FOR UPDATE`
 
-	var p dmodel.Product
-	row := tx.QueryRow(ctx, q, productId)
-	if err = row.Scan(&p.Id, &p.Price, &p.Quantity); err != nil {
-		tx.Rollback(ctx)
-		if errors.Is(err, pgx.ErrNoRows) {
-			return apperror.ErrNotFound
+	rows, err := tx.Query(ctx, q, orderId)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]int64)
+	changes := make([]string, 0)
+	for rows.Next() {
+		var productId string
+		var price int64
+		var currentPrice *int64
+		if err = rows.Scan(&productId, &price, &currentPrice); err != nil {
+			rows.Close()
+			return err
 		}
-		if detErr := postgresql.DetailedPgError(err); detErr != nil {
-			return detErr
+		if currentPrice != nil && *currentPrice != price {
+			current[productId] = *currentPrice
+			changes = append(changes, fmt.Sprintf("product %s price %d -> %d", productId, price, *currentPrice))
 		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
 		return err
 	}
-	if p.Quantity < quantity {
-		tx.Rollback(ctx)
-		return fmt.Errorf("insufficient quantity in stock")
+
+	if len(changes) == 0 {
+		return nil
+	}
+	if !confirmPrices {
+		return fmt.Errorf("%w: %s", apperror.ErrPricesChanged, strings.Join(changes, ", "))
 	}
 
 	q = `
-		INSERT INTO orders_content
-			(order_id, product_id, price, quantity)
-		VALUES
-			($1, $2, $3, $4)
-		RETURNING product_id`
+		UPDATE
+		    orders_content oc
+		SET
+		    price = $3
+		WHERE
+		    oc.order_id = $1 AND oc.product_id = $2`
 
-	row = tx.QueryRow(ctx, q, orderId, productId, p.Price, quantity)
-	if err = row.Scan(&p.Id); err != nil {
-		tx.Rollback(ctx)
-		if detErr := postgresql.DetailedPgError(err); detErr != nil {
-			return detErr
+	for productId, price := range current {
+		if _, err = tx.Exec(ctx, q, orderId, productId, price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// freezeOrder writes the amounts of the priced order and its lines, completes the order and gives it a number
+func freezeOrder(ctx context.Context, tx pgx.Tx, o *dmodel.Order) error {
+	q := `
+		UPDATE
+		    orders_content
+		SET
+		    discount = $3, tax = $4, tax_rate = $5
+		WHERE
+		    order_id = $1 AND product_id = $2`
+
+	for _, i := range o.Items {
+		if _, err := tx.Exec(ctx, q, o.Id, i.ProductId, i.Discount.Amount, i.Tax.Amount, i.TaxRate); err != nil {
+			return err
 		}
+	}
+
+	var discount int64
+	for _, d := range o.Discounts {
+		discount += d.Amount.Amount
+	}
+
+	q = `
+		UPDATE
+		    orders_promotions
+		SET
+		    discount = $2
+		WHERE
+		    order_id = $1`
+
+	if _, err := tx.Exec(ctx, q, o.Id, discount); err != nil {
 		return err
 	}
 
 	q = `
 		UPDATE 
-		    products
+		    orders
 		SET 
-		    quantity = $1
+		    completed = true, reserved_until = NULL, checked_out_at = now(),
+		    number = 'ORD-' || lpad(nextval('order_number_seq')::text, 8, '0'),
+		    subtotal = $2, discount = $3, tax = $4, total = $5, prices_include_tax = $6,
+		    shipping = $7, shipping_method = NULLIF($8, ''), shipping_region = $9
 		WHERE 
-		    id = $2`
+		    id = $1
+		RETURNING number`
+
+	row := tx.QueryRow(ctx, q, o.Id, o.Subtotal.Amount, discount, o.Tax.Amount, o.Total.Amount, o.PricesIncludeTax,
+		o.Shipping.Amount, o.ShippingMethod, o.ShippingRegion)
+	return row.Scan(&o.Number)
+}
+
+// snapshotAddresses copies the chosen or default shipping and billing addresses of the user to the order,
+// the billing address defaults to the shipping one. The copy of the shipping address is returned,
+// nil if the order has no shipping address.
+func snapshotAddresses(ctx context.Context, tx pgx.Tx, orderId string) (*dmodel.OrderAddress, error) {
+	q := `
+		WITH chosen AS (
+		    SELECT
+		        o.id,
+		        COALESCE(o.shipping_address_id,
+		                 (SELECT a.id FROM user_addresses a WHERE a.user_id = o.user_id AND a.is_default_shipping))
+		            AS shipping_id,
+		        COALESCE(o.billing_address_id,
+		                 (SELECT a.id FROM user_addresses a WHERE a.user_id = o.user_id AND a.is_default_billing))
+		            AS billing_id
+		    FROM
+		        orders o
+		    WHERE
+		        o.id = $1
+		)
+		UPDATE
+		    orders o
+		SET
+		    shipping_address_id = c.shipping_id,
+		    billing_address_id = COALESCE(c.billing_id, c.shipping_id),
+		    shipping_address = (SELECT ` + orderAddressJSON + ` FROM user_addresses a WHERE a.id = c.shipping_id),
+		    billing_address = (SELECT ` + orderAddressJSON + ` FROM user_addresses a
+		                       WHERE a.id = COALESCE(c.billing_id, c.shipping_id))
+		FROM
+		    chosen c
+		WHERE
+		    o.id = c.id
+		RETURNING
+		    o.shipping_address`
+
+	var shipping *dmodel.OrderAddress
+	if err := tx.QueryRow(ctx, q, orderId).Scan(&shipping); err != nil {
+		return nil, err
+	}
+	return shipping, nil
+}
+
+// SetShippingRegion sets the region taxes of the draft order are calculated for. ErrShippingAddressSet is returned
+// if the order has a shipping address, its region is used then. Orders of other users than userId are not found.
+func (s *orderStorage) SetShippingRegion(ctx context.Context, userId, id, region string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to set shipping region of order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, id, userId); err != nil {
+			return err
+		}
+		if err := lockOrder(ctx, tx, id); err != nil {
+			return err
+		}
+
+		q := `
+			UPDATE 
+			    orders
+			SET 
+			    shipping_region = $2
+			WHERE 
+			    id = $1 AND shipping_address_id IS NULL`
+
+		tag, err := tx.Exec(ctx, q, id, region)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return apperror.ErrShippingAddressSet
+		}
+		return nil
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// SetAddresses sets the addresses of the user the draft order is shipped and billed to, nil ids are not changed.
+// The shipping region of the order becomes the region of the shipping address.
+// Orders of other users than userId are not found.
+func (s *orderStorage) SetAddresses(ctx context.Context, userId, id string, shippingId, billingId *string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to set addresses of order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, id, userId); err != nil {
+			return err
+		}
+		if err := lockOrder(ctx, tx, id); err != nil {
+			return err
+		}
+
+		q := `
+			SELECT
+			    a.country, a.region
+			FROM
+			    user_addresses a
+			JOIN orders o
+			ON o.user_id = a.user_id
+			WHERE
+			    a.id = $1 AND o.id = $2`
+
+		var shipping dmodel.Address
+		for _, addressId := range []*string{shippingId, billingId} {
+			if addressId == nil {
+				continue
+			}
+			var a dmodel.Address
+			if err := tx.QueryRow(ctx, q, *addressId, id).Scan(&a.Country, &a.Region); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return fmt.Errorf("address %s of the order user is %w", *addressId, apperror.ErrNotFound)
+				}
+				return err
+			}
+			if addressId == shippingId {
+				shipping = a
+			}
+		}
+
+		q = `
+			UPDATE
+			    orders
+			SET
+			    shipping_address_id = COALESCE($2, shipping_address_id),
+			    billing_address_id = COALESCE($3, billing_address_id),
+			    shipping_region = CASE WHEN $2::uuid IS NULL THEN shipping_region ELSE $4 END
+			WHERE
+			    id = $1`
+
+		_, err := tx.Exec(ctx, q, id, shippingId, billingId, shipping.TaxRegion())
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// SetShippingMethod chooses the method the draft order is shipped with, nil removes the chosen method,
+// orders of other users than userId are not found
+func (s *orderStorage) SetShippingMethod(ctx context.Context, userId, id string, methodId *string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to set shipping method of order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, id, userId); err != nil {
+			return err
+		}
+		if err := lockOrder(ctx, tx, id); err != nil {
+			return err
+		}
+
+		if methodId != nil {
+			q := `
+				SELECT
+				    m.id
+				FROM
+				    shipping_methods m
+				WHERE
+				    m.id = $1`
+
+			if err := tx.QueryRow(ctx, q, *methodId).Scan(new(string)); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return fmt.Errorf("shipping method %s is %w", *methodId, apperror.ErrNotFound)
+				}
+				return err
+			}
+		}
+
+		q := `
+			UPDATE
+			    orders
+			SET
+			    shipping_method_id = $2
+			WHERE
+			    id = $1`
+
+		_, err := tx.Exec(ctx, q, id, methodId)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *orderStorage) AddProduct(ctx context.Context, userId, productId, orderId string, quantity int, reservedUntil time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to add product to order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, orderId, userId); err != nil {
+			return err
+		}
+		if err := lockOrder(ctx, tx, orderId); err != nil {
+			return err
+		}
+		if err := addLine(ctx, tx, orderId, productId, quantity); err != nil {
+			return err
+		}
+		err := appendEvents(ctx, tx, dmodel.ProductAddedToOrder{OrderId: orderId, ProductId: productId, Quantity: quantity})
+		if err != nil {
+			return err
+		}
+		return extendReservation(ctx, tx, orderId, reservedUntil)
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *orderStorage) SetProductQuantity(ctx context.Context, userId, productId, orderId string, quantity int, reservedUntil time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to set quantity of product in order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, orderId, userId); err != nil {
+			return err
+		}
+		if err := lockOrder(ctx, tx, orderId); err != nil {
+			return err
+		}
+
+		q := `
+			UPDATE
+			    orders_content oc
+			SET
+			    quantity = $3
+			WHERE
+			    oc.order_id = $1 AND oc.product_id = $2`
 
-	if _, err = tx.Exec(ctx, q, p.Quantity-quantity, productId); err != nil {
-		tx.Rollback(ctx)
+		tag, err := tx.Exec(ctx, q, orderId, productId, quantity)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return apperror.ErrNotFound
+		}
+
+		if err = reserveLine(ctx, tx, orderId, productId); err != nil {
+			return err
+		}
+		err = appendEvents(ctx, tx, dmodel.OrderProductQuantityChanged{OrderId: orderId, ProductId: productId,
+			Quantity: quantity})
+		if err != nil {
+			return err
+		}
+		return extendReservation(ctx, tx, orderId, reservedUntil)
+	})
+	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return detErr
 		}
 		return err
 	}
+	return nil
+}
+
+func (s *orderStorage) DeleteProduct(ctx context.Context, userId, productId, orderId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to delete product from order")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := checkOrderUser(ctx, tx, orderId, userId); err != nil {
+			return err
+		}
+		if err := lockOrder(ctx, tx, orderId); err != nil {
+			return err
+		}
+
+		_, allocated, err := lockLine(ctx, tx, orderId, productId)
+		if err != nil {
+			return err
+		}
+
+		// reserved products of the deleted line go back to the warehouses they were taken from
+		err = releaseStock(ctx, tx, productId, orderId, allocated, dmodel.StockCancellation, "product deleted from order")
+		if err != nil {
+			return err
+		}
 
-	err = tx.Commit(ctx)
+		q := `
+			DELETE FROM
+			    orders_content oc
+			WHERE
+			    oc.order_id = $1 AND oc.product_id = $2`
+
+		if _, err = tx.Exec(ctx, q, orderId, productId); err != nil {
+			return err
+		}
+		return appendEvents(ctx, tx, dmodel.ProductRemovedFromOrder{OrderId: orderId, ProductId: productId})
+	})
 	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return detErr
@@ -236,23 +1157,252 @@ func (s *orderStorage) AddProduct(ctx context.Context, productId, orderId string
 	return nil
 }
 
-func (s *orderStorage) DeleteProduct(ctx context.Context, productId, orderId string) error {
+// ReleaseExpiredReservations returns reserved stock of not completed orders with an expired reservation
+// and returns the number of released orders. Every order is released in its own transaction,
+// orders locked by other transactions (e.g. by the sweeper of another app replica) are skipped.
+// Orders failed to be released are skipped too, their errors are returned joined after the other orders are released.
+func (s *orderStorage) ReleaseExpiredReservations(ctx context.Context, limit int) (released int, err error) {
+	s.logger.Trace("executing Tx to release expired reservations")
+
+	failed := make([]string, 0)
+	var errs []error
+	for released+len(failed) < limit {
+		orderId, err := s.releaseExpiredReservation(ctx, failed)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				err = detErr
+			}
+			// there is no order to skip if the next one could not be found
+			if orderId == "" {
+				return released, errors.Join(append(errs, err)...)
+			}
+			failed = append(failed, orderId)
+			errs = append(errs, fmt.Errorf("failed to release reservation of order (id %s): %w", orderId, err))
+			continue
+		}
+		if orderId == "" {
+			break
+		}
+		s.logger.Debugf("reservation of order (id %s) released", orderId)
+		released++
+	}
+	return released, errors.Join(errs...)
+}
+
+// releaseExpiredReservation releases the reservation of the order that expired first except the skipped ones
+// and returns its id, the id is empty if there is no such order
+func (s *orderStorage) releaseExpiredReservation(ctx context.Context, skipped []string) (orderId string, err error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    o.id
+			FROM
+			    orders o
+			WHERE
+			    o.reserved_until < now() AND NOT COALESCE(o.completed, false) AND o.id <> ALL($1)
+			ORDER BY
+			    o.reserved_until
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED`
+
+		if err := tx.QueryRow(ctx, q, skipped).Scan(&orderId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		if err := releaseOrderReservation(ctx, tx, orderId, "reservation expired"); err != nil {
+			return err
+		}
+
+		q = `
+			UPDATE
+			    orders o
+			SET
+			    reserved_until = NULL
+			WHERE
+			    o.id = $1`
+
+		_, err := tx.Exec(ctx, q, orderId)
+		return err
+	})
+	return orderId, err
+}
+
+// lockOrder locks the draft order row, so changes of the order content are serialized
+// with each other and with the reservation sweeper. Completed orders can't be changed.
+func lockOrder(ctx context.Context, tx pgx.Tx, orderId string) error {
+	q := `
+		SELECT
+		    COALESCE(o.completed, false)
+		FROM
+		    orders o
+		WHERE
+		    o.id = $1
+		FOR UPDATE`
+
+	var completed bool
+	if err := tx.QueryRow(ctx, q, orderId).Scan(&completed); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.ErrNotFound
+		}
+		return err
+	}
+	if completed {
+		return apperror.ErrOrderCompleted
+	}
+	return nil
+}
+
+// checkOrderUser returns ErrNotFound if there is no order with the id of the user,
+// so users can't tell orders of other users from missing ones
+func checkOrderUser(ctx context.Context, db querier, orderId, userId string) error {
+	q := `
+		SELECT
+		    o.id
+		FROM
+		    orders o
+		WHERE
+		    o.id = $1 AND o.user_id = $2`
+
+	var id string
+	if err := db.QueryRow(ctx, q, orderId, userId).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// lockLine locks the order line and returns its quantity and the quantity reserved in warehouses
+func lockLine(ctx context.Context, tx pgx.Tx, orderId, productId string) (quantity, allocated int, err error) {
 	q := `
-		DELETE FROM
+		SELECT
+		    oc.quantity,
+		    COALESCE((SELECT SUM(a.quantity)
+		              FROM orders_content_allocations a
+		              WHERE a.order_id = oc.order_id AND a.product_id = oc.product_id), 0)
+		FROM
 		    orders_content oc
 		WHERE
-		    oc.order_id = $1 AND oc.product_id = $2`
+		    oc.order_id = $1 AND oc.product_id = $2
+		FOR UPDATE`
+
+	if err = tx.QueryRow(ctx, q, orderId, productId).Scan(&quantity, &allocated); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, apperror.ErrNotFound
+		}
+		return 0, 0, err
+	}
+	return quantity, allocated, nil
+}
 
-	s.logger.Trace("executing SQL query to delete product from order")
+// addLine adds the product to the locked order and reserves it. Adding a product that is already in the order
+// increases the quantity of the line, the line keeps the price in the order currency effective when it was created
+// and its description.
+func addLine(ctx context.Context, tx pgx.Tx, orderId, productId string, quantity int) error {
+	q := `
+		INSERT INTO orders_content
+			(order_id, product_id, price, currency, quantity, description)
+		SELECT
+			o.id, p.id, product_price(p.id, o.currency, now()), o.currency, $3, p.description
+		FROM
+		    products p, orders o
+		WHERE
+		    p.id = $2 AND o.id = $1
+		ON CONFLICT (order_id, product_id) DO UPDATE
+		SET
+		    quantity = orders_content.quantity + EXCLUDED.quantity
+		RETURNING product_id`
 
-	if _, err := s.db.Exec(ctx, q, orderId, productId); err != nil {
-		if detErr := postgresql.DetailedPgError(err); detErr != nil {
-			return detErr
+	var id string
+	if err := tx.QueryRow(ctx, q, orderId, productId, quantity).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.ErrNotFound
 		}
 		return err
 	}
+	return reserveLine(ctx, tx, orderId, productId)
+}
+
+// reserveLine makes the reserved quantity of the order line equal to its quantity,
+// taking missing products from stock or returning the excess
+func reserveLine(ctx context.Context, tx pgx.Tx, orderId, productId string) error {
+	quantity, allocated, err := lockLine(ctx, tx, orderId, productId)
+	if err != nil {
+		return err
+	}
+
+	switch delta := quantity - allocated; {
+	case delta > 0:
+		return allocateStock(ctx, tx, productId, orderId, delta)
+	case delta < 0:
+		return releaseStock(ctx, tx, productId, orderId, -delta, dmodel.StockCancellation, "order quantity decreased")
+	}
 	return nil
 }
+
+// releaseOrderReservation returns reserved products of all lines of the order to stock, lines stay in the order
+func releaseOrderReservation(ctx context.Context, tx pgx.Tx, orderId, reason string) error {
+	q := `
+		SELECT
+		    a.product_id, SUM(a.quantity)
+		FROM
+		    orders_content_allocations a
+		WHERE
+		    a.order_id = $1
+		GROUP BY
+		    a.product_id
+		ORDER BY
+		    a.product_id`
+
+	rows, err := tx.Query(ctx, q, orderId)
+	if err != nil {
+		return err
+	}
+	allocated := make(map[string]int)
+	productIds := make([]string, 0)
+	for rows.Next() {
+		var productId string
+		var quantity int
+		if err = rows.Scan(&productId, &quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		allocated[productId] = quantity
+		productIds = append(productIds, productId)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, productId := range productIds {
+		if _, _, err = lockLine(ctx, tx, orderId, productId); err != nil {
+			return err
+		}
+		err = releaseStock(ctx, tx, productId, orderId, allocated[productId], dmodel.StockCancellation, reason)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extendReservation(ctx context.Context, tx pgx.Tx, orderId string, reservedUntil time.Time) error {
+	q := `
+		UPDATE
+		    orders o
+		SET
+		    reserved_until = $2
+		WHERE
+		    o.id = $1 AND NOT COALESCE(o.completed, false)`
+
+	_, err := tx.Exec(ctx, q, orderId, reservedUntil)
+	return err
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// outboxRelayLock is the key of the advisory lock held by the relay publishing events,
+// one relay at a time keeps the events in the order they were written
+const outboxRelayLock = 0x6f7574626f78
+
+type outboxStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewOutboxStorage(c postgresql.Client, l *logging.Logger) *outboxStorage {
+	return &outboxStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// appendEvents writes the events to the outbox in the transaction of the change they report,
+// so an event is published if and only if the change is committed. Deliveries of the events
+// to the subscribed webhook endpoints are created with them.
+func appendEvents(ctx context.Context, tx pgx.Tx, events ...dmodel.DomainEvent) error {
+	q := `
+		WITH event AS (
+		    INSERT INTO outbox
+		        (event_id, type, aggregate_type, aggregate_id, payload)
+		    VALUES
+		        ($1, $2, $3, $4, $5)
+		    RETURNING event_id, type, payload, occurred_at
+		)
+		INSERT INTO webhook_deliveries
+			(endpoint_id, event_id, event_type, payload, occurred_at)
+		SELECT
+		    we.id, e.event_id, e.type, e.payload, e.occurred_at
+		FROM
+		    event e
+		JOIN webhook_endpoints we
+		ON e.type = ANY(we.event_types)`
+
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, q, uuid.New().String(), e.EventType(), dmodel.AggregateType(e.EventType()),
+			e.AggregateId(), payload)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishPending passes up to limit unpublished events to publish in the order they were written and marks
+// them published if publish succeeds. Events are published by one relay at a time, 0 is returned
+// while another replica holds the relay lock.
+func (s *outboxStorage) PublishPending(ctx context.Context, limit int,
+	publish func(events []dmodel.OutboxEvent) error) (published int, err error) {
+	// the transaction is open while the broker receives the events, so it gets more time than a query
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to publish outbox events")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		published = 0
+
+		var locked bool
+		if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", outboxRelayLock).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		q := `
+			SELECT
+			    ob.id, ob.event_id, ob.type, ob.aggregate_type, ob.aggregate_id, ob.payload, ob.occurred_at
+			FROM
+			    outbox ob
+			WHERE
+			    ob.published_at IS NULL
+			ORDER BY
+			    ob.id
+			LIMIT $1`
+
+		rows, err := tx.Query(ctx, q, limit)
+		if err != nil {
+			return err
+		}
+		events := make([]dmodel.OutboxEvent, 0)
+		ids := make([]int64, 0)
+		for rows.Next() {
+			var e dmodel.OutboxEvent
+			if err = rows.Scan(&e.Id, &e.EventId, &e.Type, &e.AggregateType, &e.AggregateId, &e.Payload,
+				&e.OccurredAt); err != nil {
+				rows.Close()
+				return err
+			}
+			events = append(events, e)
+			ids = append(ids, e.Id)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err = publish(events); err != nil {
+			return err
+		}
+
+		q = `
+			UPDATE
+			    outbox ob
+			SET
+			    published_at = now()
+			WHERE
+			    ob.id = ANY($1)`
+
+		if _, err = tx.Exec(ctx, q, ids); err != nil {
+			return err
+		}
+		published = len(events)
+		return nil
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return 0, detErr
+		}
+		return 0, err
+	}
+	return published, nil
+}
+
+// DeletePublished deletes events published more than retention ago and returns the number of deleted events
+func (s *outboxStorage) DeletePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    outbox ob
+		WHERE
+		    ob.published_at < now() - $1 * interval '1 millisecond'`
+
+	s.logger.Trace("executing SQL query to delete published outbox events")
+
+	tag, err := s.db.Exec(ctx, q, retention.Milliseconds())
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return 0, detErr
+		}
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publishAll publishes all pending outbox events and returns them
+func publishAll(t *testing.T, s *outboxStorage) []dmodel.OutboxEvent {
+	var r []dmodel.OutboxEvent
+	for {
+		n, err := s.PublishPending(context.Background(), 100, func(events []dmodel.OutboxEvent) error {
+			r = append(r, events...)
+			return nil
+		})
+		require.NoError(t, err)
+		if n == 0 {
+			return r
+		}
+	}
+}
+
+// Test scenario:
+//  1. Publish the pending events and create an order with a product
+//  2. Fail publishing once and check that the events stay in the outbox
+//  3. Publish again and check that the events of the order come once and in the order of the changes
+//  4. Check that nothing is left to publish
+func TestOutboxPublishesEventsOfCommittedChanges(t *testing.T) {
+	c := newTestClient(t)
+	s := NewOutboxStorage(c, logging.NewLogger("error"))
+	orders := NewOrderStorage(c, logging.NewLogger("error"))
+	publishAll(t, s)
+
+	p := newTestProduct(t, c, 10)
+	order := newTestOrders(t, orders, 1)[0]
+	require.NoError(t, orders.AddProduct(context.Background(), order.UserId, p.Id, order.Id, 2, time.Now().Add(time.Hour)))
+
+	failed := errors.New("broker is down")
+	_, err := s.PublishPending(context.Background(), 100, func([]dmodel.OutboxEvent) error { return failed })
+	require.ErrorIs(t, err, failed)
+
+	types := make([]string, 0)
+	for _, e := range publishAll(t, s) {
+		if e.AggregateId == order.Id {
+			types = append(types, e.Type)
+		}
+		if e.Type == (dmodel.ProductAddedToOrder{}).EventType() && e.AggregateId == order.Id {
+			var added dmodel.ProductAddedToOrder
+			require.NoError(t, json.Unmarshal(e.Payload, &added))
+			assert.Equal(t, p.Id, added.ProductId)
+			assert.Equal(t, 2, added.Quantity)
+		}
+		_, err = uuid.Parse(e.EventId)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"order.created", "order.product_added"}, types)
+	assert.Empty(t, publishAll(t, s))
+}
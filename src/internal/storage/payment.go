@@ -0,0 +1,632 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// paymentFields are the columns of payments table p read by scanPayment
+const paymentFields = `
+	p.id, p.order_id, p.provider, COALESCE(p.intent_id, ''), p.amount, p.currency, p.status,
+	p.captured_amount, p.refunded_amount, p.created_at, p.updated_at`
+
+type paymentStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewPaymentStorage(c postgresql.Client, l *logging.Logger) *paymentStorage {
+	return &paymentStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// Create creates a pending payment of the checked out order total of the user, pending payments
+// of the order created before are canceled
+func (s *paymentStorage) Create(ctx context.Context, userId string, p *dmodel.Payment) (r dmodel.Payment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to create payment")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    COALESCE(o.completed, false), o.paid_at IS NOT NULL, COALESCE(o.total, 0), o.currency
+			FROM
+			    orders o
+			WHERE
+			    o.id = $1 AND o.user_id = $2
+			FOR UPDATE`
+
+		var completed, paid bool
+		err := tx.QueryRow(ctx, q, p.OrderId, userId).Scan(&completed, &paid, &p.Amount.Amount, &p.Amount.Currency)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		switch {
+		case !completed:
+			return apperror.ErrOrderNotCheckedOut
+		case paid:
+			return apperror.ErrOrderPaid
+		case p.Amount.Amount <= 0:
+			return apperror.ErrNothingToPay
+		}
+
+		q = `
+			UPDATE
+			    payments
+			SET
+			    status = 'canceled', updated_at = now()
+			WHERE
+			    order_id = $1 AND status = 'pending'`
+
+		if _, err = tx.Exec(ctx, q, p.OrderId); err != nil {
+			return err
+		}
+
+		q = `
+			INSERT INTO payments
+				(id, order_id, provider, amount, currency, status, created_at, updated_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $7)`
+
+		_, err = tx.Exec(ctx, q, p.Id, p.OrderId, p.Provider, p.Amount.Amount, p.Amount.Currency, p.Status, p.CreatedAt)
+		if postgresql.IsUniqueViolation(err) {
+			// an authorized payment of the order is waiting for capture
+			return apperror.ErrAlreadyExists
+		}
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	p.CapturedAmount = dmodel.Money{Currency: p.Amount.Currency}
+	p.RefundedAmount = dmodel.Money{Currency: p.Amount.Currency}
+	p.UpdatedAt = p.CreatedAt
+	return *p, nil
+}
+
+func (s *paymentStorage) SetIntent(ctx context.Context, id, intentId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    payments
+		SET
+		    intent_id = $2, updated_at = now()
+		WHERE
+		    id = $1`
+
+	s.logger.Trace("executing SQL query to set intent of payment")
+
+	tag, err := s.db.Exec(ctx, q, id, intentId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// Fail marks the pending payment failed, e.g. when the provider failed to create it
+func (s *paymentStorage) Fail(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    payments
+		SET
+		    status = 'failed', updated_at = now()
+		WHERE
+		    id = $1 AND status = 'pending'`
+
+	s.logger.Trace("executing SQL query to fail payment")
+
+	if _, err := s.db.Exec(ctx, q, id); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *paymentStorage) FindOneById(ctx context.Context, id string) (r dmodel.Payment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + paymentFields + `
+		FROM
+		    payments p
+		WHERE
+		    p.id = $1`
+
+	s.logger.Trace("executing SQL query to find payment by id")
+
+	if err = scanPayment(s.db.QueryRow(ctx, q, id), &r); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r, apperror.ErrNotFound
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// FindAllByOrderId returns payments of the order of the user, ErrNotFound if the user has no such order
+func (s *paymentStorage) FindAllByOrderId(ctx context.Context, userId, orderId string) (r []dmodel.Payment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing SQL query to check order user")
+
+	if err = checkOrderUser(ctx, s.db, orderId, userId); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	q := `
+		SELECT` + paymentFields + `
+		FROM
+		    payments p
+		WHERE
+		    p.order_id = $1
+		ORDER BY
+		    p.created_at, p.id`
+
+	s.logger.Trace("executing SQL query to find payments by order id")
+
+	rows, err := s.db.Query(ctx, q, orderId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.Payment, 0)
+	for rows.Next() {
+		var p dmodel.Payment
+		if err = scanPayment(rows, &p); err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, p)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// ApplyEvent changes the status of the payment with the event intent, the order is paid when the whole payment
+// is captured. Capture events report the total captured amount, a partially captured payment is captured again
+// by events with a larger amount. ErrCaptureAmount is returned if the amount is not above the captured amount
+// or exceeds the payment amount. Events delivered again and events not allowed in the payment status are ignored.
+func (s *paymentStorage) ApplyEvent(ctx context.Context, e *dmodel.PaymentEvent) (r dmodel.Payment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to apply payment event")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    p.id, p.order_id
+			FROM
+			    payments p
+			WHERE
+			    p.provider = $1 AND p.intent_id = $2`
+
+		var paymentId, orderId string
+		if err := tx.QueryRow(ctx, q, e.Provider, e.IntentId).Scan(&paymentId, &orderId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if err := lockPaymentOrder(ctx, tx, orderId); err != nil {
+			return err
+		}
+
+		q = `
+			SELECT` + paymentFields + `
+			FROM
+			    payments p
+			WHERE
+			    p.id = $1
+			FOR UPDATE`
+
+		if err := scanPayment(tx.QueryRow(ctx, q, paymentId), &r); err != nil {
+			return err
+		}
+
+		q = `
+			INSERT INTO payment_events
+				(provider, event_id, payment_id, type)
+			VALUES
+				($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`
+
+		tag, err := tx.Exec(ctx, q, e.Provider, e.EventId, paymentId, e.Type)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+		captured := r.CapturedAmount.Amount
+		recaptured := r.Status == dmodel.PaymentCaptured && e.Status == dmodel.PaymentCaptured && e.Amount > captured
+		if !r.Status.CanBecome(e.Status) && !recaptured {
+			return nil
+		}
+
+		if e.Status == dmodel.PaymentCaptured {
+			if e.Amount <= captured || e.Amount > r.Amount.Amount {
+				return fmt.Errorf("%w: %d is not in (%d, %d]", apperror.ErrCaptureAmount, e.Amount, captured, r.Amount.Amount)
+			}
+			captured = e.Amount
+		}
+
+		q = `
+			UPDATE
+			    payments
+			SET
+			    status = $2, captured_amount = $3, updated_at = now()
+			WHERE
+			    id = $1
+			RETURNING
+			    updated_at`
+
+		if err = tx.QueryRow(ctx, q, paymentId, e.Status, captured).Scan(&r.UpdatedAt); err != nil {
+			return err
+		}
+		r.Status = e.Status
+		r.CapturedAmount.Amount = captured
+
+		if e.Status != dmodel.PaymentCaptured || captured < r.Amount.Amount {
+			return nil
+		}
+
+		q = `
+			UPDATE
+			    orders
+			SET
+			    paid_at = now()
+			WHERE
+			    id = $1 AND paid_at IS NULL`
+
+		_, err = tx.Exec(ctx, q, orderId)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// CreateRefund reserves the refund amount in the captured payment of the order and creates a pending refund.
+// A refund for a return refunds the received return, by default for the amount suggested by it,
+// other refunds default to the whole refundable amount.
+func (s *paymentStorage) CreateRefund(ctx context.Context, ref *dmodel.Refund) (r dmodel.Refund, p dmodel.Payment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to create refund")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if err := lockPaymentOrder(ctx, tx, ref.OrderId); err != nil {
+			return err
+		}
+
+		q := `
+			SELECT` + paymentFields + `
+			FROM
+			    payments p
+			WHERE
+			    p.order_id = $1 AND p.status = 'captured'
+			FOR UPDATE`
+
+		if err := scanPayment(tx.QueryRow(ctx, q, ref.OrderId), &p); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: order has no captured payment", apperror.ErrPaymentState)
+			}
+			return err
+		}
+
+		if ref.ReturnId != nil {
+			q = `
+				SELECT
+				    r.status, r.amount
+				FROM
+				    returns r
+				WHERE
+				    r.id = $1 AND r.order_id = $2
+				FOR UPDATE`
+
+			var status dmodel.ReturnStatus
+			var amount int64
+			if err := tx.QueryRow(ctx, q, *ref.ReturnId, ref.OrderId).Scan(&status, &amount); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return fmt.Errorf("return is not found: %w", apperror.ErrNotFound)
+				}
+				return err
+			}
+			if status != dmodel.ReturnReceived {
+				return fmt.Errorf("%w: return is %s", apperror.ErrReturnState, status)
+			}
+			q = `SELECT EXISTS(SELECT 1 FROM refunds WHERE return_id = $1 AND status = 'pending')`
+			var pending bool
+			if err := tx.QueryRow(ctx, q, *ref.ReturnId).Scan(&pending); err != nil {
+				return err
+			}
+			if pending {
+				return fmt.Errorf("%w: return is being refunded", apperror.ErrReturnState)
+			}
+			if ref.Amount.Amount == 0 {
+				ref.Amount.Amount = amount
+			}
+		}
+
+		refundable := p.CapturedAmount.Amount - p.RefundedAmount.Amount
+		if ref.Amount.Amount == 0 {
+			ref.Amount.Amount = refundable
+		}
+		if ref.Amount.Amount <= 0 || ref.Amount.Amount > refundable {
+			return fmt.Errorf("%w: %d of %s can be refunded", apperror.ErrRefundAmount, refundable, p.Amount.Currency)
+		}
+
+		q = `
+			UPDATE
+			    payments
+			SET
+			    refunded_amount = refunded_amount + $2, updated_at = now()
+			WHERE
+			    id = $1
+			RETURNING
+			    refunded_amount, updated_at`
+
+		if err := tx.QueryRow(ctx, q, p.Id, ref.Amount.Amount).Scan(&p.RefundedAmount.Amount, &p.UpdatedAt); err != nil {
+			return err
+		}
+
+		ref.PaymentId = p.Id
+		ref.Amount.Currency = p.Amount.Currency
+		ref.UserId = actorId(ctx)
+
+		q = `
+			INSERT INTO refunds
+				(id, order_id, payment_id, return_id, amount, currency, status, reason, user_id, created_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+		_, err := tx.Exec(ctx, q, ref.Id, ref.OrderId, ref.PaymentId, ref.ReturnId, ref.Amount.Amount,
+			ref.Amount.Currency, ref.Status, ref.Reason, ref.UserId, ref.CreatedAt)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, p, detErr
+		}
+		return r, p, err
+	}
+	return *ref, p, nil
+}
+
+// CompleteRefund marks the pending refund succeeded and its return refunded
+func (s *paymentStorage) CompleteRefund(ctx context.Context, id, providerRefundId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to complete refund")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			UPDATE
+			    refunds
+			SET
+			    status = 'succeeded', provider_refund_id = $2
+			WHERE
+			    id = $1 AND status = 'pending'
+			RETURNING
+			    return_id`
+
+		var returnId *string
+		if err := tx.QueryRow(ctx, q, id, providerRefundId).Scan(&returnId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if returnId == nil {
+			return nil
+		}
+
+		q = `
+			UPDATE
+			    returns
+			SET
+			    status = 'refunded', updated_at = now()
+			WHERE
+			    id = $1 AND status = 'received'`
+
+		_, err := tx.Exec(ctx, q, *returnId)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// FailRefund marks the pending refund failed and releases its amount reserved in the payment
+func (s *paymentStorage) FailRefund(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to fail refund")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			UPDATE
+			    refunds
+			SET
+			    status = 'failed'
+			WHERE
+			    id = $1 AND status = 'pending'
+			RETURNING
+			    payment_id, amount`
+
+		var paymentId string
+		var amount int64
+		if err := tx.QueryRow(ctx, q, id).Scan(&paymentId, &amount); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+
+		q = `
+			UPDATE
+			    payments
+			SET
+			    refunded_amount = refunded_amount - $2, updated_at = now()
+			WHERE
+			    id = $1`
+
+		_, err := tx.Exec(ctx, q, paymentId, amount)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// FindRefundsByOrderId returns refunds of the order of the user, ErrNotFound if the user has no such order
+func (s *paymentStorage) FindRefundsByOrderId(ctx context.Context, userId, orderId string) (r []dmodel.Refund, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing SQL query to check order user")
+
+	if err = checkOrderUser(ctx, s.db, orderId, userId); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	q := `
+		SELECT
+		    rf.id, rf.order_id, rf.payment_id, rf.return_id, rf.amount, rf.currency, rf.status,
+		    COALESCE(rf.provider_refund_id, ''), rf.reason, rf.user_id, rf.created_at
+		FROM
+		    refunds rf
+		WHERE
+		    rf.order_id = $1
+		ORDER BY
+		    rf.created_at, rf.id`
+
+	s.logger.Trace("executing SQL query to find refunds by order id")
+
+	rows, err := s.db.Query(ctx, q, orderId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.Refund, 0)
+	for rows.Next() {
+		var ref dmodel.Refund
+		err = rows.Scan(&ref.Id, &ref.OrderId, &ref.PaymentId, &ref.ReturnId, &ref.Amount.Amount, &ref.Amount.Currency,
+			&ref.Status, &ref.ProviderRefundId, &ref.Reason, &ref.UserId, &ref.CreatedAt)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, ref)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// lockPaymentOrder locks the order row before its payments, so payment changes of the order are serialized,
+// unlike lockOrder it accepts completed orders
+func lockPaymentOrder(ctx context.Context, tx pgx.Tx, orderId string) error {
+	q := `
+		SELECT
+		    o.id
+		FROM
+		    orders o
+		WHERE
+		    o.id = $1
+		FOR UPDATE`
+
+	var id string
+	if err := tx.QueryRow(ctx, q, orderId).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// scanPayment scans paymentFields into p
+func scanPayment(row pgx.Row, p *dmodel.Payment) error {
+	err := row.Scan(&p.Id, &p.OrderId, &p.Provider, &p.IntentId, &p.Amount.Amount, &p.Amount.Currency, &p.Status,
+		&p.CapturedAmount.Amount, &p.RefundedAmount.Amount, &p.CreatedAt, &p.UpdatedAt)
+	p.CapturedAmount.Currency = p.Amount.Currency
+	p.RefundedAmount.Currency = p.Amount.Currency
+	return err
+}
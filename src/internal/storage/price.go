@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+func (s *productStorage) AddPrice(ctx context.Context, p *dmodel.ProductPrice) (r dmodel.ProductPrice, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to add product price")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		return insertProductPrice(ctx, tx, p)
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return *p, nil
+}
+
+// FindPricesByProductId returns the price history of the product in all currencies if currency is empty
+func (s *productStorage) FindPricesByProductId(ctx context.Context, id, currency string, limit, offset int) (r []dmodel.ProductPrice, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// an open-ended price is shown as ending when the next open-ended price takes effect
+	q := `
+		SELECT
+		    pp.id, pp.product_id, pp.price, pp.currency, pp.effective_from,
+		    COALESCE(pp.effective_to, (
+		        SELECT MIN(n.effective_from)
+		        FROM product_prices n
+		        WHERE n.product_id = pp.product_id AND n.currency = pp.currency
+		            AND n.effective_to IS NULL AND n.effective_from > pp.effective_from
+		    )),
+		    pp.user_id, pp.created_at
+		FROM
+		    product_prices pp
+		WHERE
+		    pp.product_id = $1 AND ($2 = '' OR pp.currency = $2)
+		ORDER BY
+		    pp.currency, pp.effective_from, pp.id
+		LIMIT $3 OFFSET $4`
+
+	s.logger.Trace("executing SQL query to find prices by product id")
+
+	rows, err := s.db.Query(ctx, q, id, currency, limit, offset)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.ProductPrice, 0, limit)
+	for rows.Next() {
+		var p dmodel.ProductPrice
+		err = rows.Scan(&p.Id, &p.ProductId, &p.Price.Amount, &p.Price.Currency, &p.EffectiveFrom, &p.EffectiveTo, &p.UserId, &p.CreatedAt)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, p)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// DeletePrice cancels a scheduled price, prices that have already taken effect stay in the history
+func (s *productStorage) DeletePrice(ctx context.Context, productId string, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    product_prices pp
+		WHERE
+		    pp.id = $1 AND pp.product_id = $2 AND pp.effective_from > now()`
+
+	s.logger.Trace("executing SQL query to delete scheduled product price")
+
+	tag, err := s.db.Exec(ctx, q, id, productId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// insertProductPrice appends p to the price list of the product in tx. The price takes effect immediately
+// if p.EffectiveFrom is zero and is in the base currency if p.Price.Currency is empty.
+func insertProductPrice(ctx context.Context, tx pgx.Tx, p *dmodel.ProductPrice) (err error) {
+	if p.UserId == nil {
+		p.UserId = actorId(ctx)
+	}
+	if p.Price.Currency, err = resolveCurrency(ctx, tx, p.Price.Currency); err != nil {
+		return err
+	}
+
+	var from *time.Time
+	if !p.EffectiveFrom.IsZero() {
+		from = &p.EffectiveFrom
+	}
+
+	q := `
+		INSERT INTO product_prices
+			(product_id, price, currency, effective_from, effective_to, user_id)
+		VALUES
+			($1, $2, $3, COALESCE($4::timestamptz, now()), $5, $6)
+		RETURNING id, effective_from, created_at`
+
+	row := tx.QueryRow(ctx, q, p.ProductId, p.Price.Amount, p.Price.Currency, from, p.EffectiveTo, p.UserId)
+	if err := row.Scan(&p.Id, &p.EffectiveFrom, &p.CreatedAt); err != nil {
+		if postgresql.IsForeignKeyViolation(err) {
+			return apperror.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -28,17 +28,50 @@ func (s *productStorage) Create(ctx context.Context, p *dmodel.Product) (r dmode
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	q := `
-		INSERT INTO products
-			(id, price, quantity, description, tags)
-		VALUES
-			($1, $2, $3, $4, $5)
-		RETURNING id`
+	s.logger.Trace("executing Tx to create product")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		// the product is created out of stock, the initial quantity is received through the stock ledger
+		q := `
+			INSERT INTO products
+				(id, price, quantity, description, tags, weight, length, width, height)
+			VALUES
+				($1, $2, 0, $3, $4, $5, $6, $7, $8)
+			RETURNING id`
+
+		length, width, height := dimensionValues(p.Dimensions)
+		row := tx.QueryRow(ctx, q, p.Id, p.Price.Amount, p.Description, p.Tags, p.Weight, length, width, height)
+		if err := row.Scan(&r.Id); err != nil {
+			return err
+		}
 
-	s.logger.Trace("executing SQL query to create product")
+		// the price of a new product is in the base currency
+		price := &dmodel.ProductPrice{
+			ProductId: p.Id,
+			Price:     dmodel.Money{Amount: p.Price.Amount},
+		}
+		if err := insertProductPrice(ctx, tx, price); err != nil {
+			return err
+		}
+		p.Price.Currency = price.Price.Currency
 
-	row := s.db.QueryRow(ctx, q, p.Id, p.Price, p.Quantity, p.Description, p.Tags)
-	if err = row.Scan(&r.Id); err != nil {
+		err := appendEvents(ctx, tx, dmodel.ProductCreated{ProductId: p.Id, Description: p.Description, Price: p.Price,
+			Tags: p.Tags})
+		if err != nil {
+			return err
+		}
+
+		if p.Quantity == 0 {
+			return nil
+		}
+		return applyStockMovement(ctx, tx, &dmodel.StockMovement{
+			ProductId: p.Id,
+			Kind:      dmodel.StockReceipt,
+			Quantity:  p.Quantity,
+			Reason:    "initial quantity",
+		})
+	})
+	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return r, detErr
 		}
@@ -48,13 +81,24 @@ func (s *productStorage) Create(ctx context.Context, p *dmodel.Product) (r dmode
 	return *p, nil
 }
 
-func (s *productStorage) FindOneById(ctx context.Context, id string) (r dmodel.Product, err error) {
+// FindOneById returns the product with the price in the currency, in the base currency if currency is empty
+func (s *productStorage) FindOneById(ctx context.Context, id, currency string) (r dmodel.Product, err error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	s.logger.Trace("resolving currency of product price")
+
+	if r.Price.Currency, err = resolveCurrency(ctx, s.db, currency); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
 	q := `
 		SELECT
-		    p.id, p.price, p.quantity, p.description, p.tags
+		    p.id, product_price(p.id, $2, now()), p.quantity, p.description, p.tags,
+		    p.weight, p.length, p.width, p.height
 		FROM
 		    products p
 		WHERE
@@ -62,8 +106,10 @@ func (s *productStorage) FindOneById(ctx context.Context, id string) (r dmodel.P
 
 	s.logger.Trace("executing SQL query to find product by id")
 
-	row := s.db.QueryRow(ctx, q, id)
-	if err = row.Scan(&r.Id, &r.Price, &r.Quantity, &r.Description, &r.Tags); err != nil {
+	var length, width, height *int
+	row := s.db.QueryRow(ctx, q, id, r.Price.Currency)
+	err = row.Scan(&r.Id, &r.Price.Amount, &r.Quantity, &r.Description, &r.Tags, &r.Weight, &length, &width, &height)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return r, apperror.ErrNotFound
 		}
@@ -72,24 +118,175 @@ func (s *productStorage) FindOneById(ctx context.Context, id string) (r dmodel.P
 		}
 		return r, err
 	}
+	r.Dimensions = dimensions(length, width, height)
+
+	if r.Availability, err = s.findAvailability(ctx, id); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
 	return r, nil
 }
 
-func (s *productStorage) Update(ctx context.Context, p *dmodel.Product) error {
+// findAvailability returns quantities of the product in warehouses, the default warehouse goes first
+func (s *productStorage) findAvailability(ctx context.Context, id string) (r []dmodel.WarehouseStock, err error) {
+	q := `
+		SELECT
+		    w.id, w.code, w.name, ws.quantity
+		FROM
+		    warehouse_stock ws
+		JOIN warehouses w
+		ON w.id = ws.warehouse_id
+		WHERE
+		    ws.product_id = $1
+		ORDER BY
+		    w.is_default DESC, w.code`
+
+	s.logger.Trace("executing SQL query to find product availability")
+
+	rows, err := s.db.Query(ctx, q, id)
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.WarehouseStock, 0)
+	for rows.Next() {
+		var ws dmodel.WarehouseStock
+		if err = rows.Scan(&ws.WarehouseId, &ws.WarehouseCode, &ws.WarehouseName, &ws.Quantity); err != nil {
+			return r, err
+		}
+		r = append(r, ws)
+	}
+	return r, rows.Err()
+}
+
+func (s *productStorage) FindManyByIds(ctx context.Context, ids []string, currency string) (r []dmodel.Product, err error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	s.logger.Trace("resolving currency of product prices")
+
+	if currency, err = resolveCurrency(ctx, s.db, currency); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
 	q := `
-		UPDATE
-		    products
-		SET
-		    price = $2, quantity = $3, description = $3, tags = $4
+		SELECT
+		    p.id, product_price(p.id, $2, now()), p.quantity, p.description, p.tags,
+		    p.weight, p.length, p.width, p.height
+		FROM
+		    products p
 		WHERE
-		    id = $1`
+		    p.id = ANY($1)`
 
-	s.logger.Trace("executing SQL query to update product")
+	s.logger.Trace("executing SQL query to find products by ids")
 
-	if _, err := s.db.Exec(ctx, q, p.Id, p.Price, p.Quantity, p.Description, p.Tags); err != nil {
+	rows, err := s.db.Query(ctx, q, ids, currency)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.Product, 0, len(ids))
+	for rows.Next() {
+		var p dmodel.Product
+		var length, width, height *int
+		p.Price.Currency = currency
+		err = rows.Scan(&p.Id, &p.Price.Amount, &p.Quantity, &p.Description, &p.Tags, &p.Weight, &length, &width, &height)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		p.Dimensions = dimensions(length, width, height)
+		r = append(r, p)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func (s *productStorage) Update(ctx context.Context, p *dmodel.Product) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to update product")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    p.price
+			FROM
+			    products p
+			WHERE
+			    p.id = $1
+			FOR UPDATE`
+
+		var listPrice int64
+		if err := tx.QueryRow(ctx, q, p.Id).Scan(&listPrice); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+
+		q = `
+			UPDATE
+			    products p
+			SET
+			    price = $2, description = $3, tags = $4, weight = $5, length = $6, width = $7, height = $8
+			WHERE
+			    p.id = $1
+			RETURNING p.quantity, base_currency()`
+
+		var quantity int
+		length, width, height := dimensionValues(p.Dimensions)
+		row := tx.QueryRow(ctx, q, p.Id, p.Price.Amount, p.Description, p.Tags, p.Weight, length, width, height)
+		if err := row.Scan(&quantity, &p.Price.Currency); err != nil {
+			return err
+		}
+
+		// a changed list price takes effect immediately, sending the unchanged list price during a scheduled
+		// sale keeps the sale, prices scheduled for later are kept as well
+		if p.Price.Amount != listPrice {
+			err := insertProductPrice(ctx, tx, &dmodel.ProductPrice{
+				ProductId: p.Id,
+				Price:     dmodel.Money{Amount: p.Price.Amount},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err := appendEvents(ctx, tx, dmodel.ProductUpdated{ProductId: p.Id, Description: p.Description, Price: p.Price,
+			Tags: p.Tags})
+		if err != nil {
+			return err
+		}
+
+		// the quantity is not overwritten, the difference is recorded in the stock ledger
+		if p.Quantity == quantity {
+			return nil
+		}
+		return applyStockMovement(ctx, tx, &dmodel.StockMovement{
+			ProductId: p.Id,
+			Kind:      dmodel.StockAdjustment,
+			Quantity:  p.Quantity - quantity,
+			Reason:    "product update",
+		})
+	})
+	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return detErr
 		}
@@ -98,3 +295,19 @@ func (s *productStorage) Update(ctx context.Context, p *dmodel.Product) error {
 
 	return nil
 }
+
+// dimensions returns the product dimensions from the nullable columns, they are either all set or all null
+func dimensions(length, width, height *int) *dmodel.Dimensions {
+	if length == nil || width == nil || height == nil {
+		return nil
+	}
+	return &dmodel.Dimensions{Length: *length, Width: *width, Height: *height}
+}
+
+// dimensionValues returns the values of the nullable dimension columns
+func dimensionValues(d *dmodel.Dimensions) (length, width, height *int) {
+	if d == nil {
+		return nil, nil, nil
+	}
+	return &d.Length, &d.Width, &d.Height
+}
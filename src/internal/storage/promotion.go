@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// promotionFields are the columns of promotions table p read by scanPromotion
+const promotionFields = `
+	p.id, p.code, p.name, p.kind, p.value, COALESCE(p.currency, ''), COALESCE(p.tag, ''),
+	p.buy_quantity, p.get_quantity, p.min_order_value, p.usage_limit, p.usage_limit_per_user,
+	p.starts_at, p.ends_at, p.created_at`
+
+type promotionStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewPromotionStorage(c postgresql.Client, l *logging.Logger) *promotionStorage {
+	return &promotionStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+func (s *promotionStorage) Create(ctx context.Context, p *dmodel.Promotion) (r dmodel.Promotion, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		INSERT INTO promotions
+			(id, code, name, kind, value, currency, tag, buy_quantity, get_quantity, min_order_value,
+			usage_limit, usage_limit_per_user, starts_at, ends_at, created_at)
+		VALUES
+			($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id`
+
+	s.logger.Trace("executing SQL query to create promotion")
+
+	row := s.db.QueryRow(ctx, q, p.Id, p.Code, p.Name, p.Kind, p.Value, p.Currency, p.Tag, p.BuyQuantity,
+		p.GetQuantity, p.MinOrderValue, p.UsageLimit, p.UsageLimitPerUser, p.StartsAt, p.EndsAt, p.CreatedAt)
+	if err = row.Scan(&r.Id); err != nil {
+		if postgresql.IsUniqueViolation(err) {
+			return r, apperror.ErrAlreadyExists
+		}
+		if postgresql.IsForeignKeyViolation(err) {
+			return r, apperror.ErrUnsupportedCurrency
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	return *p, nil
+}
+
+func (s *promotionStorage) FindAll(ctx context.Context, limit, offset int) (r []dmodel.Promotion, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + promotionFields + `,
+		    (SELECT COUNT(*) FROM orders_promotions op JOIN orders o ON o.id = op.order_id
+		     WHERE op.promotion_id = p.id AND o.completed)
+		FROM
+		    promotions p
+		ORDER BY
+		    p.created_at DESC, p.code
+		LIMIT $1 OFFSET $2`
+
+	s.logger.Trace("executing SQL query to find all promotions")
+
+	rows, err := s.db.Query(ctx, q, limit, offset)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.Promotion, 0, limit)
+	for rows.Next() {
+		var p dmodel.Promotion
+		if err = scanPromotion(rows, &p, &p.Uses); err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, p)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// scanPromotion scans promotionFields into p followed by extra destinations
+func scanPromotion(row pgx.Row, p *dmodel.Promotion, extra ...any) error {
+	dest := []any{
+		&p.Id, &p.Code, &p.Name, &p.Kind, &p.Value, &p.Currency, &p.Tag,
+		&p.BuyQuantity, &p.GetQuantity, &p.MinOrderValue, &p.UsageLimit, &p.UsageLimitPerUser,
+		&p.StartsAt, &p.EndsAt, &p.CreatedAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
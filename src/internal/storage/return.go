@@ -0,0 +1,452 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// returnFields are the columns of returns table r read by scanReturn
+const returnFields = `
+	r.id, r.order_id, r.user_id, r.status, r.reason, r.note, r.amount, r.currency, r.created_at, r.updated_at`
+
+type returnStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewReturnStorage(c postgresql.Client, l *logging.Logger) *returnStorage {
+	return &returnStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// Create creates the return of lines of the checked out order of the return user. The returned quantity of a line
+// can't exceed its quantity minus the quantity in other returns that were not rejected.
+func (s *returnStorage) Create(ctx context.Context, r *dmodel.Return) (res dmodel.Return, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to create return")
+
+	// lines are locked in the order of the primary key
+	sort.Slice(r.Items, func(i, j int) bool { return r.Items[i].ProductId < r.Items[j].ProductId })
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    COALESCE(o.completed, false), o.currency
+			FROM
+			    orders o
+			WHERE
+			    o.id = $1 AND o.user_id = $2
+			FOR UPDATE`
+
+		var completed bool
+		if err := tx.QueryRow(ctx, q, r.OrderId, r.UserId).Scan(&completed, &r.Amount.Currency); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if !completed {
+			return apperror.ErrOrderNotCheckedOut
+		}
+
+		// value is the amount paid for the line
+		q = `
+			SELECT
+			    oc.quantity,
+			    oc.price * oc.quantity - COALESCE(oc.discount, 0)
+			        + CASE WHEN COALESCE(o.prices_include_tax, false) THEN 0 ELSE COALESCE(oc.tax, 0) END,
+			    COALESCE((SELECT SUM(rc.quantity)
+			              FROM returns_content rc
+			              JOIN returns r
+			              ON r.id = rc.return_id
+			              WHERE rc.order_id = oc.order_id AND rc.product_id = oc.product_id
+			                AND r.status <> 'rejected'), 0)
+			FROM
+			    orders_content oc
+			JOIN orders o
+			ON o.id = oc.order_id
+			WHERE
+			    oc.order_id = $1 AND oc.product_id = $2
+			FOR UPDATE OF oc`
+
+		r.Amount.Amount = 0
+		for _, i := range r.Items {
+			var quantity, returned int
+			var value int64
+			if err := tx.QueryRow(ctx, q, r.OrderId, i.ProductId).Scan(&quantity, &value, &returned); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return fmt.Errorf("product %s is not in the order: %w", i.ProductId, apperror.ErrNotFound)
+				}
+				return err
+			}
+			if returned+i.Quantity > quantity {
+				return fmt.Errorf("%w: %d of %d products %s can be returned",
+					apperror.ErrReturnQuantity, quantity-returned, quantity, i.ProductId)
+			}
+			r.Amount.Amount += value * int64(i.Quantity) / int64(quantity)
+		}
+
+		q = `
+			INSERT INTO returns
+				(id, order_id, user_id, status, reason, amount, currency, created_at, updated_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8, $8)`
+
+		_, err := tx.Exec(ctx, q, r.Id, r.OrderId, r.UserId, r.Status, r.Reason, r.Amount.Amount, r.Amount.Currency,
+			r.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		q = `
+			INSERT INTO returns_content
+				(return_id, order_id, product_id, quantity)
+			VALUES
+				($1, $2, $3, $4)`
+
+		for _, i := range r.Items {
+			if _, err = tx.Exec(ctx, q, r.Id, r.OrderId, i.ProductId, i.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return res, detErr
+		}
+		return res, err
+	}
+
+	r.UpdatedAt = r.CreatedAt
+	return *r, nil
+}
+
+func (s *returnStorage) FindOneById(ctx context.Context, id string) (r dmodel.Return, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + returnFields + `
+		FROM
+		    returns r
+		WHERE
+		    r.id = $1`
+
+	s.logger.Trace("executing SQL query to find return by id")
+
+	returns, err := findReturns(ctx, s.db, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	if len(returns) == 0 {
+		return r, apperror.ErrNotFound
+	}
+	return returns[0], nil
+}
+
+// FindAllByOrderId returns returns of the order of the user, ErrNotFound if the user has no such order
+func (s *returnStorage) FindAllByOrderId(ctx context.Context, userId, orderId string) (r []dmodel.Return, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing SQL query to check order user")
+
+	if err = checkOrderUser(ctx, s.db, orderId, userId); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	q := `
+		SELECT` + returnFields + `
+		FROM
+		    returns r
+		WHERE
+		    r.order_id = $1
+		ORDER BY
+		    r.created_at, r.id`
+
+	s.logger.Trace("executing SQL query to find returns by order id")
+
+	r, err = findReturns(ctx, s.db, q, orderId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// FindAll returns returns in the status or in all statuses if it is empty, the oldest first
+func (s *returnStorage) FindAll(ctx context.Context, status string, limit, offset int) (r []dmodel.Return, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + returnFields + `
+		FROM
+		    returns r
+		WHERE
+		    $1 = '' OR r.status = $1
+		ORDER BY
+		    r.created_at, r.id
+		LIMIT $2 OFFSET $3`
+
+	s.logger.Trace("executing SQL query to find all returns")
+
+	r, err = findReturns(ctx, s.db, q, status, limit, offset)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// Review approves or rejects the requested return
+func (s *returnStorage) Review(ctx context.Context, id string, status dmodel.ReturnStatus, note string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to review return")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		if _, err := lockReturn(ctx, tx, id, status); err != nil {
+			return err
+		}
+
+		q := `
+			UPDATE
+			    returns
+			SET
+			    status = $2, note = $3, updated_at = now()
+			WHERE
+			    id = $1`
+
+		_, err := tx.Exec(ctx, q, id, status, note)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// Receive restocks the products of the approved return into the warehouses they were sold from,
+// products without allocation (sold before warehouses were introduced) go to the default warehouse
+func (s *returnStorage) Receive(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to receive return")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    r.order_id
+			FROM
+			    returns r
+			WHERE
+			    r.id = $1`
+
+		var orderId string
+		if err := tx.QueryRow(ctx, q, id).Scan(&orderId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if err := lockPaymentOrder(ctx, tx, orderId); err != nil {
+			return err
+		}
+		if _, err := lockReturn(ctx, tx, id, dmodel.ReturnReceived); err != nil {
+			return err
+		}
+
+		q = `
+			SELECT
+			    rc.product_id, rc.quantity
+			FROM
+			    returns_content rc
+			WHERE
+			    rc.return_id = $1
+			ORDER BY
+			    rc.product_id`
+
+		rows, err := tx.Query(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		items := make([]dmodel.ReturnItem, 0)
+		for rows.Next() {
+			var i dmodel.ReturnItem
+			if err = rows.Scan(&i.ProductId, &i.Quantity); err != nil {
+				rows.Close()
+				return err
+			}
+			items = append(items, i)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		reason := fmt.Sprintf("return %s received", id)
+		for _, i := range items {
+			_, allocated, err := lockLine(ctx, tx, orderId, i.ProductId)
+			if err != nil {
+				return err
+			}
+			released := i.Quantity
+			if released > allocated {
+				released = allocated
+			}
+			if released > 0 {
+				err = releaseStock(ctx, tx, i.ProductId, orderId, released, dmodel.StockReturn, reason)
+				if err != nil {
+					return err
+				}
+			}
+			if i.Quantity > released {
+				err = applyStockMovement(ctx, tx, &dmodel.StockMovement{
+					ProductId: i.ProductId,
+					Kind:      dmodel.StockReturn,
+					Quantity:  i.Quantity - released,
+					Reason:    reason,
+					OrderId:   &orderId,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		q = `
+			UPDATE
+			    returns
+			SET
+			    status = 'received', updated_at = now()
+			WHERE
+			    id = $1`
+
+		_, err = tx.Exec(ctx, q, id)
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// lockReturn locks the return and checks that its status can be changed to next
+func lockReturn(ctx context.Context, tx pgx.Tx, id string, next dmodel.ReturnStatus) (dmodel.Return, error) {
+	q := `
+		SELECT` + returnFields + `
+		FROM
+		    returns r
+		WHERE
+		    r.id = $1
+		FOR UPDATE`
+
+	var r dmodel.Return
+	if err := scanReturn(tx.QueryRow(ctx, q, id), &r); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r, apperror.ErrNotFound
+		}
+		return r, err
+	}
+	if !r.Status.CanBecome(next) {
+		return r, fmt.Errorf("%w: return is %s", apperror.ErrReturnState, r.Status)
+	}
+	return r, nil
+}
+
+// findReturns runs the query selecting returnFields and loads items of the found returns
+func findReturns(ctx context.Context, db querier, q string, args ...any) (r []dmodel.Return, err error) {
+	rows, err := db.Query(ctx, q, args...)
+	if err != nil {
+		return r, err
+	}
+	r = make([]dmodel.Return, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var ret dmodel.Return
+		if err = scanReturn(rows, &ret); err != nil {
+			rows.Close()
+			return r, err
+		}
+		ret.Items = make([]dmodel.ReturnItem, 0)
+		r = append(r, ret)
+		ids = append(ids, ret.Id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	if len(ids) == 0 {
+		return r, nil
+	}
+
+	q = `
+		SELECT
+		    rc.return_id, rc.product_id, rc.quantity
+		FROM
+		    returns_content rc
+		WHERE
+		    rc.return_id = ANY($1)
+		ORDER BY
+		    rc.return_id, rc.product_id`
+
+	rows, err = db.Query(ctx, q, ids)
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+
+	positions := make(map[string]int, len(r))
+	for n, ret := range r {
+		positions[ret.Id] = n
+	}
+	for rows.Next() {
+		var returnId string
+		var i dmodel.ReturnItem
+		if err = rows.Scan(&returnId, &i.ProductId, &i.Quantity); err != nil {
+			return r, err
+		}
+		n := positions[returnId]
+		r[n].Items = append(r[n].Items, i)
+	}
+	return r, rows.Err()
+}
+
+// scanReturn scans returnFields into r
+func scanReturn(row pgx.Row, r *dmodel.Return) error {
+	return row.Scan(&r.Id, &r.OrderId, &r.UserId, &r.Status, &r.Reason, &r.Note, &r.Amount.Amount, &r.Amount.Currency,
+		&r.CreatedAt, &r.UpdatedAt)
+}
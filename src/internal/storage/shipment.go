@@ -0,0 +1,425 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// shipmentFields are the columns of shipments table sh read by scanShipment
+const shipmentFields = `
+	sh.id, sh.order_id, sh.status, sh.carrier, sh.tracking_number, sh.user_id, sh.shipped_at, sh.delivered_at`
+
+type shipmentStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewShipmentStorage(c postgresql.Client, l *logging.Logger) *shipmentStorage {
+	return &shipmentStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// Create creates the shipment of lines of the paid order and updates the fulfillment status of the order.
+// The shipped quantity of a line can't exceed its quantity minus the quantity in other shipments.
+func (s *shipmentStorage) Create(ctx context.Context, sh *dmodel.Shipment) (r dmodel.Shipment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to create shipment")
+
+	sort.Slice(sh.Items, func(i, j int) bool { return sh.Items[i].ProductId < sh.Items[j].ProductId })
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		// orders with nothing to pay are shipped without payment
+		q := `
+			SELECT
+			    COALESCE(o.completed, false), o.paid_at IS NOT NULL OR COALESCE(o.total, 0) = 0
+			FROM
+			    orders o
+			WHERE
+			    o.id = $1
+			FOR UPDATE`
+
+		var completed, paid bool
+		if err := tx.QueryRow(ctx, q, sh.OrderId).Scan(&completed, &paid); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		if !completed {
+			return apperror.ErrOrderNotCheckedOut
+		}
+		if !paid {
+			return apperror.ErrOrderNotPaid
+		}
+
+		q = `
+			SELECT
+			    oc.quantity,
+			    COALESCE((SELECT SUM(sc.quantity)
+			              FROM shipments_content sc
+			              WHERE sc.order_id = oc.order_id AND sc.product_id = oc.product_id), 0)
+			FROM
+			    orders_content oc
+			WHERE
+			    oc.order_id = $1 AND oc.product_id = $2`
+
+		for _, i := range sh.Items {
+			var quantity, shipped int
+			if err := tx.QueryRow(ctx, q, sh.OrderId, i.ProductId).Scan(&quantity, &shipped); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return fmt.Errorf("product %s is not in the order: %w", i.ProductId, apperror.ErrNotFound)
+				}
+				return err
+			}
+			if shipped+i.Quantity > quantity {
+				return fmt.Errorf("%w: %d of %d products %s can be shipped",
+					apperror.ErrShipmentQuantity, quantity-shipped, quantity, i.ProductId)
+			}
+		}
+
+		q = `
+			INSERT INTO shipments
+				(id, order_id, status, carrier, tracking_number, user_id, shipped_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7)`
+
+		_, err := tx.Exec(ctx, q, sh.Id, sh.OrderId, sh.Status, sh.Carrier, sh.TrackingNumber, sh.UserId, sh.ShippedAt)
+		if err != nil {
+			return err
+		}
+
+		q = `
+			INSERT INTO shipments_content
+				(shipment_id, order_id, product_id, quantity)
+			VALUES
+				($1, $2, $3, $4)`
+
+		for _, i := range sh.Items {
+			if _, err = tx.Exec(ctx, q, sh.Id, sh.OrderId, i.ProductId, i.Quantity); err != nil {
+				return err
+			}
+		}
+		return updateFulfillmentStatus(ctx, tx, sh.OrderId)
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	return *sh, nil
+}
+
+func (s *shipmentStorage) FindOneById(ctx context.Context, id string) (r dmodel.Shipment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + shipmentFields + `
+		FROM
+		    shipments sh
+		WHERE
+		    sh.id = $1`
+
+	s.logger.Trace("executing SQL query to find shipment by id")
+
+	shipments, err := findShipments(ctx, s.db, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	if len(shipments) == 0 {
+		return r, apperror.ErrNotFound
+	}
+	return shipments[0], nil
+}
+
+func (s *shipmentStorage) FindAllByOrderId(ctx context.Context, orderId string) (r []dmodel.Shipment, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + shipmentFields + `
+		FROM
+		    shipments sh
+		WHERE
+		    sh.order_id = $1
+		ORDER BY
+		    sh.shipped_at, sh.id`
+
+	s.logger.Trace("executing SQL query to find shipments by order id")
+
+	r, err = findShipments(ctx, s.db, q, orderId)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// Deliver marks the shipped shipment delivered at the moment and updates the fulfillment status of the order
+func (s *shipmentStorage) Deliver(ctx context.Context, id string, deliveredAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to deliver shipment")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			SELECT
+			    sh.order_id
+			FROM
+			    shipments sh
+			WHERE
+			    sh.id = $1`
+
+		var orderId string
+		if err := tx.QueryRow(ctx, q, id).Scan(&orderId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+		// the order is locked before its shipments like in Create
+		if err := lockPaymentOrder(ctx, tx, orderId); err != nil {
+			return err
+		}
+
+		q = `
+			SELECT
+			    sh.status
+			FROM
+			    shipments sh
+			WHERE
+			    sh.id = $1
+			FOR UPDATE`
+
+		var status dmodel.ShipmentStatus
+		if err := tx.QueryRow(ctx, q, id).Scan(&status); err != nil {
+			return err
+		}
+		if status != dmodel.ShipmentShipped {
+			return fmt.Errorf("%w: shipment is %s", apperror.ErrShipmentState, status)
+		}
+
+		q = `
+			UPDATE
+			    shipments
+			SET
+			    status = 'delivered', delivered_at = $2
+			WHERE
+			    id = $1`
+
+		if _, err := tx.Exec(ctx, q, id, deliveredAt); err != nil {
+			return err
+		}
+		return updateFulfillmentStatus(ctx, tx, orderId)
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// FindUnfulfilled returns paid orders with lines not shipped yet, the earliest checked out first
+func (s *shipmentStorage) FindUnfulfilled(ctx context.Context, limit, offset int) (r []dmodel.FulfillmentOrder, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    o.id, o.number, o.fulfillment_status, COALESCE(o.shipping_method, ''), o.checked_out_at, o.paid_at
+		FROM
+		    orders o
+		WHERE
+		    o.completed AND o.fulfillment_status IN ('unfulfilled', 'partially_shipped')
+		    AND (o.paid_at IS NOT NULL OR COALESCE(o.total, 0) = 0)
+		ORDER BY
+		    o.checked_out_at, o.id
+		LIMIT $1 OFFSET $2`
+
+	s.logger.Trace("executing SQL query to find unfulfilled orders")
+
+	rows, err := s.db.Query(ctx, q, limit, offset)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	r = make([]dmodel.FulfillmentOrder, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var o dmodel.FulfillmentOrder
+		if err = rows.Scan(&o.OrderId, &o.Number, &o.Status, &o.ShippingMethod, &o.CheckedOutAt, &o.PaidAt); err != nil {
+			rows.Close()
+			return r, err
+		}
+		o.Items = make([]dmodel.FulfillmentItem, 0)
+		r = append(r, o)
+		ids = append(ids, o.OrderId)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	if len(ids) == 0 {
+		return r, nil
+	}
+
+	q = `
+		SELECT
+		    l.order_id, l.product_id, l.description, l.remaining
+		FROM
+		    (SELECT
+		         oc.order_id, oc.product_id, oc.description,
+		         oc.quantity - COALESCE((SELECT SUM(sc.quantity)
+		                                 FROM shipments_content sc
+		                                 WHERE sc.order_id = oc.order_id AND sc.product_id = oc.product_id), 0)
+		             AS remaining
+		     FROM
+		         orders_content oc
+		     WHERE
+		         oc.order_id = ANY($1)) AS l
+		WHERE
+		    l.remaining > 0
+		ORDER BY
+		    l.order_id, l.product_id`
+
+	rows, err = s.db.Query(ctx, q, ids)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	positions := make(map[string]int, len(r))
+	for n, o := range r {
+		positions[o.OrderId] = n
+	}
+	for rows.Next() {
+		var orderId string
+		var i dmodel.FulfillmentItem
+		if err = rows.Scan(&orderId, &i.ProductId, &i.Description, &i.Quantity); err != nil {
+			return r, err
+		}
+		n := positions[orderId]
+		r[n].Items = append(r[n].Items, i)
+	}
+	return r, rows.Err()
+}
+
+// updateFulfillmentStatus sets the fulfillment status of the order from the quantities of its lines
+// and shipments: shipped when nothing is left to ship and delivered when no shipment is on the way
+func updateFulfillmentStatus(ctx context.Context, tx pgx.Tx, orderId string) error {
+	q := `
+		WITH totals AS (
+		    SELECT
+		        (SELECT COALESCE(SUM(oc.quantity), 0) FROM orders_content oc WHERE oc.order_id = $1) AS ordered,
+		        (SELECT COALESCE(SUM(sc.quantity), 0) FROM shipments_content sc WHERE sc.order_id = $1) AS shipped,
+		        (SELECT count(*) FROM shipments sh WHERE sh.order_id = $1 AND sh.status = 'shipped') AS on_the_way
+		)
+		UPDATE
+		    orders o
+		SET
+		    fulfillment_status = CASE
+		        WHEN t.shipped = 0 THEN 'unfulfilled'
+		        WHEN t.shipped < t.ordered THEN 'partially_shipped'
+		        WHEN t.on_the_way > 0 THEN 'shipped'
+		        ELSE 'delivered'
+		    END
+		FROM
+		    totals t
+		WHERE
+		    o.id = $1`
+
+	_, err := tx.Exec(ctx, q, orderId)
+	return err
+}
+
+// findShipments runs the query selecting shipmentFields and loads items of the found shipments
+func findShipments(ctx context.Context, db querier, q string, args ...any) (r []dmodel.Shipment, err error) {
+	rows, err := db.Query(ctx, q, args...)
+	if err != nil {
+		return r, err
+	}
+	r = make([]dmodel.Shipment, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var sh dmodel.Shipment
+		if err = scanShipment(rows, &sh); err != nil {
+			rows.Close()
+			return r, err
+		}
+		sh.Items = make([]dmodel.ShipmentItem, 0)
+		r = append(r, sh)
+		ids = append(ids, sh.Id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	if len(ids) == 0 {
+		return r, nil
+	}
+
+	q = `
+		SELECT
+		    sc.shipment_id, sc.product_id, sc.quantity
+		FROM
+		    shipments_content sc
+		WHERE
+		    sc.shipment_id = ANY($1)
+		ORDER BY
+		    sc.shipment_id, sc.product_id`
+
+	rows, err = db.Query(ctx, q, ids)
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+
+	positions := make(map[string]int, len(r))
+	for n, sh := range r {
+		positions[sh.Id] = n
+	}
+	for rows.Next() {
+		var shipmentId string
+		var i dmodel.ShipmentItem
+		if err = rows.Scan(&shipmentId, &i.ProductId, &i.Quantity); err != nil {
+			return r, err
+		}
+		n := positions[shipmentId]
+		r[n].Items = append(r[n].Items, i)
+	}
+	return r, rows.Err()
+}
+
+// scanShipment scans shipmentFields into sh
+func scanShipment(row pgx.Row, sh *dmodel.Shipment) error {
+	return row.Scan(&sh.Id, &sh.OrderId, &sh.Status, &sh.Carrier, &sh.TrackingNumber, &sh.UserId, &sh.ShippedAt,
+		&sh.DeliveredAt)
+}
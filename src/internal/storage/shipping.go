@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+type shippingStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewShippingStorage(c postgresql.Client, l *logging.Logger) *shippingStorage {
+	return &shippingStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+// Create creates the shipping method with its tiers, the amounts are in the base currency
+func (s *shippingStorage) Create(ctx context.Context, m *dmodel.ShippingMethod) (r dmodel.ShippingMethod, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to create shipping method")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			INSERT INTO shipping_methods
+				(id, code, name, kind, price, free_over, regions, created_at)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING base_currency()`
+
+		var freeOver *int64
+		if m.FreeOver != nil {
+			freeOver = &m.FreeOver.Amount
+		}
+		row := tx.QueryRow(ctx, q, m.Id, m.Code, m.Name, m.Kind, m.Price.Amount, freeOver, m.Regions, m.CreatedAt)
+		if err := row.Scan(&m.Price.Currency); err != nil {
+			return err
+		}
+
+		q = `
+			INSERT INTO shipping_method_tiers
+				(method_id, up_to, price)
+			VALUES
+				($1, $2, $3)`
+
+		for k := range m.Tiers {
+			t := &m.Tiers[k]
+			if _, err := tx.Exec(ctx, q, m.Id, t.UpTo, t.Price.Amount); err != nil {
+				return err
+			}
+			t.Price.Currency = m.Price.Currency
+		}
+		if m.FreeOver != nil {
+			m.FreeOver.Currency = m.Price.Currency
+		}
+		return nil
+	})
+	if err != nil {
+		if postgresql.IsUniqueViolation(err) {
+			return r, apperror.ErrAlreadyExists
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	return *m, nil
+}
+
+// FindAll returns all shipping methods with the amounts in the currency, in the base currency if currency is empty
+func (s *shippingStorage) FindAll(ctx context.Context, currency string) (r []dmodel.ShippingMethod, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing SQL query to find all shipping methods")
+
+	r, err = findShippingMethods(ctx, s.db, currency, nil)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// FindOneById returns the shipping method with the amounts in the currency, in the base currency if currency is empty
+func (s *shippingStorage) FindOneById(ctx context.Context, id, currency string) (r dmodel.ShippingMethod, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing SQL query to find shipping method by id")
+
+	methods, err := findShippingMethods(ctx, s.db, currency, &id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	if len(methods) == 0 {
+		return r, apperror.ErrNotFound
+	}
+	return methods[0], nil
+}
+
+// findShippingMethods returns the shipping method with the id or all methods if id is nil,
+// the amounts are converted from the base currency to the currency
+func findShippingMethods(ctx context.Context, db querier, currency string, id *string) (r []dmodel.ShippingMethod, err error) {
+	if currency, err = resolveCurrency(ctx, db, currency); err != nil {
+		return r, err
+	}
+
+	q := `
+		SELECT
+		    m.id, m.code, m.name, m.kind, convert_amount(m.price, base_currency(), $1),
+		    convert_amount(m.free_over, base_currency(), $1), m.regions, m.created_at
+		FROM
+		    shipping_methods m
+		WHERE
+		    $2::uuid IS NULL OR m.id = $2
+		ORDER BY
+		    m.code`
+
+	rows, err := db.Query(ctx, q, currency, id)
+	if err != nil {
+		return r, err
+	}
+	r = make([]dmodel.ShippingMethod, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var m dmodel.ShippingMethod
+		var freeOver *int64
+		err = rows.Scan(&m.Id, &m.Code, &m.Name, &m.Kind, &m.Price.Amount, &freeOver, &m.Regions, &m.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return r, err
+		}
+		m.Price.Currency = currency
+		if freeOver != nil {
+			m.FreeOver = &dmodel.Money{Amount: *freeOver, Currency: currency}
+		}
+		m.Tiers = make([]dmodel.ShippingTier, 0)
+		r = append(r, m)
+		ids = append(ids, m.Id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	if len(r) == 0 {
+		return r, nil
+	}
+
+	q = `
+		SELECT
+		    t.method_id, t.up_to, convert_amount(t.price, base_currency(), $2)
+		FROM
+		    shipping_method_tiers t
+		WHERE
+		    t.method_id = ANY($1)
+		ORDER BY
+		    t.method_id, t.up_to`
+
+	rows, err = db.Query(ctx, q, ids, currency)
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+
+	methods := make(map[string]*dmodel.ShippingMethod, len(r))
+	for k := range r {
+		methods[r[k].Id] = &r[k]
+	}
+	for rows.Next() {
+		var methodId string
+		t := dmodel.ShippingTier{Price: dmodel.Money{Currency: currency}}
+		if err = rows.Scan(&methodId, &t.UpTo, &t.Price.Amount); err != nil {
+			return r, err
+		}
+		m := methods[methodId]
+		m.Tiers = append(m.Tiers, t)
+	}
+	return r, rows.Err()
+}
+
+func (s *shippingStorage) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    shipping_methods m
+		WHERE
+		    m.id = $1`
+
+	s.logger.Trace("executing SQL query to delete shipping method")
+
+	tag, err := s.db.Exec(ctx, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,478 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+type stockStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewStockStorage(c postgresql.Client, l *logging.Logger) *stockStorage {
+	return &stockStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+func (s *stockStorage) AddMovement(ctx context.Context, m *dmodel.StockMovement) (r dmodel.StockMovement, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to add stock movement")
+
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		return applyStockMovement(ctx, tx, m)
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return *m, nil
+}
+
+func (s *stockStorage) Transfer(ctx context.Context, t *dmodel.WarehouseTransfer) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to transfer stock between warehouses")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		err := applyStockMovement(ctx, tx, &dmodel.StockMovement{
+			ProductId:   t.ProductId,
+			WarehouseId: t.FromWarehouseId,
+			Kind:        dmodel.StockTransfer,
+			Quantity:    -t.Quantity,
+			Reason:      t.Reason,
+			UserId:      &t.UserId,
+		})
+		if err != nil {
+			return err
+		}
+		return applyStockMovement(ctx, tx, &dmodel.StockMovement{
+			ProductId:   t.ProductId,
+			WarehouseId: t.ToWarehouseId,
+			Kind:        dmodel.StockTransfer,
+			Quantity:    t.Quantity,
+			Reason:      t.Reason,
+			UserId:      &t.UserId,
+		})
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *stockStorage) FindMovementsByProductId(ctx context.Context, id string, limit, offset int) (r []dmodel.StockMovement, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    sm.id, sm.product_id, COALESCE(sm.warehouse_id::text, ''), sm.kind, sm.quantity, sm.reason, sm.order_id, sm.user_id, sm.created_at
+		FROM
+		    stock_movements sm
+		WHERE
+		    sm.product_id = $1
+		ORDER BY
+		    sm.id
+		LIMIT $2 OFFSET $3`
+
+	s.logger.Trace("executing SQL query to find stock movements by product id")
+
+	rows, err := s.db.Query(ctx, q, id, limit, offset)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.StockMovement, 0, limit)
+	for rows.Next() {
+		var m dmodel.StockMovement
+		err = rows.Scan(&m.Id, &m.ProductId, &m.WarehouseId, &m.Kind, &m.Quantity, &m.Reason, &m.OrderId, &m.UserId, &m.CreatedAt)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, m)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func (s *stockStorage) FindDrifts(ctx context.Context, all bool) (r []dmodel.StockDrift, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    p.id, p.description, p.quantity,
+		    COALESCE(l.quantity, 0) AS ledger_quantity, COALESCE(w.quantity, 0) AS warehouse_quantity
+		FROM
+		    products p
+		LEFT JOIN
+		    (SELECT sm.product_id, SUM(sm.quantity) AS quantity
+		    FROM stock_movements sm
+		    GROUP BY sm.product_id) AS l
+		ON p.id = l.product_id
+		LEFT JOIN
+		    (SELECT ws.product_id, SUM(ws.quantity) AS quantity
+		    FROM warehouse_stock ws
+		    GROUP BY ws.product_id) AS w
+		ON p.id = w.product_id
+		WHERE
+		    $1 OR p.quantity <> COALESCE(l.quantity, 0) OR p.quantity <> COALESCE(w.quantity, 0)
+		ORDER BY
+		    p.id`
+
+	s.logger.Trace("executing SQL query to reconcile stock with ledger")
+
+	rows, err := s.db.Query(ctx, q, all)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.StockDrift, 0)
+	for rows.Next() {
+		var d dmodel.StockDrift
+		if err = rows.Scan(&d.ProductId, &d.Description, &d.Quantity, &d.LedgerQuantity, &d.WarehouseQuantity); err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		d.Drift = d.Quantity - d.LedgerQuantity
+		r = append(r, d)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// applyStockMovement changes the product quantity in the warehouse and the total product quantity by m.Quantity
+// and appends m to the stock ledger in tx. The default warehouse is used if m.WarehouseId is empty.
+// The conditional update never lets the quantity go below zero, even under concurrent transactions,
+// because the WHERE clause is re-evaluated after the row lock is acquired.
+// Order lines must be locked before calling it to keep the lock order of all transactions the same.
+func applyStockMovement(ctx context.Context, tx pgx.Tx, m *dmodel.StockMovement) error {
+	if m.UserId == nil {
+		m.UserId = actorId(ctx)
+	}
+	if m.WarehouseId == "" {
+		id, err := defaultWarehouseId(ctx, tx)
+		if err != nil {
+			return err
+		}
+		m.WarehouseId = id
+	}
+
+	var q string
+	if m.Quantity >= 0 {
+		q = `
+			INSERT INTO warehouse_stock
+				(warehouse_id, product_id, quantity)
+			VALUES
+				($1, $2, $3)
+			ON CONFLICT (warehouse_id, product_id) DO UPDATE
+			SET
+			    quantity = warehouse_stock.quantity + EXCLUDED.quantity
+			RETURNING product_id`
+	} else {
+		q = `
+			UPDATE
+			    warehouse_stock ws
+			SET
+			    quantity = ws.quantity + $3
+			WHERE
+			    ws.warehouse_id = $1 AND ws.product_id = $2 AND ws.quantity + $3 >= 0
+			RETURNING ws.product_id`
+	}
+
+	var id string
+	row := tx.QueryRow(ctx, q, m.WarehouseId, m.ProductId, m.Quantity)
+	if err := row.Scan(&id); err != nil {
+		if postgresql.IsForeignKeyViolation(err) {
+			return apperror.ErrNotFound
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		// the stock row is missing or short, a missing product or warehouse is not a lack of stock
+		q = `
+			SELECT
+			    EXISTS(SELECT 1 FROM products p WHERE p.id = $1),
+			    EXISTS(SELECT 1 FROM warehouses w WHERE w.id = $2)`
+
+		var productExists, warehouseExists bool
+		if err = tx.QueryRow(ctx, q, m.ProductId, m.WarehouseId).Scan(&productExists, &warehouseExists); err != nil {
+			return err
+		}
+		if !productExists || !warehouseExists {
+			return apperror.ErrNotFound
+		}
+		return apperror.ErrInsufficientStock
+	}
+
+	q = `
+		UPDATE
+		    products p
+		SET
+		    quantity = p.quantity + $2
+		WHERE
+		    p.id = $1
+		RETURNING p.quantity`
+
+	var quantity int
+	if err := tx.QueryRow(ctx, q, m.ProductId, m.Quantity).Scan(&quantity); err != nil {
+		return err
+	}
+
+	q = `
+		INSERT INTO stock_movements
+			(product_id, warehouse_id, kind, quantity, reason, order_id, user_id)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	row = tx.QueryRow(ctx, q, m.ProductId, m.WarehouseId, m.Kind, m.Quantity, m.Reason, m.OrderId, m.UserId)
+	if err := row.Scan(&m.Id, &m.CreatedAt); err != nil {
+		return err
+	}
+	return appendEvents(ctx, tx, dmodel.ProductStockChanged{
+		ProductId:   m.ProductId,
+		WarehouseId: m.WarehouseId,
+		Kind:        m.Kind,
+		Change:      m.Quantity,
+		Quantity:    quantity,
+		OrderId:     m.OrderId,
+	})
+}
+
+// allocateStock takes quantity of the product for the order line from warehouses.
+// The default warehouse is preferred, then warehouses with the highest stock.
+func allocateStock(ctx context.Context, tx pgx.Tx, productId, orderId string, quantity int) error {
+	// rows are locked in the order of the primary key, the allocation order is applied afterwards
+	q := `
+		SELECT
+		    ws.warehouse_id, ws.quantity, w.is_default
+		FROM
+		    warehouse_stock ws
+		JOIN warehouses w
+		ON w.id = ws.warehouse_id
+		WHERE
+		    ws.product_id = $1 AND ws.quantity > 0
+		ORDER BY
+		    ws.warehouse_id
+		FOR UPDATE OF ws`
+
+	rows, err := tx.Query(ctx, q, productId)
+	if err != nil {
+		return err
+	}
+
+	type candidate struct {
+		warehouseId string
+		quantity    int
+		isDefault   bool
+	}
+	candidates := make([]candidate, 0)
+	available := 0
+	for rows.Next() {
+		var c candidate
+		if err = rows.Scan(&c.warehouseId, &c.quantity, &c.isDefault); err != nil {
+			rows.Close()
+			return err
+		}
+		candidates = append(candidates, c)
+		available += c.quantity
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if available < quantity {
+		return apperror.ErrInsufficientStock
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].isDefault != candidates[j].isDefault {
+			return candidates[i].isDefault
+		}
+		return candidates[i].quantity > candidates[j].quantity
+	})
+
+	for _, c := range candidates {
+		if quantity == 0 {
+			break
+		}
+		n := c.quantity
+		if n > quantity {
+			n = quantity
+		}
+		quantity -= n
+
+		err = applyStockMovement(ctx, tx, &dmodel.StockMovement{
+			ProductId:   productId,
+			WarehouseId: c.warehouseId,
+			Kind:        dmodel.StockSale,
+			Quantity:    -n,
+			OrderId:     &orderId,
+		})
+		if err != nil {
+			return err
+		}
+
+		q = `
+			INSERT INTO orders_content_allocations
+				(order_id, product_id, warehouse_id, quantity)
+			VALUES
+				($1, $2, $3, $4)
+			ON CONFLICT (order_id, product_id, warehouse_id) DO UPDATE
+			SET
+			    quantity = orders_content_allocations.quantity + EXCLUDED.quantity`
+
+		if _, err = tx.Exec(ctx, q, orderId, productId, c.warehouseId, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseStock returns quantity of the product of the order line to the warehouses it was allocated from.
+// Allocations from other warehouses are released before the default one.
+func releaseStock(ctx context.Context, tx pgx.Tx, productId, orderId string, quantity int, kind dmodel.StockMovementKind, reason string) error {
+	q := `
+		SELECT
+		    a.warehouse_id, a.quantity
+		FROM
+		    orders_content_allocations a
+		JOIN warehouses w
+		ON w.id = a.warehouse_id
+		WHERE
+		    a.order_id = $1 AND a.product_id = $2
+		ORDER BY
+		    w.is_default, a.quantity, a.warehouse_id
+		FOR UPDATE OF a`
+
+	rows, err := tx.Query(ctx, q, orderId, productId)
+	if err != nil {
+		return err
+	}
+
+	type allocation struct {
+		warehouseId string
+		quantity    int
+	}
+	allocations := make([]allocation, 0)
+	for rows.Next() {
+		var a allocation
+		if err = rows.Scan(&a.warehouseId, &a.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		allocations = append(allocations, a)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, a := range allocations {
+		if quantity == 0 {
+			break
+		}
+		n := a.quantity
+		if n > quantity {
+			n = quantity
+		}
+		quantity -= n
+
+		if n == a.quantity {
+			q = `
+				DELETE FROM
+				    orders_content_allocations a
+				WHERE
+				    a.order_id = $1 AND a.product_id = $2 AND a.warehouse_id = $3`
+			_, err = tx.Exec(ctx, q, orderId, productId, a.warehouseId)
+		} else {
+			q = `
+				UPDATE
+				    orders_content_allocations a
+				SET
+				    quantity = a.quantity - $4
+				WHERE
+				    a.order_id = $1 AND a.product_id = $2 AND a.warehouse_id = $3`
+			_, err = tx.Exec(ctx, q, orderId, productId, a.warehouseId, n)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = applyStockMovement(ctx, tx, &dmodel.StockMovement{
+			ProductId:   productId,
+			WarehouseId: a.warehouseId,
+			Kind:        kind,
+			Quantity:    n,
+			Reason:      reason,
+			OrderId:     &orderId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if quantity > 0 {
+		return fmt.Errorf("order line (order id %s, product id %s) has %d products without allocation", orderId, productId, quantity)
+	}
+	return nil
+}
+
+func defaultWarehouseId(ctx context.Context, tx pgx.Tx) (id string, err error) {
+	q := `
+		SELECT
+		    w.id
+		FROM
+		    warehouses w
+		WHERE
+		    w.is_default`
+
+	if err = tx.QueryRow(ctx, q).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return id, fmt.Errorf("default warehouse is not set: %w", apperror.ErrNotFound)
+		}
+		return id, err
+	}
+	return id, nil
+}
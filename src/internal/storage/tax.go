@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+type taxStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewTaxStorage(c postgresql.Client, l *logging.Logger) *taxStorage {
+	return &taxStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+func (s *taxStorage) Create(ctx context.Context, t *dmodel.TaxRule) (r dmodel.TaxRule, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		INSERT INTO tax_rules
+			(id, name, rate, tag, region, created_at)
+		VALUES
+			($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)
+		RETURNING id`
+
+	s.logger.Trace("executing SQL query to create tax rule")
+
+	row := s.db.QueryRow(ctx, q, t.Id, t.Name, t.Rate, t.Tag, t.Region, t.CreatedAt)
+	if err = row.Scan(&r.Id); err != nil {
+		if postgresql.IsUniqueViolation(err) {
+			return r, apperror.ErrAlreadyExists
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	return *t, nil
+}
+
+func (s *taxStorage) FindAll(ctx context.Context) (r []dmodel.TaxRule, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    t.id, t.name, t.rate, COALESCE(t.tag, ''), COALESCE(t.region, ''), t.created_at
+		FROM
+		    tax_rules t
+		ORDER BY
+		    t.region NULLS FIRST, t.tag NULLS FIRST`
+
+	s.logger.Trace("executing SQL query to find all tax rules")
+
+	rows, err := s.db.Query(ctx, q)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.TaxRule, 0)
+	for rows.Next() {
+		var t dmodel.TaxRule
+		if err = rows.Scan(&t.Id, &t.Name, &t.Rate, &t.Tag, &t.Region, &t.CreatedAt); err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, t)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func (s *taxStorage) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    tax_rules t
+		WHERE
+		    t.id = $1`
+
+	s.logger.Trace("executing SQL query to delete tax rule")
+
+	tag, err := s.db.Exec(ctx, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
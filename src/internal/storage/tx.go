@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/actor"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// txMaxAttempts is the number of attempts to run a transaction that failed due to a deadlock
+const txMaxAttempts = 5
+
+// runInTx runs fn in a read committed transaction retried on deadlocks. Transactions don't rely
+// on the isolation level for consistency, they lock the rows they read with FOR UPDATE, use
+// conditional updates or take advisory locks, so they never fail on serialization.
+// The user performing the request is available to triggers as the app.user_id setting.
+func runInTx(ctx context.Context, db postgresql.Client, fn func(tx pgx.Tx) error) error {
+	return postgresql.RunInTx(ctx, db, pgx.TxOptions{}, txMaxAttempts, func(tx pgx.Tx) error {
+		if userId := actor.UserIdFromContext(ctx); userId != "" {
+			if _, err := tx.Exec(ctx, "SELECT set_config('app.user_id', $1, true)", userId); err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+}
+
+// actorId returns id of the user performing the request or nil for system actions
+func actorId(ctx context.Context) *string {
+	if userId := actor.UserIdFromContext(ctx); userId != "" {
+		return &userId
+	}
+	return nil
+}
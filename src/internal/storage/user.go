@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,17 +31,27 @@ func (s *userStorage) Create(ctx context.Context, u *dmodel.User) (r dmodel.User
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	q := `
-		INSERT INTO users
-			(id, firstname, lastname, email, password, age, is_married)
-		VALUES
-			($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id`
+	s.logger.Trace("executing Tx to create user")
 
-	s.logger.Trace("executing SQL query to create user")
+	err = runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			INSERT INTO users
+				(id, firstname, lastname, email, password, age, is_married, locale, is_admin)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id`
 
-	row := s.db.QueryRow(ctx, q, u.Id, u.FirstName, u.LastName, u.Email, u.Password, u.Age, u.IsMarried)
-	if err = row.Scan(&r.Id); err != nil {
+		row := tx.QueryRow(ctx, q, u.Id, u.FirstName, u.LastName, u.Email, u.Password, u.Age, u.IsMarried, u.Locale,
+			u.IsAdmin)
+		if err := row.Scan(&r.Id); err != nil {
+			return err
+		}
+		return appendEvents(ctx, tx, dmodel.UserCreated{UserId: u.Id, Email: u.Email})
+	})
+	if err != nil {
+		if postgresql.IsUniqueViolation(err) {
+			return r, apperror.ErrAlreadyExists
+		}
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return r, detErr
 		}
@@ -56,7 +67,7 @@ func (s *userStorage) FindOneByEmail(ctx context.Context, email string) (r dmode
 
 	q := `
 		SELECT
-		    u.id, u.firstname, u.lastname, u.email, u.password, u.age, u.is_married
+		    u.id, u.firstname, u.lastname, u.email, u.password, u.age, u.is_married, u.locale, u.is_admin
 		FROM
 		    users u
 		WHERE
@@ -65,7 +76,8 @@ func (s *userStorage) FindOneByEmail(ctx context.Context, email string) (r dmode
 	s.logger.Trace("executing SQL query to find user by email")
 
 	row := s.db.QueryRow(ctx, q, email)
-	if err = row.Scan(&r.Id, &r.FirstName, &r.LastName, &r.Email, &r.Password, &r.Age, &r.IsMarried); err != nil {
+	if err = row.Scan(&r.Id, &r.FirstName, &r.LastName, &r.Email, &r.Password, &r.Age, &r.IsMarried, &r.Locale,
+		&r.IsAdmin); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return r, apperror.ErrNotFound
 		}
@@ -83,7 +95,7 @@ func (s *userStorage) FindOneById(ctx context.Context, id string) (r dmodel.User
 
 	q := `
 		SELECT
-		    u.id, u.firstname, u.lastname, u.email, u.password, u.age, u.is_married
+		    u.id, u.firstname, u.lastname, u.email, u.password, u.age, u.is_married, u.locale, u.is_admin
 		FROM
 		    users u
 		WHERE
@@ -92,7 +104,8 @@ func (s *userStorage) FindOneById(ctx context.Context, id string) (r dmodel.User
 	s.logger.Trace("executing SQL query to find user by id")
 
 	row := s.db.QueryRow(ctx, q, id)
-	if err = row.Scan(&r.Id, &r.FirstName, &r.LastName, &r.Email, &r.Password, &r.Age, &r.IsMarried); err != nil {
+	if err = row.Scan(&r.Id, &r.FirstName, &r.LastName, &r.Email, &r.Password, &r.Age, &r.IsMarried, &r.Locale,
+		&r.IsAdmin); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return r, apperror.ErrNotFound
 		}
@@ -104,6 +117,49 @@ func (s *userStorage) FindOneById(ctx context.Context, id string) (r dmodel.User
 	return r, nil
 }
 
+// FindManyByIds returns the users with the ids, ids of missing users are skipped
+func (s *userStorage) FindManyByIds(ctx context.Context, ids []string) (r []dmodel.User, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    u.id, u.firstname, u.lastname, u.email, u.password, u.age, u.is_married, u.locale, u.is_admin
+		FROM
+		    users u
+		WHERE
+		    u.id = ANY($1)`
+
+	s.logger.Trace("executing SQL query to find users by ids")
+
+	rows, err := s.db.Query(ctx, q, ids)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.User, 0, len(ids))
+	for rows.Next() {
+		var u dmodel.User
+		err = rows.Scan(&u.Id, &u.FirstName, &u.LastName, &u.Email, &u.Password, &u.Age, &u.IsMarried, &u.Locale,
+			&u.IsAdmin)
+		if err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, u)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
 func (s *userStorage) Update(ctx context.Context, id string, chFields map[string]string) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -111,12 +167,15 @@ func (s *userStorage) Update(ctx context.Context, id string, chFields map[string
 	fields := make([]string, 0)
 	params := make([]interface{}, 0)
 	paramNum := 1
+	changed := make([]string, 0, len(chFields))
 
 	for k, v := range chFields {
 		fields = append(fields, fmt.Sprintf("%s=$%d", k, paramNum))
 		params = append(params, v)
 		paramNum++
+		changed = append(changed, k)
 	}
+	sort.Strings(changed)
 
 	fieldsToSet := strings.Join(fields, ", ")
 
@@ -131,10 +190,55 @@ func (s *userStorage) Update(ctx context.Context, id string, chFields map[string
 	q = fmt.Sprintf(q, fieldsToSet, paramNum)
 
 	params = append(params, id)
-	s.logger.Trace("executing SQL query to update user")
+	s.logger.Trace("executing Tx to update user")
 	s.logger.Tracef("params: %s", params)
 
-	if _, err := s.db.Exec(ctx, q, params...); err != nil {
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, q, params...)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		return appendEvents(ctx, tx, dmodel.UserUpdated{UserId: id, Fields: changed})
+	})
+	if err != nil {
+		if postgresql.IsUniqueViolation(err) {
+			return apperror.ErrAlreadyExists
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetAdmin grants the admin routes to the user
+func (s *userStorage) SetAdmin(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    users u
+		SET
+		    is_admin = true
+		WHERE
+		    u.id = $1`
+
+	s.logger.Trace("executing Tx to set user admin")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return apperror.ErrNotFound
+		}
+		return appendEvents(ctx, tx, dmodel.UserUpdated{UserId: id, Fields: []string{"is_admin"}})
+	})
+	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return detErr
 		}
@@ -154,9 +258,16 @@ func (s *userStorage) Delete(ctx context.Context, id string) error {
 		WHERE
 		    u.id = $1`
 
-	s.logger.Trace("executing SQL query to delete user")
+	s.logger.Trace("executing Tx to delete user")
 
-	if _, err := s.db.Exec(ctx, q, id); err != nil {
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, q, id)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		return appendEvents(ctx, tx, dmodel.UserDeleted{UserId: id})
+	})
+	if err != nil {
 		if detErr := postgresql.DetailedPgError(err); detErr != nil {
 			return detErr
 		}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+type warehouseStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewWarehouseStorage(c postgresql.Client, l *logging.Logger) *warehouseStorage {
+	return &warehouseStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+func (s *warehouseStorage) Create(ctx context.Context, w *dmodel.Warehouse) (r dmodel.Warehouse, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		INSERT INTO warehouses
+			(id, code, name, is_default, created_at)
+		VALUES
+			($1, $2, $3, false, $4)
+		RETURNING id`
+
+	s.logger.Trace("executing SQL query to create warehouse")
+
+	row := s.db.QueryRow(ctx, q, w.Id, w.Code, w.Name, w.CreatedAt)
+	if err = row.Scan(&r.Id); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	return *w, nil
+}
+
+func (s *warehouseStorage) FindAll(ctx context.Context) (r []dmodel.Warehouse, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    w.id, w.code, w.name, w.is_default, w.created_at
+		FROM
+		    warehouses w
+		ORDER BY
+		    w.is_default DESC, w.code`
+
+	s.logger.Trace("executing SQL query to find all warehouses")
+
+	rows, err := s.db.Query(ctx, q)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.Warehouse, 0)
+	for rows.Next() {
+		var w dmodel.Warehouse
+		if err = rows.Scan(&w.Id, &w.Code, &w.Name, &w.IsDefault, &w.CreatedAt); err != nil {
+			if detErr := postgresql.DetailedPgError(err); detErr != nil {
+				return r, detErr
+			}
+			return r, err
+		}
+		r = append(r, w)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func (s *warehouseStorage) SetDefault(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to set default warehouse")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			UPDATE
+			    warehouses w
+			SET
+			    is_default = false
+			WHERE
+			    w.is_default AND w.id <> $1`
+
+		if _, err := tx.Exec(ctx, q, id); err != nil {
+			return err
+		}
+
+		q = `
+			UPDATE
+			    warehouses w
+			SET
+			    is_default = true
+			WHERE
+			    w.id = $1`
+
+		tag, err := tx.Exec(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return apperror.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
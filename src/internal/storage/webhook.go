@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/slava-911/test-task-0723/internal/apperror"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/slava-911/test-task-0723/pkg/postgresql"
+)
+
+// webhookDeliveryFields are the columns of webhook_deliveries table wd read by scanWebhookDelivery
+const webhookDeliveryFields = `
+	wd.id, wd.endpoint_id, wd.event_id, wd.event_type, wd.payload, wd.occurred_at, wd.status, wd.attempts,
+	wd.last_error, wd.next_attempt_at, wd.created_at, wd.delivered_at`
+
+type webhookStorage struct {
+	db     postgresql.Client
+	logger *logging.Logger
+}
+
+func NewWebhookStorage(c postgresql.Client, l *logging.Logger) *webhookStorage {
+	return &webhookStorage{
+		db:     c,
+		logger: l,
+	}
+}
+
+func (s *webhookStorage) CreateEndpoint(ctx context.Context, e *dmodel.WebhookEndpoint) (r dmodel.WebhookEndpoint, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		INSERT INTO webhook_endpoints
+			(id, url, secret, event_types, created_at)
+		VALUES
+			($1, $2, $3, $4, $5)`
+
+	s.logger.Trace("executing SQL query to create webhook endpoint")
+
+	if _, err = s.db.Exec(ctx, q, e.Id, e.URL, e.Secret, e.EventTypes, e.CreatedAt); err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	return *e, nil
+}
+
+// FindAllEndpoints returns the endpoints without their secrets, the oldest first
+func (s *webhookStorage) FindAllEndpoints(ctx context.Context) (r []dmodel.WebhookEndpoint, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT
+		    we.id, we.url, we.event_types, we.created_at
+		FROM
+		    webhook_endpoints we
+		ORDER BY
+		    we.created_at, we.id`
+
+	s.logger.Trace("executing SQL query to find all webhook endpoints")
+
+	rows, err := s.db.Query(ctx, q)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.WebhookEndpoint, 0)
+	for rows.Next() {
+		var e dmodel.WebhookEndpoint
+		if err = rows.Scan(&e.Id, &e.URL, &e.EventTypes, &e.CreatedAt); err != nil {
+			return r, err
+		}
+		r = append(r, e)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// DeleteEndpoint deletes the endpoint with its deliveries and their log
+func (s *webhookStorage) DeleteEndpoint(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		DELETE FROM
+		    webhook_endpoints we
+		WHERE
+		    we.id = $1`
+
+	s.logger.Trace("executing SQL query to delete webhook endpoint")
+
+	tag, err := s.db.Exec(ctx, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// FindDeliveries returns deliveries to the endpoint in the status or in all statuses if it is empty,
+// the latest first
+func (s *webhookStorage) FindDeliveries(ctx context.Context, endpointId, status string, limit, offset int) (r []dmodel.WebhookDelivery, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + webhookDeliveryFields + `
+		FROM
+		    webhook_deliveries wd
+		WHERE
+		    wd.endpoint_id = $1 AND ($2 = '' OR wd.status = $2)
+		ORDER BY
+		    wd.id DESC
+		LIMIT $3 OFFSET $4`
+
+	s.logger.Trace("executing SQL query to find webhook deliveries")
+
+	rows, err := s.db.Query(ctx, q, endpointId, status, limit, offset)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.WebhookDelivery, 0)
+	for rows.Next() {
+		var d dmodel.WebhookDelivery
+		if err = scanWebhookDelivery(rows, &d); err != nil {
+			return r, err
+		}
+		r = append(r, d)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// FindDeliveryById returns the delivery with the log of its attempts
+func (s *webhookStorage) FindDeliveryById(ctx context.Context, id int64) (r dmodel.WebhookDelivery, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		SELECT` + webhookDeliveryFields + `
+		FROM
+		    webhook_deliveries wd
+		WHERE
+		    wd.id = $1`
+
+	s.logger.Trace("executing SQL query to find webhook delivery by id")
+
+	if err = scanWebhookDelivery(s.db.QueryRow(ctx, q, id), &r); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r, apperror.ErrNotFound
+		}
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+
+	q = `
+		SELECT
+		    wa.id, wa.delivery_id, wa.attempted_at, wa.status_code, wa.error, wa.duration_ms
+		FROM
+		    webhook_attempts wa
+		WHERE
+		    wa.delivery_id = $1
+		ORDER BY
+		    wa.id`
+
+	s.logger.Trace("executing SQL query to find webhook attempts by delivery id")
+
+	rows, err := s.db.Query(ctx, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r.Log = make([]dmodel.WebhookAttempt, 0)
+	for rows.Next() {
+		var a dmodel.WebhookAttempt
+		if err = rows.Scan(&a.Id, &a.DeliveryId, &a.AttemptedAt, &a.StatusCode, &a.Error, &a.DurationMs); err != nil {
+			return r, err
+		}
+		r.Log = append(r.Log, a)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// Redeliver makes the delivery pending with all attempts available again and due to be sent right away
+func (s *webhookStorage) Redeliver(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		UPDATE
+		    webhook_deliveries wd
+		SET
+		    status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL
+		WHERE
+		    wd.id = $1`
+
+	s.logger.Trace("executing SQL query to redeliver webhook delivery")
+
+	tag, err := s.db.Exec(ctx, q, id)
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDue returns up to limit pending deliveries due to be sent with the URL and the secret of their endpoints
+// and counts the attempt of sending them. The next attempt of a claimed delivery is postponed by lease,
+// so other replicas skip it while it is being sent.
+func (s *webhookStorage) ClaimDue(ctx context.Context, limit int, lease time.Duration) (r []dmodel.WebhookDelivery, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	q := `
+		WITH claimed AS (
+		    UPDATE
+		        webhook_deliveries wd
+		    SET
+		        attempts = wd.attempts + 1,
+		        next_attempt_at = now() + $2 * interval '1 millisecond'
+		    WHERE
+		        wd.id IN (
+		            SELECT
+		                id
+		            FROM
+		                webhook_deliveries
+		            WHERE
+		                status = 'pending' AND next_attempt_at <= now()
+		            ORDER BY
+		                next_attempt_at
+		            LIMIT $1
+		            FOR UPDATE SKIP LOCKED
+		        )
+		    RETURNING wd.*
+		)
+		SELECT` + webhookDeliveryFields + `, we.url, we.secret
+		FROM
+		    claimed wd
+		JOIN webhook_endpoints we
+		ON we.id = wd.endpoint_id
+		ORDER BY
+		    wd.id`
+
+	s.logger.Trace("executing SQL query to claim due webhook deliveries")
+
+	rows, err := s.db.Query(ctx, q, limit, lease.Milliseconds())
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return r, detErr
+		}
+		return r, err
+	}
+	defer rows.Close()
+
+	r = make([]dmodel.WebhookDelivery, 0)
+	for rows.Next() {
+		var d dmodel.WebhookDelivery
+		if err = rows.Scan(&d.Id, &d.EndpointId, &d.EventId, &d.EventType, &d.Payload, &d.OccurredAt, &d.Status,
+			&d.Attempts, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt, &d.URL, &d.Secret); err != nil {
+			return r, err
+		}
+		r = append(r, d)
+	}
+	if err = rows.Err(); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// RecordAttempt logs the attempt of the delivery and moves the delivery to the status,
+// the pending delivery is retried in retryAfter
+func (s *webhookStorage) RecordAttempt(ctx context.Context, a *dmodel.WebhookAttempt, status dmodel.WebhookDeliveryStatus,
+	retryAfter time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	s.logger.Trace("executing Tx to record webhook attempt")
+
+	err := runInTx(ctx, s.db, func(tx pgx.Tx) error {
+		q := `
+			INSERT INTO webhook_attempts
+				(delivery_id, attempted_at, status_code, error, duration_ms)
+			VALUES
+				($1, $2, $3, $4, $5)
+			RETURNING id`
+
+		row := tx.QueryRow(ctx, q, a.DeliveryId, a.AttemptedAt, a.StatusCode, a.Error, a.DurationMs)
+		if err := row.Scan(&a.Id); err != nil {
+			if postgresql.IsForeignKeyViolation(err) {
+				return apperror.ErrNotFound
+			}
+			return err
+		}
+
+		q = `
+			UPDATE
+			    webhook_deliveries wd
+			SET
+			    status = $2,
+			    last_error = $3,
+			    next_attempt_at = CASE WHEN $2 = 'pending' THEN now() + $4 * interval '1 millisecond'
+			                      ELSE wd.next_attempt_at END,
+			    delivered_at = CASE WHEN $2 = 'delivered' THEN now() END
+			WHERE
+			    wd.id = $1`
+
+		_, err := tx.Exec(ctx, q, a.DeliveryId, status, a.Error, retryAfter.Milliseconds())
+		return err
+	})
+	if err != nil {
+		if detErr := postgresql.DetailedPgError(err); detErr != nil {
+			return detErr
+		}
+		return err
+	}
+	return nil
+}
+
+// scanWebhookDelivery scans webhookDeliveryFields into d
+func scanWebhookDelivery(row pgx.Row, d *dmodel.WebhookDelivery) error {
+	return row.Scan(&d.Id, &d.EndpointId, &d.EventId, &d.EventType, &d.Payload, &d.OccurredAt, &d.Status,
+		&d.Attempts, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt)
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	dmodel "github.com/slava-911/test-task-0723/internal/domain/model"
+	"github.com/slava-911/test-task-0723/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test scenario:
+//  1. Register an endpoint subscribed to order.created and create an order with a product
+//  2. Check that the endpoint got one delivery of the order.created event only
+//  3. Fail the delivery for good and check that it is dead with the attempt in its log
+//  4. Redeliver it and check that it is pending with all attempts available again
+func TestWebhookDeliveriesOfSubscribedEvents(t *testing.T) {
+	c := newTestClient(t)
+	s := NewWebhookStorage(c, logging.NewLogger("error"))
+	orders := NewOrderStorage(c, logging.NewLogger("error"))
+
+	endpoint, err := s.CreateEndpoint(context.Background(), &dmodel.WebhookEndpoint{
+		Id:         uuid.New().String(),
+		URL:        "http://localhost:9/webhooks",
+		Secret:     "whsec_test",
+		EventTypes: []string{dmodel.OrderCreated{}.EventType()},
+		CreatedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.DeleteEndpoint(context.Background(), endpoint.Id) })
+
+	p := newTestProduct(t, c, 10)
+	order := newTestOrders(t, orders, 1)[0]
+	require.NoError(t, orders.AddProduct(context.Background(), order.UserId, p.Id, order.Id, 1, time.Now().Add(time.Hour)))
+
+	deliveries, err := s.FindDeliveries(context.Background(), endpoint.Id, "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	d := deliveries[0]
+	assert.Equal(t, "order.created", d.EventType)
+	assert.Equal(t, dmodel.WebhookPending, d.Status)
+
+	status := 500
+	err = s.RecordAttempt(context.Background(), &dmodel.WebhookAttempt{
+		DeliveryId:  d.Id,
+		AttemptedAt: time.Now(),
+		StatusCode:  &status,
+		Error:       "endpoint responded with status 500",
+	}, dmodel.WebhookDead, 0)
+	require.NoError(t, err)
+
+	d, err = s.FindDeliveryById(context.Background(), d.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dmodel.WebhookDead, d.Status)
+	require.Len(t, d.Log, 1)
+	assert.Equal(t, &status, d.Log[0].StatusCode)
+
+	require.NoError(t, s.Redeliver(context.Background(), d.Id))
+	d, err = s.FindDeliveryById(context.Background(), d.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dmodel.WebhookPending, d.Status)
+	assert.Equal(t, 0, d.Attempts)
+}
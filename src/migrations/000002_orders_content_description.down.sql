@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION process_orders_content_history() RETURNS TRIGGER AS $orders_content_history$
+    BEGIN
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO orders_content_history SELECT 'D', now(), OLD.*;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            INSERT INTO orders_content_history SELECT 'U', now(), NEW.*;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO orders_content_history SELECT 'I', now(), NEW.*;
+        END IF;
+        RETURN NULL;
+    END;
+$orders_content_history$ LANGUAGE plpgsql;
+
+ALTER TABLE orders_content_history DROP COLUMN IF EXISTS description;
+ALTER TABLE orders_content DROP COLUMN IF EXISTS description;
+
+END;
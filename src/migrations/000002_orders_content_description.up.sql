@@ -0,0 +1,34 @@
+BEGIN;
+
+ALTER TABLE orders_content ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+UPDATE orders_content oc
+SET description = p.description
+FROM products p
+WHERE p.id = oc.product_id;
+
+ALTER TABLE orders_content_history ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+CREATE OR REPLACE FUNCTION process_orders_content_history() RETURNS TRIGGER AS $orders_content_history$
+    BEGIN
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description)
+            VALUES
+                ('D', now(), OLD.order_id, OLD.product_id, OLD.price, OLD.quantity, OLD.description);
+        ELSIF (TG_OP = 'UPDATE') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description)
+            VALUES
+                ('U', now(), NEW.order_id, NEW.product_id, NEW.price, NEW.quantity, NEW.description);
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description)
+            VALUES
+                ('I', now(), NEW.order_id, NEW.product_id, NEW.price, NEW.quantity, NEW.description);
+        END IF;
+        RETURN NULL;
+    END;
+$orders_content_history$ LANGUAGE plpgsql;
+
+END;
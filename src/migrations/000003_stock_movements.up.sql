@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE stock_movements(
+    id         BIGSERIAL PRIMARY KEY,
+    product_id UUID      NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    kind       TEXT      NOT NULL CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment', 'cancellation')),
+    quantity   BIGINT    NOT NULL,
+    reason     TEXT      NOT NULL DEFAULT '',
+    order_id   UUID,
+    user_id    UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX stock_movements_product_id_idx ON stock_movements (product_id, id);
+
+-- opening balance, so the ledger of existing products matches their current quantity
+INSERT INTO stock_movements
+    (product_id, kind, quantity, reason)
+SELECT
+    p.id, 'adjustment', p.quantity, 'opening balance'
+FROM
+    products p;
+
+END;
@@ -0,0 +1,15 @@
+BEGIN;
+
+DELETE FROM stock_movements WHERE kind = 'transfer';
+
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_kind_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_kind_check
+    CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment', 'cancellation'));
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS warehouse_id;
+
+DROP TABLE IF EXISTS orders_content_allocations CASCADE;
+DROP TABLE IF EXISTS warehouse_stock CASCADE;
+DROP TABLE IF EXISTS warehouses CASCADE;
+
+END;
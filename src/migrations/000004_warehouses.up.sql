@@ -0,0 +1,61 @@
+BEGIN;
+
+CREATE TABLE warehouses(
+    id         UUID PRIMARY KEY,
+    code       TEXT      NOT NULL UNIQUE,
+    name       TEXT      NOT NULL,
+    is_default BOOLEAN   NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- only one warehouse can be the default one
+CREATE UNIQUE INDEX warehouses_is_default_idx ON warehouses (is_default) WHERE is_default;
+
+CREATE TABLE warehouse_stock(
+    warehouse_id UUID   NOT NULL REFERENCES warehouses (id) ON DELETE RESTRICT,
+    product_id   UUID   NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity     BIGINT NOT NULL CHECK (quantity >= 0),
+    PRIMARY KEY (warehouse_id, product_id)
+);
+
+-- warehouses the products of an order line were taken from
+CREATE TABLE orders_content_allocations(
+    order_id     UUID   NOT NULL,
+    product_id   UUID   NOT NULL,
+    warehouse_id UUID   NOT NULL REFERENCES warehouses (id) ON DELETE RESTRICT,
+    quantity     BIGINT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (order_id, product_id, warehouse_id),
+    FOREIGN KEY (order_id, product_id) REFERENCES orders_content (order_id, product_id) ON DELETE CASCADE
+);
+
+ALTER TABLE stock_movements ADD COLUMN warehouse_id UUID REFERENCES warehouses (id) ON DELETE RESTRICT;
+
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_kind_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_kind_check
+    CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment', 'cancellation', 'transfer'));
+
+-- all existing stock is moved to the default warehouse
+INSERT INTO warehouses
+    (id, code, name, is_default)
+VALUES
+    ('00000000-0000-0000-0000-000000000001', 'main', 'Main warehouse', true);
+
+INSERT INTO warehouse_stock
+    (warehouse_id, product_id, quantity)
+SELECT
+    '00000000-0000-0000-0000-000000000001', p.id, p.quantity
+FROM
+    products p;
+
+INSERT INTO orders_content_allocations
+    (order_id, product_id, warehouse_id, quantity)
+SELECT
+    oc.order_id, oc.product_id, '00000000-0000-0000-0000-000000000001', oc.quantity
+FROM
+    orders_content oc
+WHERE
+    oc.quantity > 0;
+
+UPDATE stock_movements SET warehouse_id = '00000000-0000-0000-0000-000000000001';
+
+END;
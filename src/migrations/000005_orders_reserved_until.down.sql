@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS orders_reserved_until_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS reserved_until;
+
+END;
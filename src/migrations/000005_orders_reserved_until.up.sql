@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE orders ADD COLUMN reserved_until TIMESTAMP;
+
+CREATE INDEX orders_reserved_until_idx ON orders (reserved_until) WHERE reserved_until IS NOT NULL;
+
+END;
@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION process_orders_content_history() RETURNS TRIGGER AS $orders_content_history$
+    BEGIN
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description)
+            VALUES
+                ('D', now(), OLD.order_id, OLD.product_id, OLD.price, OLD.quantity, OLD.description);
+        ELSIF (TG_OP = 'UPDATE') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description)
+            VALUES
+                ('U', now(), NEW.order_id, NEW.product_id, NEW.price, NEW.quantity, NEW.description);
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description)
+            VALUES
+                ('I', now(), NEW.order_id, NEW.product_id, NEW.price, NEW.quantity, NEW.description);
+        END IF;
+        RETURN NULL;
+    END;
+$orders_content_history$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS orders_content_history_order_id_idx;
+ALTER TABLE orders_content_history DROP COLUMN IF EXISTS user_id;
+ALTER TABLE orders_content_history DROP COLUMN IF EXISTS id;
+ALTER TABLE orders_content_history ADD PRIMARY KEY (stamp, order_id, product_id);
+
+END;
@@ -0,0 +1,35 @@
+BEGIN;
+
+-- several changes of the same line can happen at the same stamp, history entries get their own id
+ALTER TABLE orders_content_history DROP CONSTRAINT orders_content_history_pkey;
+ALTER TABLE orders_content_history ADD COLUMN id BIGSERIAL PRIMARY KEY;
+ALTER TABLE orders_content_history ADD COLUMN user_id UUID;
+
+CREATE INDEX orders_content_history_order_id_idx ON orders_content_history (order_id, id);
+
+-- the user performing the change is set by the app with set_config('app.user_id', ..., true)
+CREATE OR REPLACE FUNCTION process_orders_content_history() RETURNS TRIGGER AS $orders_content_history$
+    DECLARE
+        actor UUID := NULLIF(current_setting('app.user_id', true), '')::UUID;
+    BEGIN
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description, user_id)
+            VALUES
+                ('D', now(), OLD.order_id, OLD.product_id, OLD.price, OLD.quantity, OLD.description, actor);
+        ELSIF (TG_OP = 'UPDATE') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description, user_id)
+            VALUES
+                ('U', now(), NEW.order_id, NEW.product_id, NEW.price, NEW.quantity, NEW.description, actor);
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO orders_content_history
+                (operation, stamp, order_id, product_id, price, quantity, description, user_id)
+            VALUES
+                ('I', now(), NEW.order_id, NEW.product_id, NEW.price, NEW.quantity, NEW.description, actor);
+        END IF;
+        RETURN NULL;
+    END;
+$orders_content_history$ LANGUAGE plpgsql;
+
+END;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS product_price(UUID, TIMESTAMP);
+DROP TABLE IF EXISTS product_prices CASCADE;
+
+END;
@@ -0,0 +1,40 @@
+BEGIN;
+
+-- a price is valid from effective_from until effective_to, an open-ended price (effective_to IS NULL)
+-- is valid until the next open-ended price takes effect; when several prices are valid at the same time
+-- the one that took effect last wins, so a sale can be scheduled on top of the regular price
+CREATE TABLE product_prices(
+    id             BIGSERIAL PRIMARY KEY,
+    product_id     UUID      NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price          INT       NOT NULL CHECK (price >= 0),
+    effective_from TIMESTAMP NOT NULL,
+    effective_to   TIMESTAMP,
+    user_id        UUID,
+    created_at     TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK (effective_to > effective_from)
+);
+
+CREATE INDEX product_prices_product_id_idx ON product_prices (product_id, effective_from);
+
+-- product_price returns the price of the product effective at the moment or NULL if there is none
+CREATE FUNCTION product_price(product UUID, at TIMESTAMP) RETURNS INT AS $$
+    SELECT
+        pp.price
+    FROM
+        product_prices pp
+    WHERE
+        pp.product_id = product AND pp.effective_from <= at AND (pp.effective_to IS NULL OR pp.effective_to > at)
+    ORDER BY
+        pp.effective_from DESC, pp.id DESC
+    LIMIT 1
+$$ LANGUAGE sql STABLE;
+
+-- current prices of existing products become the first entries of their history
+INSERT INTO product_prices
+    (product_id, price, effective_from)
+SELECT
+    p.id, p.price, now()
+FROM
+    products p;
+
+END;
@@ -0,0 +1,29 @@
+BEGIN;
+
+ALTER TABLE orders_content DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+
+DROP FUNCTION IF EXISTS product_price(UUID, CHAR(3), TIMESTAMP);
+DELETE FROM product_prices WHERE currency <> (SELECT er.currency FROM exchange_rates er WHERE er.is_base);
+ALTER TABLE product_prices DROP COLUMN IF EXISTS currency;
+
+DROP INDEX IF EXISTS product_prices_product_id_idx;
+CREATE INDEX product_prices_product_id_idx ON product_prices (product_id, effective_from);
+
+CREATE FUNCTION product_price(product UUID, at TIMESTAMP) RETURNS INT AS $$
+    SELECT
+        pp.price
+    FROM
+        product_prices pp
+    WHERE
+        pp.product_id = product AND pp.effective_from <= at AND (pp.effective_to IS NULL OR pp.effective_to > at)
+    ORDER BY
+        pp.effective_from DESC, pp.id DESC
+    LIMIT 1
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS convert_amount(BIGINT, CHAR(3), CHAR(3));
+DROP FUNCTION IF EXISTS base_currency();
+DROP TABLE IF EXISTS exchange_rates CASCADE;
+
+END;
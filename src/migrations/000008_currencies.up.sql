@@ -0,0 +1,78 @@
+BEGIN;
+
+-- rate is the number of major units of the currency for one major unit of the base currency,
+-- minor_units is the number of digits after the decimal point, amounts are stored in minor units
+CREATE TABLE exchange_rates(
+    currency    CHAR(3)        PRIMARY KEY CHECK (currency ~ '^[A-Z]{3}$'),
+    rate        NUMERIC(20,10) NOT NULL CHECK (rate > 0),
+    minor_units SMALLINT       NOT NULL DEFAULT 2 CHECK (minor_units BETWEEN 0 AND 4),
+    is_base     BOOLEAN        NOT NULL DEFAULT false,
+    updated_at  TIMESTAMP      NOT NULL DEFAULT now(),
+    CHECK (NOT is_base OR rate = 1)
+);
+
+CREATE UNIQUE INDEX exchange_rates_is_base_idx ON exchange_rates (is_base) WHERE is_base;
+
+-- all existing amounts are in the base currency
+INSERT INTO exchange_rates
+    (currency, rate, minor_units, is_base)
+VALUES
+    ('USD', 1, 2, true);
+
+CREATE FUNCTION base_currency() RETURNS CHAR(3) AS $$
+    SELECT er.currency FROM exchange_rates er WHERE er.is_base
+$$ LANGUAGE sql STABLE;
+
+-- convert_amount converts the amount in minor units between currencies, NULL if a currency has no rate
+CREATE FUNCTION convert_amount(amount BIGINT, from_currency CHAR(3), to_currency CHAR(3)) RETURNS BIGINT AS $$
+    SELECT
+        round(amount * t.rate / f.rate * power(10::NUMERIC, t.minor_units - f.minor_units))::BIGINT
+    FROM
+        exchange_rates f, exchange_rates t
+    WHERE
+        f.currency = from_currency AND t.currency = to_currency
+$$ LANGUAGE sql STABLE;
+
+-- prices are kept in per-currency price lists
+ALTER TABLE product_prices ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' REFERENCES exchange_rates (currency);
+ALTER TABLE product_prices ALTER COLUMN currency DROP DEFAULT;
+
+DROP INDEX product_prices_product_id_idx;
+CREATE INDEX product_prices_product_id_idx ON product_prices (product_id, currency, effective_from);
+
+-- product_price returns the price of the product in the currency effective at the moment:
+-- the price from the price list of the currency if there is one, otherwise the converted base price
+DROP FUNCTION product_price(UUID, TIMESTAMP);
+CREATE FUNCTION product_price(product UUID, cur CHAR(3), at TIMESTAMP) RETURNS BIGINT AS $$
+    SELECT COALESCE(
+        (SELECT
+            pp.price
+        FROM
+            product_prices pp
+        WHERE
+            pp.product_id = product AND pp.currency = cur
+            AND pp.effective_from <= at AND (pp.effective_to IS NULL OR pp.effective_to > at)
+        ORDER BY
+            pp.effective_from DESC, pp.id DESC
+        LIMIT 1),
+        (SELECT
+            convert_amount(pp.price, pp.currency, cur)
+        FROM
+            product_prices pp
+        WHERE
+            pp.product_id = product AND pp.currency = base_currency()
+            AND pp.effective_from <= at AND (pp.effective_to IS NULL OR pp.effective_to > at)
+        ORDER BY
+            pp.effective_from DESC, pp.id DESC
+        LIMIT 1),
+        (SELECT convert_amount(p.price, base_currency(), cur) FROM products p WHERE p.id = product)
+    )
+$$ LANGUAGE sql STABLE;
+
+-- an order is priced in one currency, its lines keep the currency they were priced in
+ALTER TABLE orders ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' REFERENCES exchange_rates (currency);
+ALTER TABLE orders ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE orders_content ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' REFERENCES exchange_rates (currency);
+ALTER TABLE orders_content ALTER COLUMN currency DROP DEFAULT;
+
+END;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS orders_promotions CASCADE;
+DROP TABLE IF EXISTS promotions CASCADE;
+
+END;
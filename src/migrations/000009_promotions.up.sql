@@ -0,0 +1,39 @@
+BEGIN;
+
+-- value is the percentage for percentage promotions and the amount in minor units of currency for fixed ones,
+-- buy_x_get_y gives get_quantity units for free for every buy_quantity + get_quantity units of a line;
+-- the discount is limited to lines of products tagged with tag if it is set
+CREATE TABLE promotions(
+    id                   UUID      PRIMARY KEY,
+    code                 TEXT      NOT NULL UNIQUE,
+    name                 TEXT      NOT NULL,
+    kind                 TEXT      NOT NULL CHECK (kind IN ('percentage', 'fixed', 'buy_x_get_y')),
+    value                BIGINT    NOT NULL DEFAULT 0 CHECK (value >= 0),
+    currency             CHAR(3)   REFERENCES exchange_rates (currency),
+    tag                  TEXT,
+    buy_quantity         INT       NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+    get_quantity         INT       NOT NULL DEFAULT 0 CHECK (get_quantity >= 0),
+    min_order_value      BIGINT    NOT NULL DEFAULT 0 CHECK (min_order_value >= 0),
+    usage_limit          INT       CHECK (usage_limit > 0),
+    usage_limit_per_user INT       CHECK (usage_limit_per_user > 0),
+    starts_at            TIMESTAMP,
+    ends_at              TIMESTAMP,
+    created_at           TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK (kind <> 'percentage' OR value BETWEEN 1 AND 100),
+    CHECK (kind <> 'fixed' OR currency IS NOT NULL),
+    CHECK (min_order_value = 0 OR currency IS NOT NULL),
+    CHECK (kind <> 'buy_x_get_y' OR (buy_quantity > 0 AND get_quantity > 0)),
+    CHECK (ends_at > starts_at)
+);
+
+-- an order has at most one coupon, applied coupons count as uses of the promotion
+CREATE TABLE orders_promotions(
+    order_id     UUID      PRIMARY KEY REFERENCES orders (id) ON DELETE CASCADE,
+    promotion_id UUID      NOT NULL REFERENCES promotions (id) ON DELETE CASCADE,
+    user_id      UUID      NOT NULL,
+    applied_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX orders_promotions_promotion_id_idx ON orders_promotions (promotion_id, user_id);
+
+END;
@@ -0,0 +1,21 @@
+BEGIN;
+
+DROP TRIGGER orders_content_history ON orders_content;
+CREATE TRIGGER orders_content_history
+AFTER INSERT OR UPDATE OR DELETE ON orders_content
+    FOR EACH ROW EXECUTE FUNCTION process_orders_content_history();
+
+ALTER TABLE orders_promotions DROP COLUMN IF EXISTS discount;
+ALTER TABLE orders_content DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE orders_content DROP COLUMN IF EXISTS tax;
+ALTER TABLE orders_content DROP COLUMN IF EXISTS discount;
+ALTER TABLE orders DROP COLUMN IF EXISTS prices_include_tax;
+ALTER TABLE orders DROP COLUMN IF EXISTS total;
+ALTER TABLE orders DROP COLUMN IF EXISTS tax;
+ALTER TABLE orders DROP COLUMN IF EXISTS discount;
+ALTER TABLE orders DROP COLUMN IF EXISTS subtotal;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_region;
+
+DROP TABLE IF EXISTS tax_rules CASCADE;
+
+END;
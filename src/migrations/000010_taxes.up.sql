@@ -0,0 +1,64 @@
+BEGIN;
+
+-- rate is in percent; a rule applies to lines of products tagged with tag and to orders shipped to region
+-- or its subregions (rule region US applies to US-CA), rules without tag or region apply to all of them
+CREATE TABLE tax_rules(
+    id         UUID         PRIMARY KEY,
+    name       TEXT         NOT NULL,
+    rate       NUMERIC(7,4) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    tag        TEXT,
+    region     TEXT,
+    created_at TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX tax_rules_tag_region_idx ON tax_rules (COALESCE(tag, ''), COALESCE(region, ''));
+
+ALTER TABLE orders ADD COLUMN shipping_region TEXT NOT NULL DEFAULT '';
+
+-- amounts are frozen when the order leaves draft and are NULL while it is a draft
+ALTER TABLE orders ADD COLUMN subtotal BIGINT;
+ALTER TABLE orders ADD COLUMN discount BIGINT;
+ALTER TABLE orders ADD COLUMN tax BIGINT;
+ALTER TABLE orders ADD COLUMN total BIGINT;
+ALTER TABLE orders ADD COLUMN prices_include_tax BOOLEAN;
+ALTER TABLE orders_content ADD COLUMN discount BIGINT;
+ALTER TABLE orders_content ADD COLUMN tax BIGINT;
+ALTER TABLE orders_content ADD COLUMN tax_rate NUMERIC(7,4);
+ALTER TABLE orders_promotions ADD COLUMN discount BIGINT;
+
+-- freezing amounts is not a change of the order content
+DROP TRIGGER orders_content_history ON orders_content;
+CREATE TRIGGER orders_content_history
+AFTER INSERT OR DELETE OR UPDATE OF order_id, product_id, price, quantity, description ON orders_content
+    FOR EACH ROW EXECUTE FUNCTION process_orders_content_history();
+
+-- orders completed before taxes were introduced are frozen without tax and discounts
+UPDATE orders_content oc
+SET
+    discount = 0, tax = 0, tax_rate = 0
+FROM
+    orders o
+WHERE
+    o.id = oc.order_id AND o.completed;
+
+UPDATE orders o
+SET
+    subtotal = s.subtotal, discount = 0, tax = 0, total = s.subtotal, prices_include_tax = false
+FROM
+    (SELECT o.id, COALESCE(SUM(oc.price * oc.quantity), 0) AS subtotal
+    FROM orders o
+    LEFT JOIN orders_content oc
+    ON o.id = oc.order_id
+    GROUP BY o.id) AS s
+WHERE
+    o.id = s.id AND o.completed;
+
+UPDATE orders_promotions op
+SET
+    discount = 0
+FROM
+    orders o
+WHERE
+    o.id = op.order_id AND o.completed;
+
+END;
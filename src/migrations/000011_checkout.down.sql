@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS checked_out_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS number;
+
+DROP SEQUENCE IF EXISTS order_number_seq;
+
+END;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- human-friendly numbers are given to orders at checkout, gaps are possible when a checkout fails
+CREATE SEQUENCE order_number_seq;
+
+ALTER TABLE orders ADD COLUMN number TEXT UNIQUE;
+ALTER TABLE orders ADD COLUMN checked_out_at TIMESTAMP;
+
+-- orders completed before checkout was introduced are numbered in the order of creation
+UPDATE orders o
+SET
+    number = 'ORD-' || lpad(c.n::text, 8, '0')
+FROM
+    (SELECT id, row_number() OVER (ORDER BY created_at, id) AS n
+    FROM orders
+    WHERE completed) AS c
+WHERE
+    o.id = c.id;
+
+SELECT setval('order_number_seq', (SELECT COUNT(*) FROM orders WHERE completed) + 1, false);
+
+END;
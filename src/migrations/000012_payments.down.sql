@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS paid_at;
+
+DROP TABLE IF EXISTS payment_events CASCADE;
+DROP TABLE IF EXISTS payments CASCADE;
+
+END;
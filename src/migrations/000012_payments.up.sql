@@ -0,0 +1,35 @@
+BEGIN;
+
+-- a payment is an attempt to pay the order total through a payment provider, intent_id is the id
+-- of the payment at the provider; an order has at most one payment that is not failed or canceled
+CREATE TABLE payments(
+    id              UUID      PRIMARY KEY,
+    order_id        UUID      NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    provider        TEXT      NOT NULL,
+    intent_id       TEXT,
+    amount          BIGINT    NOT NULL CHECK (amount > 0),
+    currency        CHAR(3)   NOT NULL REFERENCES exchange_rates (currency),
+    status          TEXT      NOT NULL CHECK (status IN ('pending', 'authorized', 'captured', 'failed', 'canceled')),
+    captured_amount BIGINT    NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX payments_provider_intent_id_idx ON payments (provider, intent_id);
+CREATE UNIQUE INDEX payments_order_id_active_idx ON payments (order_id)
+    WHERE status IN ('pending', 'authorized', 'captured');
+CREATE INDEX payments_order_id_idx ON payments (order_id, created_at);
+
+-- webhook events already applied, providers deliver events at least once
+CREATE TABLE payment_events(
+    provider    TEXT      NOT NULL,
+    event_id    TEXT      NOT NULL,
+    payment_id  UUID      NOT NULL REFERENCES payments (id) ON DELETE CASCADE,
+    type        TEXT      NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (provider, event_id)
+);
+
+ALTER TABLE orders ADD COLUMN paid_at TIMESTAMP;
+
+END;
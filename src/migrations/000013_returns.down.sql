@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS refunds CASCADE;
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+DROP TABLE IF EXISTS returns_content CASCADE;
+DROP TABLE IF EXISTS returns CASCADE;
+
+END;
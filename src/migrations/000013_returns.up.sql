@@ -0,0 +1,52 @@
+BEGIN;
+
+-- a return is requested by the customer for lines of a checked out order, amount is the refund suggested
+-- for the returned products: their part of the frozen line total minus discount plus tax if it was added on top
+CREATE TABLE returns(
+    id         UUID      PRIMARY KEY,
+    order_id   UUID      NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    user_id    UUID      NOT NULL,
+    status     TEXT      NOT NULL CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunded')),
+    reason     TEXT      NOT NULL,
+    note       TEXT      NOT NULL DEFAULT '',
+    amount     BIGINT    NOT NULL CHECK (amount >= 0),
+    currency   CHAR(3)   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX returns_order_id_idx ON returns (order_id, created_at);
+CREATE INDEX returns_status_idx ON returns (status, created_at);
+
+CREATE TABLE returns_content(
+    return_id  UUID NOT NULL REFERENCES returns (id) ON DELETE CASCADE,
+    order_id   UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity   INT  NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (return_id, product_id),
+    FOREIGN KEY (order_id, product_id) REFERENCES orders_content (order_id, product_id) ON DELETE CASCADE
+);
+
+CREATE INDEX returns_content_order_id_idx ON returns_content (order_id, product_id);
+
+-- refunds reserve their amount in payments.refunded_amount when created and release it if they fail
+ALTER TABLE payments ADD COLUMN refunded_amount BIGINT NOT NULL DEFAULT 0
+    CHECK (refunded_amount >= 0 AND refunded_amount <= captured_amount);
+
+CREATE TABLE refunds(
+    id                 UUID      PRIMARY KEY,
+    order_id           UUID      NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    payment_id         UUID      NOT NULL REFERENCES payments (id) ON DELETE CASCADE,
+    return_id          UUID      REFERENCES returns (id) ON DELETE SET NULL,
+    amount             BIGINT    NOT NULL CHECK (amount > 0),
+    currency           CHAR(3)   NOT NULL,
+    status             TEXT      NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    provider_refund_id TEXT,
+    reason             TEXT      NOT NULL DEFAULT '',
+    user_id            UUID,
+    created_at         TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refunds_order_id_idx ON refunds (order_id, created_at);
+
+END;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS carts_content CASCADE;
+DROP TABLE IF EXISTS carts CASCADE;
+
+END;
@@ -0,0 +1,27 @@
+BEGIN;
+
+-- a cart belongs to a user or, before login, to the anonymous token kept in the client cookie;
+-- carts don't hold stock, it is reserved when the cart is converted to an order
+CREATE TABLE carts(
+    id         UUID      PRIMARY KEY,
+    user_id    UUID      UNIQUE,
+    token      TEXT      UNIQUE,
+    currency   CHAR(3)   NOT NULL REFERENCES exchange_rates (currency),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK (user_id IS NOT NULL OR token IS NOT NULL)
+);
+
+CREATE INDEX carts_anonymous_updated_at_idx ON carts (updated_at) WHERE user_id IS NULL;
+
+-- added_price is the price in the cart currency when the product was added, to show price changes
+CREATE TABLE carts_content(
+    cart_id     UUID      NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    product_id  UUID      NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity    INT       NOT NULL CHECK (quantity > 0),
+    added_price BIGINT    NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (cart_id, product_id)
+);
+
+END;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS billing_address;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_address;
+ALTER TABLE orders DROP COLUMN IF EXISTS billing_address_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_address_id;
+DROP TABLE IF EXISTS user_addresses CASCADE;
+
+END;
@@ -0,0 +1,32 @@
+BEGIN;
+
+-- address book of the user, a user has at most one default billing and one default shipping address
+CREATE TABLE user_addresses(
+    id                  UUID      PRIMARY KEY,
+    user_id             UUID      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name                TEXT      NOT NULL,
+    line1               TEXT      NOT NULL,
+    line2               TEXT      NOT NULL DEFAULT '',
+    city                TEXT      NOT NULL,
+    region              TEXT      NOT NULL DEFAULT '',
+    postal_code         TEXT      NOT NULL DEFAULT '',
+    country             CHAR(2)   NOT NULL,
+    phone               TEXT      NOT NULL DEFAULT '',
+    is_default_billing  BOOLEAN   NOT NULL DEFAULT false,
+    is_default_shipping BOOLEAN   NOT NULL DEFAULT false,
+    created_at          TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at          TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_addresses_user_id_idx ON user_addresses (user_id, created_at);
+CREATE UNIQUE INDEX user_addresses_default_billing_idx ON user_addresses (user_id) WHERE is_default_billing;
+CREATE UNIQUE INDEX user_addresses_default_shipping_idx ON user_addresses (user_id) WHERE is_default_shipping;
+
+-- the addresses chosen for the draft order, their copies are kept in the order at checkout
+-- and don't change when the address book does
+ALTER TABLE orders ADD COLUMN shipping_address_id UUID REFERENCES user_addresses (id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN billing_address_id UUID REFERENCES user_addresses (id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN shipping_address JSONB;
+ALTER TABLE orders ADD COLUMN billing_address JSONB;
+
+END;
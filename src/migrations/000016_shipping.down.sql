@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_method;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_method_id;
+DROP TABLE IF EXISTS shipping_method_tiers CASCADE;
+DROP TABLE IF EXISTS shipping_methods CASCADE;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_dimensions_check;
+ALTER TABLE products DROP COLUMN IF EXISTS height;
+ALTER TABLE products DROP COLUMN IF EXISTS width;
+ALTER TABLE products DROP COLUMN IF EXISTS length;
+ALTER TABLE products DROP COLUMN IF EXISTS weight;
+
+END;
@@ -0,0 +1,37 @@
+BEGIN;
+
+-- optional physical properties of products: weight in grams and dimensions in millimeters
+ALTER TABLE products ADD COLUMN weight INT CHECK (weight >= 0);
+ALTER TABLE products ADD COLUMN length INT CHECK (length > 0);
+ALTER TABLE products ADD COLUMN width INT CHECK (width > 0);
+ALTER TABLE products ADD COLUMN height INT CHECK (height > 0);
+ALTER TABLE products ADD CONSTRAINT products_dimensions_check
+    CHECK ((length IS NULL) = (width IS NULL) AND (width IS NULL) = (height IS NULL));
+
+-- amounts of shipping methods are in the base currency and converted to the order currency;
+-- flat methods cost price, weight and quantity methods cost the price of the first tier
+-- the order weight in grams or the number of items fits in. Empty regions mean everywhere.
+CREATE TABLE shipping_methods(
+    id         UUID      PRIMARY KEY,
+    code       TEXT      NOT NULL UNIQUE,
+    name       TEXT      NOT NULL,
+    kind       TEXT      NOT NULL CHECK (kind IN ('flat', 'weight', 'quantity')),
+    price      BIGINT    NOT NULL DEFAULT 0 CHECK (price >= 0),
+    free_over  BIGINT    CHECK (free_over >= 0),
+    regions    TEXT[]    NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE shipping_method_tiers(
+    method_id UUID   NOT NULL REFERENCES shipping_methods (id) ON DELETE CASCADE,
+    up_to     BIGINT NOT NULL CHECK (up_to > 0),
+    price     BIGINT NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (method_id, up_to)
+);
+
+-- the chosen method of the draft order, its name and cost are frozen at checkout
+ALTER TABLE orders ADD COLUMN shipping_method_id UUID REFERENCES shipping_methods (id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN shipping_method TEXT;
+ALTER TABLE orders ADD COLUMN shipping BIGINT;
+
+END;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS orders_fulfillment_status_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS fulfillment_status;
+DROP TABLE IF EXISTS shipments_content CASCADE;
+DROP TABLE IF EXISTS shipments CASCADE;
+
+END;
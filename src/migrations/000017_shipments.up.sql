@@ -0,0 +1,35 @@
+BEGIN;
+
+-- a shipment is a parcel with a subset of the lines of a paid order handed over to a carrier
+CREATE TABLE shipments(
+    id              UUID      PRIMARY KEY,
+    order_id        UUID      NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    status          TEXT      NOT NULL CHECK (status IN ('shipped', 'delivered')),
+    carrier         TEXT      NOT NULL,
+    tracking_number TEXT      NOT NULL DEFAULT '',
+    user_id         UUID,
+    shipped_at      TIMESTAMP NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMP
+);
+
+CREATE INDEX shipments_order_id_idx ON shipments (order_id, shipped_at);
+CREATE INDEX shipments_status_idx ON shipments (status, shipped_at);
+
+CREATE TABLE shipments_content(
+    shipment_id UUID NOT NULL REFERENCES shipments (id) ON DELETE CASCADE,
+    order_id    UUID NOT NULL,
+    product_id  UUID NOT NULL,
+    quantity    INT  NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (shipment_id, product_id),
+    FOREIGN KEY (order_id, product_id) REFERENCES orders_content (order_id, product_id) ON DELETE CASCADE
+);
+
+CREATE INDEX shipments_content_order_id_idx ON shipments_content (order_id, product_id);
+
+-- the status is updated when shipments are created and delivered
+ALTER TABLE orders ADD COLUMN fulfillment_status TEXT NOT NULL DEFAULT 'unfulfilled'
+    CHECK (fulfillment_status IN ('unfulfilled', 'partially_shipped', 'shipped', 'delivered'));
+
+CREATE INDEX orders_fulfillment_status_idx ON orders (fulfillment_status, checked_out_at) WHERE completed;
+
+END;
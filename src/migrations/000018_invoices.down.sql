@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS invoices CASCADE;
+DROP TABLE IF EXISTS invoice_counters CASCADE;
+
+END;
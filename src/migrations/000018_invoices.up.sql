@@ -0,0 +1,22 @@
+BEGIN;
+
+-- invoice numbers are taken from the counter of the prefix in the transaction issuing the invoice,
+-- unlike a sequence the counter is rolled back with a failed transaction, so there are no gaps
+CREATE TABLE invoice_counters(
+    prefix      TEXT   PRIMARY KEY,
+    last_number BIGINT NOT NULL CHECK (last_number > 0)
+);
+
+-- an invoice is issued once for a checked out order, seller and buyer details are copied at that moment
+-- so the invoice is rendered the same way later
+CREATE TABLE invoices(
+    id          UUID      PRIMARY KEY,
+    order_id    UUID      NOT NULL UNIQUE REFERENCES orders (id) ON DELETE RESTRICT,
+    number      TEXT      NOT NULL UNIQUE,
+    issued_at   TIMESTAMP NOT NULL,
+    seller      JSONB     NOT NULL,
+    buyer       JSONB     NOT NULL,
+    minor_units INT       NOT NULL DEFAULT 2
+);
+
+END;
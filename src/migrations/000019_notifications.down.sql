@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS notifications CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
+
+END;
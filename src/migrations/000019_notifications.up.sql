@@ -0,0 +1,25 @@
+BEGIN;
+
+-- the language of the emails sent to the user
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
+
+-- notifications is the queue of rendered emails, a sender failure schedules the next attempt
+CREATE TABLE notifications(
+    id              UUID      PRIMARY KEY,
+    event           TEXT      NOT NULL,
+    user_id         UUID,
+    recipient       TEXT      NOT NULL,
+    subject         TEXT      NOT NULL,
+    body            TEXT      NOT NULL,
+    status          TEXT      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts        INT       NOT NULL DEFAULT 0,
+    last_error      TEXT      NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    sent_at         TIMESTAMP
+);
+
+CREATE INDEX notifications_pending_idx ON notifications (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, created_at);
+
+END;
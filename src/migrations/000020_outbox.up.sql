@@ -0,0 +1,19 @@
+BEGIN;
+
+-- outbox keeps domain events written in the transactions of the changes they report until the relay
+-- publishes them to the broker, the id is the order events are published in
+CREATE TABLE outbox(
+    id             BIGSERIAL PRIMARY KEY,
+    event_id       UUID      NOT NULL UNIQUE,
+    type           TEXT      NOT NULL,
+    aggregate_type TEXT      NOT NULL,
+    aggregate_id   TEXT      NOT NULL,
+    payload        JSONB     NOT NULL,
+    occurred_at    TIMESTAMP NOT NULL DEFAULT now(),
+    published_at   TIMESTAMP
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+
+END;
@@ -0,0 +1,47 @@
+BEGIN;
+
+-- webhook_endpoints are URLs of other systems receiving events of the subscribed types
+CREATE TABLE webhook_endpoints(
+    id          UUID      PRIMARY KEY,
+    url         TEXT      NOT NULL,
+    secret      TEXT      NOT NULL,
+    event_types TEXT[]    NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhook_endpoints_event_types_idx ON webhook_endpoints USING gin (event_types);
+
+-- a delivery of an event to an endpoint is created with the outbox event, it is retried until it is
+-- delivered or runs out of attempts and is dead
+CREATE TABLE webhook_deliveries(
+    id              BIGSERIAL PRIMARY KEY,
+    endpoint_id     UUID      NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+    event_id        UUID      NOT NULL,
+    event_type      TEXT      NOT NULL,
+    payload         JSONB     NOT NULL,
+    occurred_at     TIMESTAMP NOT NULL,
+    status          TEXT      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts        INT       NOT NULL DEFAULT 0,
+    last_error      TEXT      NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMP,
+    UNIQUE (endpoint_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_endpoint_id_idx ON webhook_deliveries (endpoint_id, id);
+
+-- webhook_attempts is the log of requests made for deliveries
+CREATE TABLE webhook_attempts(
+    id           BIGSERIAL PRIMARY KEY,
+    delivery_id  BIGINT    NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempted_at TIMESTAMP NOT NULL DEFAULT now(),
+    status_code  INT,
+    error        TEXT      NOT NULL DEFAULT '',
+    duration_ms  INT       NOT NULL
+);
+
+CREATE INDEX webhook_attempts_delivery_id_idx ON webhook_attempts (delivery_id, id);
+
+END;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS orders_promotions_changes ON orders_promotions;
+DROP TRIGGER IF EXISTS orders_content_changes ON orders_content;
+DROP TRIGGER IF EXISTS orders_changes ON orders;
+DROP FUNCTION IF EXISTS notify_order_change();
+
+END;
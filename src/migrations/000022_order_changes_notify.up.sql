@@ -0,0 +1,38 @@
+BEGIN;
+
+-- notify_order_change notifies listeners of the order_changes channel with the id of the changed order.
+-- Notifications are sent on commit and identical ones of a transaction are folded into one,
+-- so a transaction changing many lines of an order sends a single notification.
+CREATE OR REPLACE FUNCTION notify_order_change() RETURNS TRIGGER AS $order_changes$
+    DECLARE
+        changed_order_id UUID;
+    BEGIN
+        IF (TG_TABLE_NAME = 'orders') THEN
+            IF (TG_OP = 'DELETE') THEN
+                changed_order_id := OLD.id;
+            ELSE
+                changed_order_id := NEW.id;
+            END IF;
+        ELSIF (TG_OP = 'DELETE') THEN
+            changed_order_id := OLD.order_id;
+        ELSE
+            changed_order_id := NEW.order_id;
+        END IF;
+        PERFORM pg_notify('order_changes', changed_order_id::text);
+        RETURN NULL;
+    END;
+$order_changes$ LANGUAGE plpgsql;
+
+CREATE TRIGGER orders_changes
+AFTER INSERT OR UPDATE OR DELETE ON orders
+    FOR EACH ROW EXECUTE FUNCTION notify_order_change();
+
+CREATE TRIGGER orders_content_changes
+AFTER INSERT OR UPDATE OR DELETE ON orders_content
+    FOR EACH ROW EXECUTE FUNCTION notify_order_change();
+
+CREATE TRIGGER orders_promotions_changes
+AFTER INSERT OR UPDATE OR DELETE ON orders_promotions
+    FOR EACH ROW EXECUTE FUNCTION notify_order_change();
+
+END;
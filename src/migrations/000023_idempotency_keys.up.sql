@@ -0,0 +1,23 @@
+BEGIN;
+
+-- idempotency_keys keep the responses of mutating requests sent with the Idempotency-Key header, so retries
+-- of a request get the original response. Keys are unique per user, scope is empty for anonymous requests.
+-- A request holds the key until locked_until, status_code is set when its response is stored.
+CREATE TABLE idempotency_keys(
+    scope            TEXT      NOT NULL,
+    key              TEXT      NOT NULL,
+    method           TEXT      NOT NULL,
+    path             TEXT      NOT NULL,
+    fingerprint      TEXT      NOT NULL,
+    status_code      INT,
+    response_headers JSONB,
+    response_body    BYTEA,
+    created_at       TIMESTAMP NOT NULL DEFAULT now(),
+    locked_until     TIMESTAMP NOT NULL,
+    expires_at       TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+END;
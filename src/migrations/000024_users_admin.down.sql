@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+
+END;
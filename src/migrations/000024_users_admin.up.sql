@@ -0,0 +1,34 @@
+BEGIN;
+
+-- is_admin grants the admin routes, it is set only for the admin user created or promoted by the app from its config
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- users with the same email can't be merged automatically as their orders, carts and addresses would be mixed,
+-- so the migration fails listing them and the duplicates have to be resolved by hand first
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT
+        string_agg(d.email || ' (' || d.ids || ')', ', ')
+    INTO
+        duplicates
+    FROM (
+        SELECT
+            u.email, string_agg(u.id::text, ', ' ORDER BY u.id) AS ids
+        FROM
+            users u
+        GROUP BY
+            u.email
+        HAVING
+            COUNT(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users_email_key can not be created, emails are used by several users: %', duplicates;
+    END IF;
+END $$;
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+END;
@@ -0,0 +1,34 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS product_price(UUID, CHAR(3), TIMESTAMPTZ);
+CREATE FUNCTION product_price(product UUID, cur CHAR(3), at TIMESTAMP) RETURNS BIGINT AS $$
+    SELECT COALESCE(
+        (SELECT
+            pp.price
+        FROM
+            product_prices pp
+        WHERE
+            pp.product_id = product AND pp.currency = cur
+            AND pp.effective_from <= at AND (pp.effective_to IS NULL OR pp.effective_to > at)
+        ORDER BY
+            pp.effective_from DESC, pp.id DESC
+        LIMIT 1),
+        (SELECT
+            convert_amount(pp.price, pp.currency, cur)
+        FROM
+            product_prices pp
+        WHERE
+            pp.product_id = product AND pp.currency = base_currency()
+            AND pp.effective_from <= at AND (pp.effective_to IS NULL OR pp.effective_to > at)
+        ORDER BY
+            pp.effective_from DESC, pp.id DESC
+        LIMIT 1),
+        (SELECT convert_amount(p.price, base_currency(), cur) FROM products p WHERE p.id = product)
+    )
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE product_prices ALTER COLUMN effective_to TYPE TIMESTAMP USING effective_to AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE product_prices ALTER COLUMN effective_from TYPE TIMESTAMP USING effective_from AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE product_prices ALTER COLUMN price TYPE INT;
+
+END;
@@ -0,0 +1,96 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test scenario:
+// 1. Load 100 keys concurrently
+// 2. Check that all of them were resolved with one batch call
+// 3. Load the same key again and check that it is served from cache
+// 4. Check that a key missing from the batch result returns ErrNoResult
+func TestLoaderBatching(t *testing.T) {
+	var calls int32
+	loader := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+		atomic.AddInt32(&calls, 1)
+		r := make(map[int]string, len(keys))
+		for _, k := range keys {
+			if k >= 0 {
+				r[k] = strconv.Itoa(k)
+			}
+		}
+		return r, nil
+	}, 10*time.Millisecond, 0)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := loader.Load(ctx, i)
+			if assert.NoError(t, err) {
+				assert.Equal(t, strconv.Itoa(i), v)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	v, err := loader.Load(ctx, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	_, err = loader.Load(ctx, -1)
+	assert.ErrorIs(t, err, ErrNoResult)
+}
+
+func TestLoaderMaxBatch(t *testing.T) {
+	var calls int32
+	loader := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		atomic.AddInt32(&calls, 1)
+		assert.LessOrEqual(t, len(keys), 10)
+		r := make(map[int]int, len(keys))
+		for _, k := range keys {
+			r[k] = k * k
+		}
+		return r, nil
+	}, 10*time.Millisecond, 10)
+
+	keys := make([]int, 50)
+	for i := range keys {
+		keys[i] = i
+	}
+	values, errs := loader.LoadMany(context.Background(), keys)
+	for i := range keys {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, i*i, values[i])
+	}
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&calls), int32(5))
+}
+
+func TestLoaderErrorIsNotCached(t *testing.T) {
+	var calls int32
+	loader := New(func(ctx context.Context, keys []string) (map[string]string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errors.New("db is down")
+		}
+		return map[string]string{keys[0]: "ok"}, nil
+	}, time.Millisecond, 0)
+
+	_, err := loader.Load(context.Background(), "key")
+	assert.Error(t, err)
+
+	v, err := loader.Load(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", v)
+}
@@ -71,3 +71,64 @@ func DetailedPgError(err error) error {
 	}
 	return nil
 }
+
+const (
+	foreignKeyViolationCode  = "23503"
+	uniqueViolationCode      = "23505"
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+// IsForeignKeyViolation reports whether the query referenced a row that does not exist
+func IsForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == foreignKeyViolationCode
+	}
+	return false
+}
+
+// IsUniqueViolation reports whether the query tried to insert a duplicate of a unique value
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == uniqueViolationCode
+	}
+	return false
+}
+
+// IsRetryable reports whether the transaction failed due to concurrent access
+// and can be safely run again
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode
+	}
+	return false
+}
+
+// RunInTx runs fn in a transaction and commits it if fn returns nil.
+// The whole transaction is retried up to maxAttempts times on deadlocks, and on serialization failures
+// which happen only with the repeatable read and serializable isolation levels of opts,
+// so fn must not have side effects outside the transaction.
+func RunInTx(ctx context.Context, c Client, opts pgx.TxOptions, maxAttempts int, fn func(tx pgx.Tx) error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = runInTx(ctx, c, opts, fn)
+		if err == nil || !IsRetryable(err) || attempt >= maxAttempts {
+			return err
+		}
+		logging.LoggerFromContext(ctx).Tracef("retrying Tx after error: %v (attempt %d)", err, attempt)
+	}
+}
+
+func runInTx(ctx context.Context, c Client, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := c.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}